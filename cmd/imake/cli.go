@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+	"github.com/gshireesh/imake/pkg/runner"
+)
+
+// listTarget is the JSON form of a target printed by `imake list --json`.
+type listTarget struct {
+	Name  string   `json:"name"`
+	Doc   string   `json:"doc,omitempty"`
+	File  string   `json:"file"`
+	Line  int      `json:"line"`
+	Deps  []string `json:"deps,omitempty"`
+	Phony bool     `json:"phony,omitempty"`
+	Group string   `json:"group,omitempty"`
+}
+
+// listCommand prints the targets of r, as a table by default.
+func listCommand(r runner.Runner, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	plain := fs.Bool("plain", false, "print target names only, one per line")
+	asJSON := fs.Bool("json", false, "print targets as JSON")
+	all := fs.Bool("all", false, "include file targets, pattern rules and internal targets")
+	fs.Parse(args)
+	if *plain && *asJSON {
+		return errors.New("list: -plain and -json cannot be combined")
+	}
+
+	discovered, err := r.Discover()
+	if err != nil {
+		return err
+	}
+	var targets []runner.Target
+	for _, t := range discovered {
+		if *all || t.Kind == makefile.KindTarget {
+			targets = append(targets, t)
+		}
+	}
+
+	switch {
+	case *plain:
+		for _, t := range targets {
+			fmt.Println(t.Name)
+		}
+	case *asJSON:
+		list := make([]listTarget, 0, len(targets))
+		for _, t := range targets {
+			list = append(list, listTarget{t.Name, t.Doc, t.File, t.Line, t.Deps, t.Phony, t.Group})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, t := range targets {
+			fmt.Fprintf(w, "%s\t%s\n", t.Name, t.Doc)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+// exportHelpCommand prints the documentation of the targets of r in the
+// format picked with -format.
+func exportHelpCommand(r runner.Runner, args []string) error {
+	fs := flag.NewFlagSet("export-help", flag.ExitOnError)
+	format := fs.String("format", makefile.HelpText, "output format: "+strings.Join(makefile.HelpFormats, ", "))
+	fs.Parse(args)
+
+	targets, err := r.Discover()
+	if err != nil {
+		return err
+	}
+	return makefile.WriteHelp(os.Stdout, targets, *format)
+}
+
+// runEvent is a line printed by `imake run --json`.
+type runEvent struct {
+	Event    string    `json:"event"` // started, stdout, stderr or exited
+	Time     time.Time `json:"time"`
+	Target   string    `json:"target,omitempty"`
+	Command  []string  `json:"command,omitempty"`
+	Line     *string   `json:"line,omitempty"`
+	Code     *int      `json:"code,omitempty"`
+	Duration float64   `json:"duration,omitempty"` // seconds
+}
+
+// runCommand runs a target of r with the remaining arguments, streaming
+// its output, and returns the exit code of the command. With --json,
+// anywhere in args, the output is printed as JSON events instead.
+func runCommand(r runner.Runner, args []string) (int, error) {
+	var rest []string
+	asJSON := false
+	for _, arg := range args {
+		if arg == "--json" || arg == "-json" {
+			asJSON = true
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	if len(rest) == 0 {
+		return 0, errors.New("run: missing target")
+	}
+	cmd := r.Exec(rest[0], rest[1:])
+	if asJSON {
+		return runJSON(cmd, rest[0])
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+// runJSON runs cmd for target, printing newline-delimited runEvents: when
+// it starts, for each line of its output and when it exits.
+func runJSON(cmd *exec.Cmd, target string) (int, error) {
+	var mu sync.Mutex
+	enc := json.NewEncoder(os.Stdout)
+	emit := func(e runEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		e.Time = time.Now()
+		enc.Encode(e)
+	}
+
+	cmd.Stdin = os.Stdin
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return 0, err
+	}
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	emit(runEvent{Event: "started", Target: target, Command: cmd.Args})
+
+	var wg sync.WaitGroup
+	stream := func(r io.Reader, event string) {
+		defer wg.Done()
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			line := scanner.Text()
+			emit(runEvent{Event: event, Line: &line})
+		}
+	}
+	wg.Add(2)
+	go stream(stdout, "stdout")
+	go stream(stderr, "stderr")
+	wg.Wait()
+
+	err = cmd.Wait()
+	code := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	} else if err != nil {
+		return 0, err
+	}
+	emit(runEvent{Event: "exited", Code: &code, Duration: time.Since(started).Seconds()})
+	return code, nil
+}
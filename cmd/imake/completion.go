@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// The completion scripts complete the subcommands and, after `run`, the
+// targets printed by `imake list --plain` for the build file selected by
+// the flags typed so far.
+const (
+	bashCompletion = `# bash completion for imake
+_imake() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	local i cmd flags=()
+	case $prev in
+	-f|-file|--file) return ;;
+	-runner|--runner) COMPREPLY=($(compgen -W "make task just npm cargo-make rake compose gradle maven bazel go" -- "$cur")); return ;;
+	esac
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case ${COMP_WORDS[i]} in
+		-f|-file|--file|-runner|--runner)
+			flags+=("${COMP_WORDS[i]}" "${COMP_WORDS[i+1]}")
+			((i++))
+			;;
+		list|run|export-help|serve|completion)
+			cmd=${COMP_WORDS[i]}
+			break
+			;;
+		esac
+	done
+	case $cmd in
+	"") COMPREPLY=($(compgen -W "list run export-help serve completion $(imake "${flags[@]}" list --plain 2>/dev/null)" -- "$cur")) ;;
+	list) COMPREPLY=($(compgen -W "-plain -json -all" -- "$cur")) ;;
+	serve) COMPREPLY=($(compgen -W "-addr" -- "$cur")) ;;
+	export-help)
+		if [[ $prev == -format ]]; then
+			COMPREPLY=($(compgen -W "text markdown recipe" -- "$cur"))
+		else
+			COMPREPLY=($(compgen -W "-format" -- "$cur"))
+		fi
+		;;
+	run)
+		if ((i + 1 == COMP_CWORD)); then
+			COMPREPLY=($(compgen -W "$(imake "${flags[@]}" list --plain 2>/dev/null)" -- "$cur"))
+		fi
+		;;
+	completion) COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+	esac
+}
+complete -o default -F _imake imake
+`
+
+	zshCompletion = `#compdef imake
+_imake() {
+	local -a flags
+	local i
+	case ${words[CURRENT-1]} in
+	-f|-file|--file) _files; return ;;
+	-runner|--runner) compadd make task just npm cargo-make rake compose gradle maven bazel go; return ;;
+	esac
+	for ((i = 2; i < CURRENT; i++)); do
+		case ${words[i]} in
+		-f|-file|--file|-runner|--runner)
+			flags+=(${words[i]} ${words[i+1]})
+			((i++))
+			;;
+		list) compadd -- -plain -json -all; return ;;
+		serve) compadd -- -addr; return ;;
+		export-help)
+			if [[ ${words[CURRENT-1]} == -format ]]; then
+				compadd text markdown recipe
+			else
+				compadd -- -format
+			fi
+			return
+			;;
+		run)
+			if ((i + 1 == CURRENT)); then
+				compadd -- ${(f)"$(imake $flags list --plain 2>/dev/null)"}
+			else
+				_files
+			fi
+			return
+			;;
+		completion) compadd bash zsh fish; return ;;
+		esac
+	done
+	compadd list run export-help serve completion ${(f)"$(imake $flags list --plain 2>/dev/null)"}
+}
+if [ "$funcstack[1]" = "_imake" ]; then
+	_imake "$@"
+else
+	compdef _imake imake
+fi
+`
+
+	fishCompletion = `# fish completion for imake
+function __imake_targets
+	set -l tokens (commandline -opc)
+	set -l flags
+	set -l i 2
+	while test $i -lt (count $tokens)
+		switch $tokens[$i]
+			case -f -file --file -runner --runner
+				set -a flags $tokens[$i] $tokens[(math $i + 1)]
+				set i (math $i + 1)
+		end
+		set i (math $i + 1)
+	end
+	imake $flags list --plain 2>/dev/null
+end
+
+complete -c imake -f
+complete -c imake -o f -o file -r -F -d 'Makefile to load'
+complete -c imake -o runner -x -a 'make task just npm cargo-make rake compose gradle maven bazel go' -d 'Runner to use'
+complete -c imake -n __fish_use_subcommand -a list -d 'Print the targets'
+complete -c imake -n __fish_use_subcommand -a run -d 'Run a target'
+complete -c imake -n __fish_use_subcommand -a export-help -d 'Print the documentation of the targets'
+complete -c imake -n __fish_use_subcommand -a serve -d 'Serve an HTTP API of the targets and runs'
+complete -c imake -n __fish_use_subcommand -a completion -d 'Print a completion script'
+complete -c imake -n __fish_use_subcommand -a '(__imake_targets)'
+complete -c imake -n '__fish_seen_subcommand_from list' -o plain -o json -o all
+complete -c imake -n '__fish_seen_subcommand_from run' -a '(__imake_targets)'
+complete -c imake -n '__fish_seen_subcommand_from export-help' -o format -x -a 'text markdown recipe'
+complete -c imake -n '__fish_seen_subcommand_from serve' -o addr -x
+complete -c imake -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'
+`
+)
+
+// completionCommand prints the completion script for the shell in args.
+func completionCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("completion: expected one of bash, zsh or fish")
+	}
+	scripts := map[string]string{
+		"bash": bashCompletion,
+		"zsh":  zshCompletion,
+		"fish": fishCompletion,
+	}
+	script, ok := scripts[args[0]]
+	if !ok {
+		return fmt.Errorf("completion: unknown shell %q: must be bash, zsh or fish", args[0])
+	}
+	_, err := fmt.Fprint(os.Stdout, script)
+	return err
+}
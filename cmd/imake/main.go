@@ -0,0 +1,152 @@
+// Command imake is an interactive terminal UI for running Makefile
+// targets and the tasks of other build tools.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gshireesh/imake"
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/gshireesh/imake/pkg/server"
+	"github.com/gshireesh/imake/pkg/ui"
+)
+
+func main() {
+	var (
+		makefilePath string
+		runnerName   string
+		vim          bool
+		fresh        bool
+		ansiMode     string
+		searchDepth  int
+		makeDatabase bool
+		serveAddr    string
+		noTUI        bool
+	)
+	flag.StringVar(&makefilePath, "file", "", "path to the Makefile to load")
+	flag.StringVar(&makefilePath, "f", "", "path to the Makefile to load (shorthand)")
+	flag.StringVar(&runnerName, "runner", "", "backend to use: make, task, just, npm, cargo-make, rake, compose, gradle, maven, bazel or go (detected by default)")
+	flag.BoolVar(&vim, "vim", false, "use vim-style keybindings")
+	flag.BoolVar(&fresh, "fresh", false, "start without restoring the last session")
+	flag.IntVar(&searchDepth, "search-depth", 5, "how many parent directories to search for a build file (0 to only use the current one)")
+	flag.BoolVar(&makeDatabase, "make-database", false, "list the targets of make's database (make -qp), including eval'd and computed rules")
+	flag.BoolVar(&noTUI, "no-tui", false, "run the target given without the UI, like imake run")
+	flag.StringVar(&serveAddr, "serve", "", "also serve the HTTP API at this address, e.g. "+defaultServeAddr+", sharing the runs with the UI")
+	flag.StringVar(&ansiMode, "ansi", ui.ANSIRender, "how to handle ANSI escapes in command output: render or strip")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), `Usage:
+  imake [flags]                      open the interactive UI
+  imake [flags] TARGET [ARGS]        open the UI running TARGET, or only run it
+                                     with -no-tui
+  imake [flags] list [-plain|-json]  print the targets
+  imake [flags] run TARGET [ARGS] [--json]
+                                     run a target, printing JSON events with --json
+  imake [flags] export-help [-format text|markdown|recipe]
+                                     print the documentation of the targets
+  imake [flags] serve [-addr ADDR]   serve an HTTP API of the targets and their runs
+  imake completion bash|zsh|fish     print a shell completion script
+
+Flags:
+`)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if ansiMode != ui.ANSIRender && ansiMode != ui.ANSIStrip {
+		log.Fatalf("invalid -ansi value %q: must be %s or %s", ansiMode, ui.ANSIRender, ui.ANSIStrip)
+	}
+
+	if makefilePath == "" {
+		// Like git, work from the nearest parent with a build file so that
+		// commands run where it is.
+		if dir, ok := runner.FindUp(".", runnerName, searchDepth); ok {
+			if err := os.Chdir(dir); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	r, err := runner.Detect(makefilePath, runnerName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if d, ok := r.(runner.DatabaseReader); ok && makeDatabase {
+		d.UseDatabase(true)
+	}
+	switch flag.Arg(0) {
+	case "list":
+		if err := listCommand(r, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+	case "run":
+		code, err := runCommand(r, flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(code)
+	case "export-help":
+		if err := exportHelpCommand(r, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+	case "serve":
+		if err := serveCommand(r, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+	case "completion":
+		if err := completionCommand(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		// Anything else is a target to run as soon as the UI starts, so
+		// that `imake test` can be aliased.
+		args := flag.Args()
+		if noTUI {
+			if len(args) == 0 {
+				fmt.Fprintln(os.Stderr, "imake: -no-tui needs a target to run")
+				flag.Usage()
+				os.Exit(2)
+			}
+			code, err := runCommand(r, args)
+			if err != nil {
+				log.Fatal(err)
+			}
+			os.Exit(code)
+		}
+		cfg := imake.Config{Runner: r, ANSI: ansiMode, Vim: vim, Fresh: fresh}
+		if len(args) > 0 {
+			cfg.Target, cfg.Args = args[0], args[1:]
+		}
+		if serveAddr != "" {
+			ln, err := listenAPI(serveAddr)
+			if err != nil {
+				log.Fatal(err)
+			}
+			cfg.Runs = runner.NewRunManager()
+			go http.Serve(ln, server.New(r, cfg.Runs))
+		}
+		if err := imake.Run(context.Background(), cfg); err != nil {
+			var crash *imake.CrashError
+			if errors.As(err, &crash) {
+				reportCrash(crash)
+				os.Exit(2)
+			}
+			log.Fatal(err)
+		}
+	}
+}
+
+// reportCrash tells the user that imake crashed and where the report to
+// attach to a bug report is.
+func reportCrash(crash *imake.CrashError) {
+	fmt.Fprintln(os.Stderr, crash)
+	if crash.LogErr != nil {
+		fmt.Fprintf(os.Stderr, "The crash log could not be written (%v), so here is the stack trace:\n\n%s", crash.LogErr, crash.Stack)
+	} else {
+		fmt.Fprintf(os.Stderr, "The stack trace and recent logs are in %s.\n", crash.Log)
+	}
+	fmt.Fprintln(os.Stderr, "Please attach them to a bug report at https://github.com/gshireesh/imake/issues.")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/gshireesh/imake/pkg/server"
+)
+
+// defaultServeAddr is where the HTTP API is served unless told otherwise:
+// on this machine only.
+const defaultServeAddr = "127.0.0.1:7878"
+
+// listenAPI listens on addr for the HTTP API, warning when other machines
+// can connect, as the API runs any target.
+func listenAPI(addr string) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if !server.IsLoopback(addr) {
+		log.Printf("imake: warning: %s accepts connections from other machines, which can run any target", addr)
+	}
+	return ln, nil
+}
+
+// serveCommand serves the HTTP API of the targets of r until interrupted,
+// then cancels the runs left.
+func serveCommand(r runner.Runner, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", defaultServeAddr, "address to listen on")
+	fs.Parse(args)
+
+	ln, err := listenAPI(*addr)
+	if err != nil {
+		return err
+	}
+	runs := runner.NewRunManager()
+	srv := &http.Server{Handler: server.New(r, runs)}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		runs.CancelAll()
+		// Close rather than Shutdown: the log streams of the runs would
+		// keep it waiting.
+		srv.Close()
+	}()
+	log.Printf("imake: serving the targets of %s on http://%s", r.File(), ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
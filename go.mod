@@ -3,7 +3,14 @@ module github.com/gshireesh/imake
 go 1.22.4
 
 require (
-	github.com/jroimartin/gocui v0.5.0 // indirect
+	github.com/creack/pty v1.1.21
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/jroimartin/gocui v0.5.0
+	github.com/nsf/termbox-go v1.1.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/mattn/go-runewidth v0.0.9 // indirect
-	github.com/nsf/termbox-go v1.1.1 // indirect
+	golang.org/x/sys v0.4.0 // indirect
 )
@@ -0,0 +1,105 @@
+// Package imake embeds the imake terminal UI in other programs:
+//
+//	err := imake.Run(ctx, imake.Config{Makefile: "build/Makefile"})
+//
+// The UI keeps its state in package variables, so only one Run may be
+// active at a time; each Run starts afresh, without the tabs, marks and
+// settings of the last one.
+package imake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/gshireesh/imake/pkg/ui"
+)
+
+// Config configures a Run. The zero Config behaves like the imake command
+// started without flags in the working directory.
+type Config struct {
+	// Runner discovers and runs the targets. If nil, one is picked from
+	// Makefile and RunnerName like the imake command does.
+	Runner runner.Runner
+	// Makefile is the path of a Makefile to load instead of looking for a
+	// build file.
+	Makefile string
+	// RunnerName picks a runner by name, such as "just", instead of
+	// detecting it.
+	RunnerName string
+	// Theme replaces the theme of the user's config if not nil.
+	Theme *Theme
+	// Keybindings override the keys of actions in the user's config, by
+	// action name, e.g. {"quit": {"ctrl+c", "q"}}.
+	Keybindings map[string][]string
+	// Vim selects the vim keybinding profile.
+	Vim bool
+	// ANSI selects how escape sequences in command output are handled:
+	// "render" (the default) or "strip".
+	ANSI string
+	// Fresh starts without restoring the last session in the directory.
+	Fresh bool
+	// Target, if set, is selected and run with Args as soon as the UI
+	// starts.
+	Target string
+	Args   []string
+	// Runs, if not nil, own the running commands, to share them with the
+	// HTTP API of package server served alongside the UI.
+	Runs *runner.RunManager
+}
+
+// Theme holds the colors of the UI; see the theme section of the config.
+type Theme = ui.Theme
+
+// Runner discovers and runs the targets of a build tool.
+type Runner = runner.Runner
+
+// CrashError is returned by Run when the UI panicked, once the terminal
+// is given back; see ui.CrashError.
+type CrashError = ui.CrashError
+
+// RegisterRunner adds a custom runner, called name, for the build files
+// named files, which imake then detects like the built-in ones. open
+// returns the runner for the build file at path. It must be called before
+// Run.
+func RegisterRunner(name string, files []string, open func(path string) Runner) {
+	runner.Register(name, files, open)
+}
+
+// Run shows the UI until the user quits or ctx is done, in which case it
+// returns ctx.Err(). If the UI panics, Run returns a *CrashError instead
+// of crashing the program.
+func Run(ctx context.Context, cfg Config) error {
+	switch cfg.ANSI {
+	case "", ui.ANSIRender, ui.ANSIStrip:
+	default:
+		return fmt.Errorf("invalid ANSI mode %q: must be %s or %s", cfg.ANSI, ui.ANSIRender, ui.ANSIStrip)
+	}
+	r := cfg.Runner
+	if r == nil {
+		var err error
+		if r, err = runner.Detect(cfg.Makefile, cfg.RunnerName); err != nil {
+			return err
+		}
+	}
+	keybindings := make(map[string]ui.KeyList, len(cfg.Keybindings))
+	for name, keys := range cfg.Keybindings {
+		keybindings[name] = keys
+	}
+	err := ui.Run(ui.Options{
+		Runner:      r,
+		ANSI:        cfg.ANSI,
+		Vim:         cfg.Vim,
+		Fresh:       cfg.Fresh,
+		Theme:       cfg.Theme,
+		Keybindings: keybindings,
+		Context:     ctx,
+		Target:      cfg.Target,
+		Args:        cfg.Args,
+		Runs:        cfg.Runs,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,159 @@
+package makefile
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// databaseRuleRegexp matches the rule lines of make's database, such
+	// as "build: dep1 dep2" or "clean::".
+	databaseRuleRegexp = regexp.MustCompile(`^([^#\s:][^:]*?)(::?)(?:\s+(.*))?$`)
+	// recipeFromRegexp matches the comment giving the location of a recipe
+	// in make's database.
+	recipeFromRegexp = regexp.MustCompile(`^#\s+recipe to execute \(from '(.+)', line (\d+)\):$`)
+)
+
+// ParseDatabase returns the targets of the database make prints with -p,
+// such as rules defined by $(eval ...) or computed names, which reading the
+// Makefile cannot find. make lists them in no particular order. Pattern
+// rules, special targets and files make merely knows about are left out.
+func ParseDatabase(r io.Reader) ([]Target, error) {
+	var targets []Target
+	index := make(map[string]int)
+	var current []int // the targets of the last rule line
+	inFiles, notTarget := false, false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "# Files":
+			inFiles = true
+			continue
+		case strings.HasPrefix(line, "# files hash-table stats"):
+			inFiles = false
+			continue
+		case !inFiles:
+			continue
+		case line == "":
+			current, notTarget = nil, false
+			continue
+		case line == "# Not a target:":
+			notTarget = true
+			continue
+		case strings.HasPrefix(line, "\t"):
+			for _, i := range current {
+				targets[i].Recipe = append(targets[i].Recipe, line[1:])
+			}
+			continue
+		case strings.HasPrefix(line, "#"):
+			if line == "#  Phony target (prerequisite of .PHONY)." {
+				for _, i := range current {
+					targets[i].Phony = true
+				}
+			}
+			if m := recipeFromRegexp.FindStringSubmatch(line); m != nil {
+				n, _ := strconv.Atoi(m[2])
+				for _, i := range current {
+					if targets[i].File == "" {
+						targets[i].File, targets[i].Line = m[1], n
+						targets[i].Locations = []Location{{m[1], n}}
+					}
+				}
+			}
+			continue
+		}
+
+		m := databaseRuleRegexp.FindStringSubmatch(line)
+		if m == nil || notTarget {
+			continue
+		}
+		rest := m[3]
+		if before, _, _ := strings.Cut(rest, "|"); strings.Contains(before, "=") {
+			// A target-specific variable such as "debug: CFLAGS += -g".
+			continue
+		}
+		current = nil
+		for _, name := range strings.Fields(m[1]) {
+			if name == ".PHONY" || specialRegexp.MatchString(name) {
+				continue
+			}
+			i, ok := index[name]
+			if !ok {
+				i = len(targets)
+				index[name] = i
+				targets = append(targets, Target{Name: name})
+			}
+			t := &targets[i]
+			normal, orderOnly, _ := strings.Cut(rest, "|")
+			for _, dep := range strings.Fields(normal) {
+				if !contains(t.Deps, dep) {
+					t.Deps = append(t.Deps, dep)
+				}
+			}
+			for _, dep := range strings.Fields(orderOnly) {
+				if !contains(t.OrderOnly, dep) {
+					t.OrderOnly = append(t.OrderOnly, dep)
+					t.Deps = append(t.Deps, dep)
+				}
+			}
+			t.DoubleColon = t.DoubleColon || m[2] == "::"
+			current = append(current, i)
+		}
+	}
+	return targets, scanner.Err()
+}
+
+// WithDatabase returns the targets of make's database, as ParseDatabase
+// returns them, documented by the targets read from the Makefile. The
+// targets read keep their order, those read from the Makefile alone are
+// dropped, as make does not define them, and the rest follow by location
+// and name.
+// Targets without a location, such as rules without a recipe, are put in
+// file.
+func WithDatabase(read, database []Target, file string) []Target {
+	known := make(map[string]Target)
+	for _, t := range database {
+		known[t.Name] = t
+	}
+	var targets []Target
+	listed := make(map[string]bool)
+	for _, t := range read {
+		// Pattern rules are listed apart from the targets in the database.
+		if _, ok := known[t.Name]; ok || t.Kind == KindPattern {
+			targets = append(targets, t)
+			listed[t.Name] = true
+		}
+	}
+	start := len(targets)
+	for _, t := range database {
+		if listed[t.Name] {
+			continue
+		}
+		if t.File == "" {
+			t.File = file
+		}
+		t.Kind = kind(t)
+		if t.Doc == "" {
+			t.Doc = strings.Join(t.Deps, " ")
+		}
+		targets = append(targets, t)
+	}
+	added := targets[start:]
+	sort.SliceStable(added, func(i, j int) bool {
+		if added[i].File != added[j].File {
+			return added[i].File < added[j].File
+		}
+		if added[i].Line != added[j].Line {
+			return added[i].Line < added[j].Line
+		}
+		return added[i].Name < added[j].Name
+	})
+	return targets
+}
@@ -0,0 +1,99 @@
+package makefile
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// automaticRegexp matches the automatic variables of a recipe, such as $@
+// or $(<D).
+var automaticRegexp = regexp.MustCompile(`\$(?:([@<^+?*|])|[({]([@<^+?*])([DF])[)}])`)
+
+// automaticMeanings describes each automatic variable.
+var automaticMeanings = map[string]string{
+	"@": "the target",
+	"<": "the first prerequisite",
+	"^": "all prerequisites, without duplicates",
+	"+": "all prerequisites, with duplicates",
+	"?": "the prerequisites newer than the target",
+	"*": "the stem the % of a pattern rule matched",
+	"|": "the order-only prerequisites",
+}
+
+// ExplainAutomatic describes the automatic variables, such as $@, used by
+// the prerequisites, recipe and target-specific variables of t, in the
+// order they first appear. Those known without running make are given
+// with their value for t: "$@ = build (the target)".
+func ExplainAutomatic(t Target) []string {
+	text := strings.Join(t.Deps, " ") + "\n" + strings.Join(t.Recipe, "\n") + "\n" + strings.Join(t.Vars, "\n")
+	// $$ is a literal dollar sign, for the shell.
+	text = strings.ReplaceAll(text, "$$", "")
+	var lines []string
+	seen := make(map[string]bool)
+	for _, m := range automaticRegexp.FindAllStringSubmatch(text, -1) {
+		name, part := m[1], m[3]
+		if name == "" {
+			name = m[2]
+		}
+		if seen[m[0]] {
+			continue
+		}
+		seen[m[0]] = true
+
+		meaning := automaticMeanings[name]
+		switch part {
+		case "D":
+			meaning = "the directory of " + meaning
+		case "F":
+			meaning = "the file name of " + meaning
+		}
+		value, known := automaticValue(t, name)
+		if !known {
+			lines = append(lines, fmt.Sprintf("%s: %s", m[0], meaning))
+			continue
+		}
+		if part != "" {
+			words := strings.Fields(value)
+			for i, w := range words {
+				if part == "D" {
+					words[i] = path.Dir(w)
+				} else {
+					words[i] = path.Base(w)
+				}
+			}
+			value = strings.Join(words, " ")
+		}
+		lines = append(lines, fmt.Sprintf("%s = %s (%s)", m[0], value, meaning))
+	}
+	return lines
+}
+
+// automaticValue returns the value of the automatic variable name for t,
+// if it can be known without running make.
+func automaticValue(t Target, name string) (string, bool) {
+	switch name {
+	case "@":
+		return t.Name, !strings.Contains(t.Name, "%")
+	case "<":
+		if len(t.Deps) > 0 && !strings.Contains(t.Deps[0], "%") {
+			return t.Deps[0], true
+		}
+	case "^":
+		var deps []string
+		for _, dep := range t.Deps {
+			if !contains(deps, dep) && !contains(t.OrderOnly, dep) {
+				deps = append(deps, dep)
+			}
+		}
+		if len(deps) > 0 && !strings.Contains(strings.Join(deps, " "), "%") {
+			return strings.Join(deps, " "), true
+		}
+	case "|":
+		if len(t.OrderOnly) > 0 {
+			return strings.Join(t.OrderOnly, " "), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,135 @@
+package makefile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Help formats accepted by WriteHelp.
+const (
+	HelpText     = "text"     // aligned names and docs, grouped by section
+	HelpMarkdown = "markdown" // a Markdown table
+	HelpRecipe   = "recipe"   // a help target echoing the text format
+)
+
+// HelpFormats lists the formats accepted by WriteHelp.
+var HelpFormats = []string{HelpText, HelpMarkdown, HelpRecipe}
+
+// WriteHelp writes the documentation of the runnable targets among
+// targets to w in format, listing them in order within their groups.
+func WriteHelp(w io.Writer, targets []Target, format string) error {
+	var runnable []Target
+	for _, t := range targets {
+		if t.Kind == KindTarget {
+			runnable = append(runnable, t)
+		}
+	}
+	switch format {
+	case HelpText:
+		return writeHelpText(w, runnable)
+	case HelpMarkdown:
+		return writeHelpMarkdown(w, runnable)
+	case HelpRecipe:
+		var text bytes.Buffer
+		if err := writeHelpText(&text, runnable); err != nil {
+			return err
+		}
+		return writeHelpRecipe(w, text.String())
+	default:
+		return fmt.Errorf("unknown help format %q: must be %s", format, strings.Join(HelpFormats, ", "))
+	}
+}
+
+// helpGroups returns the groups of targets in the order they first
+// appear, starting with the ungrouped targets.
+func helpGroups(targets []Target) []string {
+	groups := []string{""}
+	seen := map[string]bool{"": true}
+	for _, t := range targets {
+		if !seen[t.Group] {
+			seen[t.Group] = true
+			groups = append(groups, t.Group)
+		}
+	}
+	return groups
+}
+
+func writeHelpText(w io.Writer, targets []Target) error {
+	var text bytes.Buffer
+	tw := tabwriter.NewWriter(&text, 0, 4, 2, ' ', 0)
+	wrote := false
+	for _, group := range helpGroups(targets) {
+		indent := ""
+		if group != "" {
+			if wrote {
+				fmt.Fprintln(tw)
+			}
+			fmt.Fprintf(tw, "%s:\n", group)
+			indent = "  "
+		}
+		for _, t := range targets {
+			if t.Group == group {
+				fmt.Fprintf(tw, "%s%s\t%s\n", indent, t.Name, t.Doc)
+				wrote = true
+			}
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	// Undocumented targets leave the padding of the doc column behind.
+	for _, line := range strings.Split(strings.TrimSuffix(text.String(), "\n"), "\n") {
+		if _, err := io.WriteString(w, strings.TrimRight(line, " ")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHelpMarkdown(w io.Writer, targets []Target) error {
+	grouped := len(helpGroups(targets)) > 1
+	if grouped {
+		fmt.Fprintln(w, "| Group | Target | Description |")
+		fmt.Fprintln(w, "|-------|--------|-------------|")
+	} else {
+		fmt.Fprintln(w, "| Target | Description |")
+		fmt.Fprintln(w, "|--------|-------------|")
+	}
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+	for _, group := range helpGroups(targets) {
+		for _, t := range targets {
+			if t.Group != group {
+				continue
+			}
+			row := "| `" + t.Name + "` | " + cell.Replace(t.Doc) + " |"
+			if grouped {
+				row = "| " + cell.Replace(group) + " " + row
+			}
+			if _, err := fmt.Fprintln(w, row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeHelpRecipe writes a help rule printing text, escaped for the shell
+// and for make.
+func writeHelpRecipe(w io.Writer, text string) error {
+	quote := strings.NewReplacer("'", `'\''`, "$", "$$")
+	fmt.Fprintln(w, ".PHONY: help")
+	fmt.Fprintln(w, "help: ## Show the available targets")
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if line == "" {
+			fmt.Fprintln(w, "\t@echo")
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\t@echo '%s'\n", quote.Replace(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,621 @@
+// Package makefile discovers the targets of a Makefile and their
+// documentation.
+package makefile
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Target is a single rule discovered in a build file. Other build tools
+// reuse it for their tasks and recipes.
+type Target struct {
+	Name  string
+	Doc   string   // Documentation shown in the help pane
+	File  string   // Makefile the target was defined in
+	Line  int      // 1-based line of the rule in File
+	Deps  []string // Prerequisites of the rule
+	Phony bool     // Listed as a prerequisite of .PHONY
+	Kind  Kind
+	Group string // Section from an "@group name" doc annotation
+	// OrderOnly lists the prerequisites after a "|", which are also in
+	// Deps.
+	OrderOnly []string
+	// DoubleColon is set for "target::" rules, whose definitions each
+	// have their own recipe.
+	DoubleColon bool
+	// Locations lists every definition of the target in the order they
+	// were read; File and Line are the first of them.
+	Locations []Location
+	// Recipe holds the command lines of the rule without their leading
+	// tab, including an inline "target: ; command".
+	Recipe []string
+	// Vars lists the target-specific variable assignments of the target,
+	// such as "CFLAGS += -g".
+	Vars []string
+	// Env and Dir come from "@env NAME=value" and "@cwd dir" doc
+	// annotations: the variables to set in the environment of the target
+	// and the directory, relative to the Makefile, to run it from.
+	Env []string
+	Dir string
+	// Badges lists the other "@name" doc annotations, such as @test or
+	// @docker, without the "@".
+	Badges []string
+	// Submake is set for the targets of a Makefile that another one runs
+	// recursively with "$(MAKE) -C dir": the directory of that Makefile,
+	// relative to the one the targets were discovered from.
+	Submake string
+}
+
+// Variable is a variable assigned in a Makefile outside of rules.
+type Variable struct {
+	Name string
+	// Value is the value as written, after every assignment: "+=" appends
+	// to it and ":=" expands it.
+	Value string
+	// Expanded is Value with the references to the other variables and to
+	// the environment expanded. Functions such as $(shell ...) are left as
+	// they are.
+	Expanded string
+	File     string // Makefile the variable is first assigned in
+	Line     int    // 1-based line of that assignment in File
+}
+
+// Diagnostic is a problem found in a Makefile, such as a line that is
+// neither a rule, a recipe line, an assignment nor a directive, which is
+// left out of the targets.
+type Diagnostic struct {
+	File    string
+	Line    int // 1-based
+	Message string
+}
+
+// Location is a place where a target is defined.
+type Location struct {
+	File string
+	Line int
+}
+
+// Kind tells ordinary targets apart from rules that are usually not run
+// by hand.
+type Kind int
+
+const (
+	// KindTarget is a target meant to be run, such as "build" or "test".
+	KindTarget Kind = iota
+	// KindFile is a rule producing a file, such as "bin/app" or "main.o".
+	KindFile
+	// KindPattern is a pattern rule such as "%.o".
+	KindPattern
+	// KindInternal is a helper whose name starts with "_" or ".".
+	KindInternal
+)
+
+var (
+	ruleRegexp      = regexp.MustCompile(`^([^:#=\s][^:#=]*?)\s*(::?)(.*)$`)
+	specialRegexp   = regexp.MustCompile(`^\.[A-Z_]+$`)
+	groupRegexp     = regexp.MustCompile(`^@group\s+(\S+)\s*(.*)$`)
+	envRegexp       = regexp.MustCompile(`@env((?:\s+[A-Za-z_][A-Za-z0-9_]*=\S*)+)`)
+	cwdRegexp       = regexp.MustCompile(`@cwd\s+(\S+)`)
+	badgeRegexp     = regexp.MustCompile(`^@([a-z][a-z0-9-]*)$`)
+	includeRegexp   = regexp.MustCompile(`^(-?include|sinclude)\s+(.+)$`)
+	variableRegexp  = regexp.MustCompile(`^(?:(?:export|override)\s+)*([a-zA-Z0-9_.-]+)\s*(:=|::=|\?=|\+=|=)\s*(.*)$`)
+	referenceRegexp = regexp.MustCompile(`\$[({]([a-zA-Z0-9_.-]+)[)}]`)
+)
+
+// Read returns the targets of the Makefile at path in the order they are
+// defined, following include directives.
+func Read(path string) ([]Target, error) {
+	targets, _, err := ReadWithSources(path)
+	return targets, err
+}
+
+// ReadWithSources is like Read but also returns the files that were read:
+// the Makefile itself and everything it includes.
+func ReadWithSources(path string) ([]Target, []string, error) {
+	p, err := newParser(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range p.targets {
+		t := &p.targets[i]
+		t.Phony = p.phony[t.Name]
+		t.Kind = kind(*t)
+		t.Vars = p.targetVars[t.Name]
+		if t.Doc == "" {
+			t.Doc = strings.Join(t.Deps, " ")
+		}
+	}
+	return p.targets, p.sources, nil
+}
+
+// ReadVariables returns the variables assigned in the Makefile at path and
+// the files it includes, in the order they are first assigned.
+func ReadVariables(path string) ([]Variable, error) {
+	p, err := newParser(path)
+	if err != nil {
+		return nil, err
+	}
+	for i := range p.variables {
+		v := &p.variables[i]
+		value, ok := p.vars[v.Name]
+		if !ok {
+			// A "?=" assignment the environment overrides.
+			value = os.Getenv(v.Name)
+		}
+		v.Value, v.Expanded = value, expandVars(value, p.vars)
+	}
+	return p.variables, nil
+}
+
+// Check returns the problems found reading the Makefile at path and the
+// files it includes, in the order they were found.
+func Check(path string) ([]Diagnostic, error) {
+	p, err := newParser(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.diagnostics, nil
+}
+
+// kind classifies t. Targets that are not phony and look like a path are
+// assumed to produce that file.
+func kind(t Target) Kind {
+	switch {
+	case strings.Contains(t.Name, "%"):
+		return KindPattern
+	case strings.HasPrefix(t.Name, "_"), strings.HasPrefix(t.Name, "."):
+		return KindInternal
+	case !t.Phony && strings.ContainsAny(t.Name, "./"):
+		return KindFile
+	}
+	return KindTarget
+}
+
+type parser struct {
+	targets []Target
+	index   map[string]int // position of each target name in targets
+	// vars collects simple variable assignments so that include paths such
+	// as `include $(MK_DIR)/*.mk` and target names such as
+	// `$(SERVICE)-build` can be resolved.
+	vars    map[string]string
+	visited map[string]bool
+	sources []string        // files parsed so far, in order
+	phony   map[string]bool // prerequisites of .PHONY
+	// targetVars holds the target-specific variable assignments by
+	// target, which may come before the rule.
+	targetVars map[string][]string
+	// variables lists the variables in the order they are first assigned,
+	// their values being filled in by ReadVariables.
+	variables []Variable
+	// diagnostics lists the problems found so far, for Check.
+	diagnostics []Diagnostic
+}
+
+// newParser returns a parser that has read the Makefile at path and the
+// files it includes.
+func newParser(path string) (*parser, error) {
+	p := &parser{
+		index:      make(map[string]int),
+		vars:       make(map[string]string),
+		visited:    make(map[string]bool),
+		phony:      make(map[string]bool),
+		targetVars: make(map[string][]string),
+	}
+	if err := p.parse(path); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// directives are the words starting the lines of a Makefile that are
+// neither rules nor assignments but are understood by make.
+var directives = map[string]bool{
+	"ifeq": true, "ifneq": true, "ifdef": true, "ifndef": true, "else": true, "endif": true,
+	"export": true, "unexport": true, "override": true, "undefine": true, "private": true,
+	"vpath": true, "load": true, "-load": true, "include": true, "-include": true, "sinclude": true,
+}
+
+// understood reports whether make understands line, trimmed, although it
+// is not a recipe line: a rule, an assignment, a directive, a comment or
+// a function call such as $(eval ...).
+func understood(line string) bool {
+	return ruleRegexp.MatchString(line) || variableRegexp.MatchString(line) ||
+		directives[strings.Fields(line)[0]] || strings.HasPrefix(line, "#") ||
+		strings.HasPrefix(line, "$(") || strings.HasPrefix(line, "${")
+}
+
+// warn records a problem at line of path.
+func (p *parser) warn(path string, line int, format string, args ...any) {
+	p.diagnostics = append(p.diagnostics, Diagnostic{File: path, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// parse reads the Makefile at path, recursing into included files.
+func (p *parser) parse(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if p.visited[abs] {
+		return nil
+	}
+	p.visited[abs] = true
+	p.sources = append(p.sources, path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// comment holds the "# ..." lines directly above the current line so
+	// they can be used as documentation for a target that follows them.
+	var comment []string
+	// inDefine is set between "define" and "endef", whose lines are
+	// variable contents rather than rules.
+	inDefine, defineLine := false, 0
+	// conditionals holds the lines of the conditionals not closed yet.
+	var conditionals []int
+	// continued is set when the line before ends with a backslash, which
+	// makes the line part of it.
+	continued := false
+	// recipeOf lists the targets of the last rule, which receive the
+	// recipe lines that follow it.
+	var recipeOf []string
+
+	lineNo := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNo++
+		// Makefiles written on Windows may end their lines with CRLF.
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		partOfPrevious := continued
+		continued = strings.HasSuffix(line, "\\")
+		if strings.HasPrefix(line, "\t") {
+			if !inDefine {
+				p.addRecipe(recipeOf, line[1:])
+			}
+			continue
+		}
+		if directive := strings.Fields(line); len(directive) > 0 {
+			switch directive[0] {
+			case "define":
+				inDefine, defineLine = true, lineNo
+				continue
+			case "endef":
+				inDefine = false
+				continue
+			}
+		}
+		if inDefine {
+			continue
+		}
+		if directive := strings.Fields(line); len(directive) > 0 && !partOfPrevious {
+			switch directive[0] {
+			case "ifeq", "ifneq", "ifdef", "ifndef":
+				conditionals = append(conditionals, lineNo)
+			case "endif":
+				if len(conditionals) == 0 {
+					p.warn(path, lineNo, "endif without a matching ifeq, ifneq, ifdef or ifndef")
+				} else {
+					conditionals = conditionals[:len(conditionals)-1]
+				}
+			}
+		}
+		if strings.HasPrefix(line, "#") {
+			comment = append(comment, strings.TrimSpace(strings.TrimLeft(line, "#")))
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			// Blank lines may separate the lines of a recipe.
+			comment = nil
+			continue
+		}
+		above := strings.Join(comment, " ")
+		comment = nil
+		if trimmed := strings.TrimSpace(line); len(recipeOf) > 0 && strings.HasPrefix(line, " ") &&
+			!partOfPrevious && !understood(trimmed) {
+			p.warn(path, lineNo, "recipe line of %s starts with spaces instead of a tab", recipeOf[0])
+			continue
+		}
+		recipeOf = nil
+
+		if m := includeRegexp.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			optional := m[1] != "include"
+			for _, pattern := range strings.Fields(expandVars(m[2], p.vars)) {
+				matches, err := filepath.Glob(pattern)
+				if err != nil {
+					continue
+				}
+				// Missing includes are skipped: they are often generated by
+				// the build itself.
+				for _, match := range matches {
+					if err := p.parse(match); err != nil && !optional {
+						return err
+					}
+				}
+			}
+			continue
+		}
+
+		if m := variableRegexp.FindStringSubmatch(line); m != nil {
+			name, op, value := m[1], m[2], strings.TrimSpace(m[3])
+			if _, seen := p.vars[name]; !seen && !p.assigned(name) {
+				p.variables = append(p.variables, Variable{Name: name, File: path, Line: lineNo})
+			}
+			switch op {
+			case "?=":
+				_, set := p.vars[name]
+				if _, env := os.LookupEnv(name); !set && !env {
+					p.vars[name] = value
+				}
+			case "+=":
+				p.vars[name] = strings.TrimSpace(p.vars[name] + " " + value)
+			case ":=", "::=":
+				p.vars[name] = expandVars(value, p.vars)
+			default:
+				p.vars[name] = value
+			}
+			continue
+		}
+
+		m := ruleRegexp.FindStringSubmatch(line)
+		if m == nil || strings.HasPrefix(m[3], "=") {
+			// Not a rule, or an assignment such as "export FOO := 1".
+			if trimmed := strings.TrimSpace(line); m == nil && !partOfPrevious && !understood(trimmed) {
+				p.warn(path, lineNo, "not a rule, recipe line, assignment or directive, so it is left out: %q", trimmed)
+			}
+			continue
+		}
+		rest := m[3]
+		if assignment, ok := targetAssignment(rest); ok {
+			// Target-specific variable such as "debug: CFLAGS += -g",
+			// which does not define the rule.
+			for _, name := range strings.Fields(expandVars(m[1], p.vars)) {
+				p.targetVars[name] = append(p.targetVars[name], assignment)
+			}
+			continue
+		}
+		for _, name := range strings.Fields(expandVars(m[1], p.vars)) {
+			switch {
+			case name == ".PHONY":
+				for _, dep := range p.prerequisites(rest) {
+					p.phony[dep] = true
+				}
+			case specialRegexp.MatchString(name):
+				// Special targets such as .DEFAULT_GOAL or .SUFFIXES.
+			default:
+				doc, group := splitGroup(expandVars(targetDoc(rest, above), p.vars))
+				doc, env, dir, badges := splitAnnotations(doc)
+				p.add(Target{
+					Name:        name,
+					Doc:         doc,
+					Group:       group,
+					Env:         env,
+					Dir:         dir,
+					Badges:      badges,
+					File:        path,
+					Line:        lineNo,
+					Deps:        p.prerequisites(rest),
+					OrderOnly:   p.orderOnly(rest),
+					DoubleColon: m[2] == "::",
+					Locations:   []Location{{path, lineNo}},
+				})
+				recipeOf = append(recipeOf, name)
+			}
+		}
+		if beforeComment, _, _ := strings.Cut(rest, "#"); strings.Contains(beforeComment, ";") {
+			_, inline, _ := strings.Cut(rest, ";")
+			p.addRecipe(recipeOf, strings.TrimSpace(inline))
+		}
+	}
+	if inDefine {
+		p.warn(path, defineLine, "define without endef")
+	}
+	for _, line := range conditionals {
+		p.warn(path, line, "conditional without endif")
+	}
+	return scanner.Err()
+}
+
+// targetAssignment returns the target-specific variable assignment in the
+// rest of a rule line, after the colon, such as "CFLAGS += -g" for
+// "debug: CFLAGS += -g". An "=" after the ";" of an inline recipe, as in
+// "run: ; ./app --flag=1", is part of the recipe.
+func targetAssignment(rest string) (string, bool) {
+	r, _, _ := strings.Cut(rest, "#")
+	eq := strings.Index(r, "=")
+	if semi := strings.Index(r, ";"); eq < 0 || semi >= 0 && semi < eq {
+		return "", false
+	}
+	assignment := strings.TrimSpace(r)
+	if v := variableRegexp.FindStringSubmatch(assignment); v != nil {
+		assignment = v[1] + " " + v[2] + " " + strings.TrimSpace(v[3])
+	}
+	return assignment, true
+}
+
+// assigned reports whether an assignment to the variable called name was
+// read.
+func (p *parser) assigned(name string) bool {
+	for _, v := range p.variables {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addRecipe appends a recipe line to each of the targets called names.
+func (p *parser) addRecipe(names []string, line string) {
+	for _, name := range names {
+		t := &p.targets[p.index[name]]
+		t.Recipe = append(t.Recipe, line)
+	}
+}
+
+// add records t. A target defined more than once is listed at its first
+// definition, with the docs, prerequisites and locations of every
+// definition merged, as make merges their prerequisites.
+func (p *parser) add(t Target) {
+	if i, ok := p.index[t.Name]; ok {
+		p.targets[i] = merge(p.targets[i], t)
+		return
+	}
+	p.index[t.Name] = len(p.targets)
+	p.targets = append(p.targets, t)
+}
+
+// merge combines the definition t of a target with its earlier
+// definitions in prev.
+func merge(prev, t Target) Target {
+	if t.Doc != "" && !strings.Contains(prev.Doc, t.Doc) {
+		if prev.Doc == "" {
+			prev.Doc = t.Doc
+		} else {
+			prev.Doc += "; " + t.Doc
+		}
+	}
+	for _, dep := range t.Deps {
+		if !contains(prev.Deps, dep) {
+			prev.Deps = append(prev.Deps, dep)
+		}
+	}
+	for _, dep := range t.OrderOnly {
+		if !contains(prev.OrderOnly, dep) {
+			prev.OrderOnly = append(prev.OrderOnly, dep)
+		}
+	}
+	if prev.Group == "" {
+		prev.Group = t.Group
+	}
+	if prev.Dir == "" {
+		prev.Dir = t.Dir
+	}
+	prev.Env = append(prev.Env, t.Env...)
+	for _, badge := range t.Badges {
+		if !contains(prev.Badges, badge) {
+			prev.Badges = append(prev.Badges, badge)
+		}
+	}
+	prev.DoubleColon = prev.DoubleColon || t.DoubleColon
+	prev.Locations = append(prev.Locations, t.Locations...)
+	return prev
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the target called name.
+func Find(targets []Target, name string) (Target, bool) {
+	for _, t := range targets {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Target{}, false
+}
+
+// prerequisites returns the normal and order-only prerequisites listed in
+// the rest of a rule line, after the colon.
+func (p *parser) prerequisites(rest string) []string {
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, _, _ = strings.Cut(rest, ";") // inline recipe
+	var deps []string
+	for _, dep := range strings.Fields(expandVars(rest, p.vars)) {
+		if dep != "|" {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// orderOnly returns the order-only prerequisites listed in the rest of a
+// rule line, after a "|".
+func (p *parser) orderOnly(rest string) []string {
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, _, _ = strings.Cut(rest, ";")
+	_, after, found := strings.Cut(expandVars(rest, p.vars), "|")
+	if !found {
+		return nil
+	}
+	return strings.Fields(after)
+}
+
+// targetDoc picks the documentation for a rule: a trailing "## ..." comment
+// wins, then "# ..." lines directly above the rule. Undocumented targets
+// get their prerequisite list once every definition has been read.
+func targetDoc(rest, above string) string {
+	_, doc, found := strings.Cut(rest, "##")
+	if found && strings.TrimSpace(doc) != "" {
+		return strings.TrimSpace(doc)
+	}
+	return above
+}
+
+// splitGroup separates an "@group name" annotation at the start of doc
+// from the rest of the documentation.
+func splitGroup(doc string) (string, string) {
+	if m := groupRegexp.FindStringSubmatch(doc); m != nil {
+		return m[2], m[1]
+	}
+	return doc, ""
+}
+
+// splitAnnotations removes the "@env NAME=value...", "@cwd dir" and
+// badge annotations from doc, returning them separately.
+func splitAnnotations(doc string) (string, []string, string, []string) {
+	var env []string
+	for _, m := range envRegexp.FindAllStringSubmatch(doc, -1) {
+		env = append(env, strings.Fields(m[1])...)
+	}
+	dir := ""
+	if m := cwdRegexp.FindStringSubmatch(doc); m != nil {
+		dir = m[1]
+	}
+	doc = cwdRegexp.ReplaceAllString(envRegexp.ReplaceAllString(doc, ""), "")
+	var badges, words []string
+	for _, word := range strings.Fields(doc) {
+		if m := badgeRegexp.FindStringSubmatch(word); m != nil {
+			if !contains(badges, m[1]) {
+				badges = append(badges, m[1])
+			}
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " "), env, dir, badges
+}
+
+// expandVars replaces $(NAME) and ${NAME} references with values from vars,
+// falling back to the environment like make does. Unknown references are
+// left untouched.
+func expandVars(s string, vars map[string]string) string {
+	for i := 0; i < 10 && referenceRegexp.MatchString(s); i++ {
+		expanded := referenceRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+			name := referenceRegexp.FindStringSubmatch(ref)[1]
+			if value, ok := vars[name]; ok {
+				return value
+			}
+			if value, ok := os.LookupEnv(name); ok {
+				return value
+			}
+			return ref
+		})
+		if expanded == s {
+			break
+		}
+		s = expanded
+	}
+	return s
+}
@@ -0,0 +1,347 @@
+package makefile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles creates files, by path relative to dir, in dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// chdir makes dir the working directory until the test ends, as include
+// paths are relative to it.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// testTarget holds the fields of a Target the tests compare.
+type testTarget struct {
+	Name   string
+	Doc    string
+	Deps   []string
+	Vars   []string
+	Recipe []string
+}
+
+func summarize(targets []Target) []testTarget {
+	var list []testTarget
+	for _, t := range targets {
+		list = append(list, testTarget{Name: t.Name, Doc: t.Doc, Deps: t.Deps, Vars: t.Vars, Recipe: t.Recipe})
+	}
+	return list
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []testTarget
+	}{
+		{
+			name: "docs",
+			files: map[string]string{"Makefile": "build: ## Build the app\n\tgo build\n\n" +
+				"# Run the tests\n# verbosely\ntest: build\n\tgo test -v\n\n" +
+				"# Not the doc of lint\n\nlint:\n"},
+			want: []testTarget{
+				{Name: "build", Doc: "Build the app", Recipe: []string{"go build"}},
+				{Name: "test", Doc: "Run the tests verbosely", Deps: []string{"build"}, Recipe: []string{"go test -v"}},
+				{Name: "lint"},
+			},
+		},
+		{
+			name:  "trailing doc wins over the comment above",
+			files: map[string]string{"Makefile": "# Above\nbuild: ## Trailing\n"},
+			want:  []testTarget{{Name: "build", Doc: "Trailing"}},
+		},
+		{
+			name:  "undocumented targets list their prerequisites",
+			files: map[string]string{"Makefile": "all: build test\nbuild:\ntest:\n"},
+			want: []testTarget{
+				{Name: "all", Doc: "build test", Deps: []string{"build", "test"}},
+				{Name: "build"},
+				{Name: "test"},
+			},
+		},
+		{
+			name: "includes",
+			files: map[string]string{
+				"Makefile":      "include mk/*.mk\n-include missing.mk\nsinclude opt.mk\nall: ## All\n",
+				"mk/docker.mk":  "image: ## Build the image\n",
+				"mk/release.mk": "release: ## Release\n",
+				"opt.mk":        "optional: ## Optional\n",
+			},
+			want: []testTarget{
+				{Name: "image", Doc: "Build the image"},
+				{Name: "release", Doc: "Release"},
+				{Name: "optional", Doc: "Optional"},
+				{Name: "all", Doc: "All"},
+			},
+		},
+		{
+			name: "included through a variable",
+			files: map[string]string{
+				"Makefile":   "MK_DIR := mk\ninclude $(MK_DIR)/lint.mk\n",
+				"mk/lint.mk": "lint: ## Lint\n",
+			},
+			want: []testTarget{{Name: "lint", Doc: "Lint"}},
+		},
+		{
+			name: "double-colon rules merge",
+			files: map[string]string{"Makefile": "clean:: ## Remove the binaries\n\trm -f app\n" +
+				"clean:: tmp ## Remove the temporary files\n\trm -rf tmp\n"},
+			want: []testTarget{{
+				Name:   "clean",
+				Doc:    "Remove the binaries; Remove the temporary files",
+				Deps:   []string{"tmp"},
+				Recipe: []string{"rm -f app", "rm -rf tmp"},
+			}},
+		},
+		{
+			name:  "a redefined target keeps its place and merges its docs",
+			files: map[string]string{"Makefile": "build: ## First\ntest:\nbuild: ## Second\n"},
+			want: []testTarget{
+				{Name: "build", Doc: "First; Second"},
+				{Name: "test"},
+			},
+		},
+		{
+			name: "variable expansion",
+			files: map[string]string{"Makefile": "SERVICE = api\nDEPS := $(SERVICE)-gen\n" +
+				"$(SERVICE)-build: $(DEPS) ## Build $(SERVICE)\n$(SERVICE)-gen:\n"},
+			want: []testTarget{
+				{Name: "api-build", Doc: "Build api", Deps: []string{"api-gen"}},
+				{Name: "api-gen"},
+			},
+		},
+		{
+			name: "target-specific variables",
+			files: map[string]string{"Makefile": "debug: CFLAGS += -g\ndebug: ## Debug build\n" +
+				"release test: OPT:=2\nrelease:\ntest:\n"},
+			want: []testTarget{
+				{Name: "debug", Doc: "Debug build", Vars: []string{"CFLAGS += -g"}},
+				{Name: "release", Vars: []string{"OPT := 2"}},
+				{Name: "test", Vars: []string{"OPT := 2"}},
+			},
+		},
+		{
+			name:  "inline recipes",
+			files: map[string]string{"Makefile": "fmt: ; gofmt -w .\n"},
+			want:  []testTarget{{Name: "fmt", Recipe: []string{"gofmt -w ."}}},
+		},
+		{
+			name: "inline recipes with an assignment",
+			files: map[string]string{"Makefile": "run: build ; ./app --flag=1\n" +
+				"url: URL := http://localhost/?a=1;b\nurl:\nbuild:\n"},
+			want: []testTarget{
+				{Name: "run", Doc: "build", Deps: []string{"build"}, Recipe: []string{"./app --flag=1"}},
+				{Name: "url", Vars: []string{"URL := http://localhost/?a=1;b"}},
+				{Name: "build"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+			chdir(t, dir)
+			targets, err := Read("Makefile")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := summarize(targets); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadWithSources(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Makefile":     "include a.mk b.mk\n-include gone.mk\n",
+		"a.mk":         "include b.mk\n",
+		"b.mk":         "b:\n",
+		"unused.mk":    "unused:\n",
+		"sub/Makefile": "sub:\n",
+	})
+	chdir(t, dir)
+	_, sources, err := ReadWithSources("Makefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Makefile", "a.mk", "b.mk"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("sources = %v, want %v", sources, want)
+	}
+}
+
+func TestReadMissingInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"Makefile": "include missing.mk\nall:\n"})
+	chdir(t, dir)
+	// Missing includes are often generated by the build, so they are
+	// skipped rather than failing.
+	targets, err := Read("Makefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 || targets[0].Name != "all" {
+		t.Errorf("Read() = %+v, want all", summarize(targets))
+	}
+}
+
+func TestReadPhonyAndKind(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"Makefile": ".PHONY: build docs/site\n" +
+		"build:\ndocs/site:\nbin/app:\n%.o: %.c\n_helper:\n"})
+	chdir(t, dir)
+	targets, err := Read("Makefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Kind{
+		"build":     KindTarget,
+		"docs/site": KindTarget,
+		"bin/app":   KindFile,
+		"%.o":       KindPattern,
+		"_helper":   KindInternal,
+	}
+	for _, target := range targets {
+		if target.Kind != want[target.Name] {
+			t.Errorf("%s: Kind = %v, want %v", target.Name, target.Kind, want[target.Name])
+		}
+	}
+	if build, _ := Find(targets, "build"); !build.Phony {
+		t.Error("build is not phony")
+	}
+}
+
+func TestReadAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"Makefile": "" +
+		"deploy: | bin ## @group release @env STAGE=prod REGION=eu @cwd deploy @docker Deploy it\n" +
+		"bin:\n"})
+	chdir(t, dir)
+	targets, err := Read("Makefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := targets[0]
+	want := Target{
+		Name:      "deploy",
+		Doc:       "Deploy it",
+		Group:     "release",
+		Env:       []string{"STAGE=prod", "REGION=eu"},
+		Dir:       "deploy",
+		Badges:    []string{"docker"},
+		Deps:      []string{"bin"},
+		OrderOnly: []string{"bin"},
+	}
+	if got.Doc != want.Doc || got.Group != want.Group || got.Dir != want.Dir ||
+		!reflect.DeepEqual(got.Env, want.Env) || !reflect.DeepEqual(got.Badges, want.Badges) ||
+		!reflect.DeepEqual(got.Deps, want.Deps) || !reflect.DeepEqual(got.OrderOnly, want.OrderOnly) {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadVariables(t *testing.T) {
+	t.Setenv("IMAKE_TEST_HOME", "/home/test")
+	t.Setenv("IMAKE_TEST_SET", "from the environment")
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Makefile": "NAME = app\nBIN := bin/$(NAME)\nFLAGS = -v\nFLAGS += -race\n" +
+			"IMAKE_TEST_SET ?= default\nDIR = $(IMAKE_TEST_HOME)/src\ninclude vars.mk\nbuild: CFLAGS = -g\n",
+		"vars.mk": "export VERSION ?= 1.0\n",
+	})
+	chdir(t, dir)
+	vars, err := ReadVariables("Makefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type variable struct{ Name, Value, Expanded, File string }
+	var got []variable
+	for _, v := range vars {
+		got = append(got, variable{v.Name, v.Value, v.Expanded, v.File})
+	}
+	want := []variable{
+		{"NAME", "app", "app", "Makefile"},
+		{"BIN", "bin/app", "bin/app", "Makefile"},
+		{"FLAGS", "-v -race", "-v -race", "Makefile"},
+		{"IMAKE_TEST_SET", "from the environment", "from the environment", "Makefile"},
+		{"DIR", "$(IMAKE_TEST_HOME)/src", "/home/test/src", "Makefile"},
+		{"VERSION", "1.0", "1.0", "vars.mk"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadVariables() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		makefile string
+		want     []int // lines of the diagnostics
+	}{
+		{name: "clean", makefile: "ifdef DEBUG\nFLAGS = -g\nendif\nbuild:\n\tgo build\n"},
+		{name: "spaces instead of a tab", makefile: "build:\n    go build\n", want: []int{2}},
+		{name: "not understood", makefile: "build:\n\tgo build\nthis is not make\n", want: []int{3}},
+		{name: "conditional without endif", makefile: "ifeq ($(OS),Windows_NT)\nEXE = .exe\n", want: []int{1}},
+		{name: "endif without conditional", makefile: "endif\n", want: []int{1}},
+		{name: "define without endef", makefile: "define HELP\nusage\n", want: []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"Makefile": tt.makefile})
+			chdir(t, dir)
+			diagnostics, err := Check("Makefile")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var lines []int
+			for _, d := range diagnostics {
+				lines = append(lines, d.Line)
+			}
+			if !reflect.DeepEqual(lines, tt.want) {
+				t.Errorf("Check() = %+v, want diagnostics on lines %v", diagnostics, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubmakes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"Makefile": "SERVICES = api web\n" +
+		"all:\n\t$(MAKE) -C services/api build\n\tmake --directory=tools lint\n" +
+		"$(SERVICES):\n\t$(MAKE) -C $@\n" +
+		"loop:\n\tfor d in a b; do $(MAKE) -C $$d; done\n" +
+		"again:\n\t${MAKE} -s -C ./services/api test\n"})
+	chdir(t, dir)
+	dirs, err := Submakes("Makefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"services/api", "tools", "api", "web"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("Submakes() = %q, want %q", dirs, want)
+	}
+}
@@ -0,0 +1,46 @@
+package makefile
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// submakeRegexp matches a recursive make in a recipe line, such as
+// "$(MAKE) -C services/api build" or "$(MAKE) -s --directory=web",
+// capturing the directory.
+var submakeRegexp = regexp.MustCompile(`(?:\$[({]MAKE[)}]|\bmake\b)[^;&|]*?\s(?:-C\s*|--directory=)([^\s;&|]+)`)
+
+// Submakes returns the directories the recipes of the Makefile at path, and
+// of the files it includes, run make in with -C or --directory, such as
+// services/api for "$(MAKE) -C services/api build", in the order they
+// appear. They are relative to the directory of the Makefile. Directories
+// named by shell variables, such as the one of a for loop, are left out.
+func Submakes(path string) ([]string, error) {
+	p, err := newParser(path)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, t := range p.targets {
+		// Rules such as "$(SERVICES): ; $(MAKE) -C $@" dispatch to the
+		// directory named after the target.
+		self := strings.NewReplacer("$@", t.Name, "$(@)", t.Name, "${@}", t.Name)
+		for _, line := range t.Recipe {
+			for _, m := range submakeRegexp.FindAllStringSubmatch(expandVars(self.Replace(line), p.vars), -1) {
+				dir := strings.Trim(m[1], `"'`)
+				if strings.ContainsAny(dir, "$%") {
+					continue
+				}
+				dir = filepath.Clean(dir)
+				if dir == "." || seen[dir] {
+					continue
+				}
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs, nil
+}
@@ -0,0 +1,252 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bazelFileNames mark the root of a Bazel workspace, in priority order.
+var bazelFileNames = []string{"MODULE.bazel", "WORKSPACE.bazel", "WORKSPACE"}
+
+// bazelRuleRegexp matches a line of `bazel query --output=location`, such
+// as "/src/app/BUILD.bazel:3:1: go_binary rule //app:app".
+var bazelRuleRegexp = regexp.MustCompile(`^(.*):(\d+):\d+: (\S+) rule (\S+)$`)
+
+// bazelRunner builds and tests the rules of a Bazel workspace. Querying a
+// large workspace is slow, so the rules are cached until a BUILD file in
+// the scope changes.
+type bazelRunner struct {
+	path string
+
+	// mu guards the fields below: the HTTP API discovers and runs rules
+	// from its own goroutines while the UI rediscovers them.
+	mu    sync.Mutex
+	scope []string
+	kinds map[string]string // rule kinds by label, from the last Discover
+}
+
+func (r *bazelRunner) Name() string { return "bazel" }
+
+func (r *bazelRunner) File() string { return r.path }
+
+// SetScope limits the rules listed to the target patterns in scope, such
+// as //services/..., instead of the whole workspace.
+func (r *bazelRunner) SetScope(scope []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scope = scope
+}
+
+// bazelQuery returns the query listing the rules in scope.
+func bazelQuery(scope []string) string {
+	if len(scope) == 0 {
+		scope = []string{"//..."}
+	}
+	return fmt.Sprintf("kind(rule, %s)", strings.Join(scope, " + "))
+}
+
+// Discover lists the rules of the scope, grouped by package and
+// documented by their kind, from the cache if no BUILD file changed since
+// it was written.
+func (r *bazelRunner) Discover() ([]Target, error) {
+	r.mu.Lock()
+	scope := r.scope
+	r.mu.Unlock()
+	root := filepath.Dir(r.path)
+	query := bazelQuery(scope)
+	cache := bazelCachePath(root, query)
+	targets, err := readBazelCache(cache, root, scope)
+	if err != nil {
+		targets, err = r.queryRules(root, query)
+		if err != nil {
+			return nil, err
+		}
+		writeBazelCache(cache, targets)
+	}
+	kinds := make(map[string]string, len(targets))
+	for _, t := range targets {
+		kinds[t.Name] = t.Doc
+	}
+	r.mu.Lock()
+	r.kinds = kinds
+	r.mu.Unlock()
+	return targets, nil
+}
+
+// queryRules asks Bazel for the rules matching query.
+func (r *bazelRunner) queryRules(root, query string) ([]Target, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("bazel", "query", "--output=location", "--keep_going", query)
+	cmd.Dir = root
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	// With --keep_going, exit code 3 means some packages failed to load
+	// and the others were still listed.
+	var exit *exec.ExitError
+	if err != nil && !(errors.As(err, &exit) && exit.ExitCode() == 3) {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("bazel query: %v: %s", err, lastLine(msg))
+		}
+		return nil, fmt.Errorf("bazel query: %w", err)
+	}
+
+	var targets []Target
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		m := bazelRuleRegexp.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		pkg, _, _ := strings.Cut(m[4], ":")
+		targets = append(targets, Target{Name: m[4], Doc: m[3], Group: pkg, File: m[1], Line: line})
+	}
+	return targets, scanner.Err()
+}
+
+// lastLine returns the last line of s, where Bazel puts its error.
+func lastLine(s string) string {
+	return s[strings.LastIndex(s, "\n")+1:]
+}
+
+// bazelCachePath returns the location of the cached rules of query in the
+// workspace at root.
+func bazelCachePath(root, query string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	abs, _ := filepath.Abs(root)
+	sum := sha256.Sum256([]byte(abs + "\x00" + query))
+	return filepath.Join(dir, "imake", "bazel", hex.EncodeToString(sum[:8])+".json")
+}
+
+// readBazelCache returns the rules cached at path, or an error if there
+// are none or a BUILD file in the scope is newer.
+func readBazelCache(path, root string, scope []string) ([]Target, error) {
+	if path == "" {
+		return nil, fs.ErrNotExist
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if bazelChangedSince(root, scope, info.ModTime()) {
+		return nil, fmt.Errorf("%s is stale", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var targets []Target
+	return targets, json.Unmarshal(data, &targets)
+}
+
+// writeBazelCache caches targets at path. Failing to is not an error, the
+// workspace is queried again next time.
+func writeBazelCache(path string, targets []Target) {
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(targets)
+	if err != nil || os.MkdirAll(filepath.Dir(path), 0o755) != nil {
+		return
+	}
+	os.WriteFile(path, data, 0o644)
+}
+
+// bazelChangedSince reports whether a workspace file, BUILD file or
+// Starlark file in the directories of scope was modified after t. The
+// output trees and hidden directories are skipped.
+func bazelChangedSince(root string, scope []string, t time.Time) bool {
+	dirs := []string{root}
+	if len(scope) > 0 {
+		dirs = dirs[:0]
+		for _, pattern := range scope {
+			pkg := strings.TrimPrefix(pattern, "//")
+			pkg, _, _ = strings.Cut(pkg, ":")
+			pkg = strings.TrimSuffix(strings.TrimSuffix(pkg, "..."), "/")
+			dirs = append(dirs, filepath.Join(root, filepath.FromSlash(pkg)))
+		}
+		// Macros loaded from elsewhere change rules too.
+		for _, name := range bazelFileNames {
+			if info, err := os.Stat(filepath.Join(root, name)); err == nil && info.ModTime().After(t) {
+				return true
+			}
+		}
+	}
+	changed := false
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || changed {
+				return fs.SkipDir
+			}
+			name := d.Name()
+			if d.IsDir() {
+				if path != dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "bazel-") || name == "node_modules") {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if name != "BUILD" && name != "BUILD.bazel" && !strings.HasSuffix(name, ".bzl") && !contains(bazelFileNames, name) {
+				return nil
+			}
+			if info, err := d.Info(); err == nil && info.ModTime().After(t) {
+				changed = true
+				return fs.SkipAll
+			}
+			return nil
+		})
+	}
+	return changed
+}
+
+// currentKinds returns the rule kinds found by the last Discover.
+func (r *bazelRunner) currentKinds() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.kinds
+}
+
+// isBazelTest reports whether rules of kind are run with bazel test.
+func isBazelTest(kind string) bool {
+	return strings.HasSuffix(kind, "_test") || kind == "test_suite"
+}
+
+// Exec builds the rule, or runs it with bazel test if it is a test.
+func (r *bazelRunner) Exec(target string, args []string) *exec.Cmd {
+	kinds := r.currentKinds()
+	if kinds == nil {
+		// The rules were not listed yet, as with imake run.
+		r.Discover()
+		kinds = r.currentKinds()
+	}
+	verb := "build"
+	if isBazelTest(kinds[target]) {
+		verb = "test"
+	}
+	cmd := exec.Command("bazel", append([]string{verb, target}, args...)...)
+	cmd.Dir = filepath.Dir(r.path)
+	return cmd
+}
+
+// DryRun analyzes the rule without building it.
+func (r *bazelRunner) DryRun(target string, args []string) *exec.Cmd {
+	cmd := exec.Command("bazel", append([]string{"build", "--nobuild", target}, args...)...)
+	cmd.Dir = filepath.Dir(r.path)
+	return cmd
+}
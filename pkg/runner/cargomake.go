@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	tomlTaskRegexp      = regexp.MustCompile(`^\[tasks\.(?:"([^"]+)"|([A-Za-z0-9_-]+))\]`)
+	tomlKeyRegexp       = regexp.MustCompile(`^([A-Za-z0-9_-]+)\s*=\s*(.*)$`)
+	tomlStringRegexp    = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'[^']*'`)
+	tomlInlineDepRegexp = regexp.MustCompile(`\{[^}]*\bname\s*=\s*("(?:[^"\\]|\\.)*"|'[^']*')[^}]*\}`)
+)
+
+// cargoMakeRunner runs tasks of a Makefile.toml with cargo-make.
+type cargoMakeRunner struct {
+	path string
+}
+
+func (r *cargoMakeRunner) Name() string { return "cargo-make" }
+
+func (r *cargoMakeRunner) File() string { return r.path }
+
+// Discover lists the public tasks of the Makefile.toml, documented by
+// their description. Only the parts of TOML used by task definitions are
+// understood; the built-in tasks of cargo-make are not listed.
+func (r *cargoMakeRunner) Discover() ([]Target, error) {
+	file, err := os.Open(r.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var targets []Target
+	var current *Target
+	private := false
+	deps := "" // an unfinished multi-line dependencies array
+	flush := func() {
+		if current != nil && !private {
+			targets = append(targets, *current)
+		}
+		current, private = nil, false
+	}
+
+	lineNo := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if deps != "" {
+			deps += " " + line
+			if strings.Contains(line, "]") {
+				current.Deps = tomlDependencies(deps)
+				deps = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			m := tomlTaskRegexp.FindStringSubmatch(line)
+			switch {
+			case m != nil:
+				flush()
+				name := m[1] + m[2]
+				current = &Target{Name: name, File: r.path, Line: lineNo}
+			case current != nil && strings.HasPrefix(line, "[tasks."+current.Name+"."):
+				// A sub-table of the task, such as its env.
+			default:
+				flush()
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		m := tomlKeyRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		switch m[1] {
+		case "description":
+			current.Doc = tomlString(m[2])
+		case "private":
+			private = strings.HasPrefix(m[2], "true")
+		case "dependencies":
+			if strings.Contains(m[2], "]") {
+				current.Deps = tomlDependencies(m[2])
+			} else {
+				deps = m[2]
+			}
+		}
+	}
+	flush()
+	return targets, scanner.Err()
+}
+
+// tomlString returns the first string in a TOML value, unquoted.
+func tomlString(value string) string {
+	s := tomlStringRegexp.FindString(value)
+	if strings.HasPrefix(s, "'") {
+		return strings.Trim(s, "'")
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	return ""
+}
+
+// tomlDependencies returns the task names of a dependencies array, whose
+// entries are either names or { name = "...", path = "..." } tables.
+func tomlDependencies(value string) []string {
+	value = tomlInlineDepRegexp.ReplaceAllString(value, "$1")
+	var deps []string
+	for _, s := range tomlStringRegexp.FindAllString(value, -1) {
+		deps = append(deps, tomlString(s))
+	}
+	return deps
+}
+
+func (r *cargoMakeRunner) Exec(target string, args []string) *exec.Cmd {
+	argv := append([]string{"make", "--makefile", r.path, target}, args...)
+	return exec.Command("cargo", argv...)
+}
+
+func (r *cargoMakeRunner) DryRun(target string, args []string) *exec.Cmd {
+	argv := append([]string{"make", "--makefile", r.path, "--print-steps", target}, args...)
+	return exec.Command("cargo", argv...)
+}
@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// composeFileNames are the file names docker compose looks for, in
+// priority order.
+var composeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// composeActions are the actions offered for every service and for the
+// project as a whole.
+var composeActions = []struct {
+	name, doc string
+}{
+	{"up", "Create and start"},
+	{"down", "Stop and remove"},
+	{"logs", "Follow the logs of"},
+	{"restart", "Restart"},
+	{"build", "Build the images of"},
+}
+
+// composeRunner runs docker compose actions on the services of a compose
+// file. Its targets are the actions, such as "up", applying to every
+// service, and "service:action" for each service, grouped by service.
+type composeRunner struct {
+	path string
+}
+
+func (r *composeRunner) Name() string { return "compose" }
+
+func (r *composeRunner) File() string { return r.path }
+
+// Discover lists the actions of the project and of each service. Starting
+// a service depends on starting the services in its depends_on.
+func (r *composeRunner) Discover() ([]Target, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+	// Decode into a node first: a map would lose the order of the services.
+	var doc struct {
+		Services yaml.Node `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", r.path, err)
+	}
+
+	var targets []Target
+	for _, action := range composeActions {
+		targets = append(targets, Target{
+			Name: action.name,
+			Doc:  action.doc + " all services",
+			File: r.path,
+			Line: doc.Services.Line,
+		})
+	}
+	if doc.Services.Kind != yaml.MappingNode {
+		return targets, nil
+	}
+	for i := 0; i+1 < len(doc.Services.Content); i += 2 {
+		key, value := doc.Services.Content[i], doc.Services.Content[i+1]
+		var service struct {
+			Image     string    `yaml:"image"`
+			DependsOn yaml.Node `yaml:"depends_on"`
+		}
+		if value.Kind == yaml.MappingNode {
+			if err := value.Decode(&service); err != nil {
+				return nil, fmt.Errorf("%s: service %s: %w", r.path, key.Value, err)
+			}
+		}
+		for _, action := range composeActions {
+			doc := action.doc + " " + key.Value
+			if service.Image != "" {
+				doc += " (" + service.Image + ")"
+			}
+			var deps []string
+			if action.name == "up" {
+				for _, dep := range composeDependencies(service.DependsOn) {
+					deps = append(deps, dep+":up")
+				}
+			}
+			targets = append(targets, Target{
+				Name:  key.Value + ":" + action.name,
+				Doc:   doc,
+				Group: key.Value,
+				File:  r.path,
+				Line:  key.Line,
+				Deps:  deps,
+			})
+		}
+	}
+	return targets, nil
+}
+
+// composeDependencies returns the services of a depends_on entry, which is
+// either a list of names or a mapping from names to conditions.
+func composeDependencies(node yaml.Node) []string {
+	var deps []string
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, n := range node.Content {
+			deps = append(deps, n.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			deps = append(deps, node.Content[i].Value)
+		}
+	}
+	return deps
+}
+
+// composeArgs returns the docker arguments running target: the action,
+// followed by the service for a service's target.
+func (r *composeRunner) composeArgs(flags []string, target string, args []string) []string {
+	argv := append([]string{"compose", "-f", r.path}, flags...)
+	service, action, found := strings.Cut(target, ":")
+	if !found {
+		action, service = target, ""
+	}
+	argv = append(argv, action)
+	if action == "logs" {
+		argv = append(argv, "--follow")
+	}
+	if service != "" {
+		argv = append(argv, service)
+	}
+	return append(argv, args...)
+}
+
+func (r *composeRunner) Exec(target string, args []string) *exec.Cmd {
+	return exec.Command("docker", r.composeArgs(nil, target, args)...)
+}
+
+func (r *composeRunner) DryRun(target string, args []string) *exec.Cmd {
+	return exec.Command("docker", r.composeArgs([]string{"--dry-run"}, target, args)...)
+}
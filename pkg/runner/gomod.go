@@ -0,0 +1,195 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+)
+
+// goRunner offers the go test, bench, vet and generate commands of each
+// package of a Go module, for modules whose package tree is deep and
+// whose Makefile, if any, is thin. Targets are named after the package
+// directory and the command, such as "./pkg/ui:test".
+type goRunner struct {
+	path string
+}
+
+// goPackage is the part of `go list -json` read by the runner.
+type goPackage struct {
+	ImportPath   string
+	Dir          string
+	Doc          string
+	GoFiles      []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+}
+
+// goVerbs describe the targets of a package, in the order they are listed.
+var goVerbs = []struct{ name, doc string }{
+	{"test", "Run the tests of"},
+	{"bench", "Run the benchmarks of"},
+	{"vet", "Vet"},
+	{"generate", "Run the go:generate directives of"},
+}
+
+func (r *goRunner) Name() string { return "go" }
+
+func (r *goRunner) File() string { return r.path }
+
+// Discover lists the packages of the module with go list, each with the
+// targets that apply to it: bench only if it has benchmarks and generate
+// only if it has go:generate directives. Targets for the whole module come
+// first, the others are grouped by package.
+func (r *goRunner) Discover() ([]Target, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("go", "list", "-e", "-json", "./...")
+	cmd.Dir = filepath.Dir(r.path)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("go list: %v: %s", err, lastLine(msg))
+		}
+		return nil, fmt.Errorf("go list: %w", err)
+	}
+	root, err := filepath.Abs(cmd.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []Target
+	found := make(map[string]bool)
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var p goPackage
+		if err := dec.Decode(&p); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("go list: %w", err)
+		}
+		rel, err := filepath.Rel(root, p.Dir)
+		if err != nil {
+			continue
+		}
+		pkg := "./" + filepath.ToSlash(rel)
+		if rel == "." {
+			pkg = "."
+		}
+		for _, verb := range goVerbs {
+			file, line, ok := goVerbLocation(p, verb.name)
+			if !ok {
+				continue
+			}
+			found[verb.name] = true
+			doc := fmt.Sprintf("%s %s", verb.doc, p.ImportPath)
+			if verb.name == "test" && p.Doc != "" {
+				doc += ": " + p.Doc
+			}
+			packages = append(packages, r.target(pkg, verb.name, doc, pkg, file, line))
+		}
+	}
+
+	var targets []Target
+	for _, verb := range goVerbs {
+		if found[verb.name] {
+			doc := verb.doc + " every package of the module"
+			targets = append(targets, r.target("./...", verb.name, doc, "", r.path, 1))
+		}
+	}
+	return append(targets, packages...), nil
+}
+
+// target returns the target running verb on pkg.
+func (r *goRunner) target(pkg, verb, doc, group, file string, line int) Target {
+	t := Target{
+		Name:      pkg + ":" + verb,
+		Doc:       doc,
+		Group:     group,
+		File:      file,
+		Line:      line,
+		Phony:     true,
+		Locations: []makefile.Location{{File: file, Line: line}},
+	}
+	t.Recipe = []string{strings.Join(goArgs(pkg, verb, nil), " ")}
+	return t
+}
+
+// goVerbLocation returns where verb applies to p: the first test file, the
+// first benchmark or go:generate directive, or the first Go file for vet.
+// It reports false if verb does not apply to p.
+func goVerbLocation(p goPackage, verb string) (string, int, bool) {
+	tests := append(append([]string(nil), p.TestGoFiles...), p.XTestGoFiles...)
+	switch verb {
+	case "test":
+		if len(tests) > 0 {
+			return filepath.Join(p.Dir, tests[0]), 1, true
+		}
+	case "bench":
+		return findLine(p.Dir, tests, "func Benchmark")
+	case "vet":
+		if files := append(append([]string(nil), p.GoFiles...), tests...); len(files) > 0 {
+			return filepath.Join(p.Dir, files[0]), 1, true
+		}
+	case "generate":
+		return findLine(p.Dir, append(append([]string(nil), p.GoFiles...), tests...), "//go:generate ")
+	}
+	return "", 0, false
+}
+
+// findLine returns the first line of files in dir starting with prefix.
+func findLine(dir string, files []string, prefix string) (string, int, bool) {
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for line := 1; scanner.Scan(); line++ {
+			if strings.HasPrefix(scanner.Text(), prefix) {
+				f.Close()
+				return path, line, true
+			}
+		}
+		f.Close()
+	}
+	return "", 0, false
+}
+
+// goArgs returns the command line running verb on pkg with the extra flags
+// in args, such as -run=TestParse or -count=1.
+func goArgs(pkg, verb string, args []string) []string {
+	switch verb {
+	case "bench":
+		return append(append([]string{"go", "test", "-run=^$", "-bench=."}, args...), pkg)
+	default:
+		return append(append([]string{"go", verb}, args...), pkg)
+	}
+}
+
+// Exec runs the go command of target from the directory of go.mod.
+func (r *goRunner) Exec(target string, args []string) *exec.Cmd {
+	i := strings.LastIndex(target, ":")
+	if i < 0 {
+		i = len(target)
+	}
+	pkg, verb := target[:i], strings.TrimPrefix(target[i:], ":")
+	argv := goArgs(pkg, verb, args)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = filepath.Dir(r.path)
+	return cmd
+}
+
+// DryRun prints the commands the go command would run, with -n.
+func (r *goRunner) DryRun(target string, args []string) *exec.Cmd {
+	return r.Exec(target, append([]string{"-n"}, args...))
+}
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// gradleFileNames are the build scripts of a Gradle project, in priority
+// order.
+var gradleFileNames = []string{"build.gradle.kts", "build.gradle"}
+
+var gradleTaskRegexp = regexp.MustCompile(`^(\S+)(?: - (.*))?$`)
+
+// gradleRunner runs the tasks of a Gradle project, with the Gradle
+// wrapper when the project has one.
+type gradleRunner struct {
+	path string
+}
+
+func (r *gradleRunner) Name() string { return "gradle" }
+
+func (r *gradleRunner) File() string { return r.path }
+
+// command returns the gradle command of the project: its wrapper, or
+// gradle from the PATH.
+func (r *gradleRunner) command() string {
+	name := "gradlew"
+	if runtime.GOOS == "windows" {
+		name = "gradlew.bat"
+	}
+	return wrapper(filepath.Dir(r.path), name, "gradle")
+}
+
+// Discover asks Gradle for every task, grouped as `gradle tasks` groups
+// them, since build scripts are code.
+func (r *gradleRunner) Discover() ([]Target, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(r.command(), "-p", filepath.Dir(r.path), "-q", "--console=plain", "tasks", "--all")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("gradle tasks: %v: %s", err, msg)
+		}
+		return nil, fmt.Errorf("gradle tasks: %w", err)
+	}
+
+	// Each group is a "Build tasks" header underlined with dashes,
+	// followed by a "name - description" line per task.
+	var targets []Target
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), " "))
+	}
+	group := ""
+	for i, line := range lines {
+		switch {
+		case line == "":
+			group = ""
+		case i+1 < len(lines) && strings.Trim(lines[i+1], "-") == "" && lines[i+1] != "":
+			group = ""
+			if name, ok := strings.CutSuffix(line, " tasks"); ok {
+				group = strings.ToLower(name)
+			}
+		case strings.Trim(line, "-") == "":
+		case group != "":
+			if m := gradleTaskRegexp.FindStringSubmatch(line); m != nil {
+				targets = append(targets, Target{Name: m[1], Doc: m[2], Group: group, File: r.path, Line: 1})
+			}
+		}
+	}
+	return targets, scanner.Err()
+}
+
+func (r *gradleRunner) Exec(target string, args []string) *exec.Cmd {
+	argv := append([]string{"-p", filepath.Dir(r.path), target}, args...)
+	return exec.Command(r.command(), argv...)
+}
+
+func (r *gradleRunner) DryRun(target string, args []string) *exec.Cmd {
+	argv := append([]string{"-p", filepath.Dir(r.path), "--dry-run", target}, args...)
+	return exec.Command(r.command(), argv...)
+}
+
+// wrapper returns the path of the wrapper script called name in dir, such
+// as gradlew, or fallback to run the tool from the PATH when there is
+// none.
+func wrapper(dir, name, fallback string) string {
+	path, err := filepath.Abs(filepath.Join(dir, name))
+	if err != nil || !fileExists(path) {
+		return fallback
+	}
+	return path
+}
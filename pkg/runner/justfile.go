@@ -0,0 +1,118 @@
+package runner
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// justfileNames are the file names just looks for.
+var justfileNames = []string{"justfile", "Justfile", ".justfile"}
+
+var (
+	recipeRegexp  = regexp.MustCompile(`^@?([a-zA-Z_][a-zA-Z0-9_-]*)(\s+[^:]*)?:([^=]|$)`)
+	docAttrRegexp = regexp.MustCompile(`^\[.*\bdoc\(\s*['"](.*)['"]\s*\)`)
+)
+
+// justRunner runs recipes of a justfile with just.
+type justRunner struct {
+	path string
+}
+
+func (r *justRunner) Name() string { return "just" }
+
+func (r *justRunner) File() string { return r.path }
+
+// Discover lists the public recipes of the justfile, documented by the
+// comment directly above them or a [doc(...)] attribute.
+func (r *justRunner) Discover() ([]Target, error) {
+	file, err := os.Open(r.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var targets []Target
+	var comment string
+	private := false
+	lineNo := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		switch {
+		case strings.HasPrefix(line, "#"):
+			comment = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		case strings.HasPrefix(line, "["):
+			// Attributes sit between a recipe and its doc comment.
+			if m := docAttrRegexp.FindStringSubmatch(line); m != nil {
+				comment = m[1]
+			}
+			if strings.Contains(line, "private") {
+				private = true
+			}
+			continue
+		}
+
+		if m := recipeRegexp.FindStringSubmatch(line); m != nil && !isJustKeyword(m[1]) {
+			name := m[1]
+			if !private && !strings.HasPrefix(name, "_") {
+				targets = append(targets, Target{
+					Name: name,
+					Doc:  comment,
+					File: r.path,
+					Line: lineNo,
+					Deps: justDependencies(line),
+				})
+			}
+		}
+		comment, private = "", false
+	}
+	return targets, scanner.Err()
+}
+
+// justDependencies returns the recipes a recipe line depends on. Calls
+// with arguments, like `(build "x")`, are reduced to the recipe name.
+func justDependencies(line string) []string {
+	_, rest, _ := strings.Cut(line, ":")
+	rest = strings.ReplaceAll(rest, "&&", " ")
+	var deps []string
+	inCall := false
+	for _, field := range strings.Fields(rest) {
+		switch {
+		case strings.HasPrefix(field, "#"):
+			return deps
+		case strings.HasPrefix(field, "("):
+			deps = append(deps, strings.TrimSuffix(strings.TrimPrefix(field, "("), ")"))
+			inCall = !strings.HasSuffix(field, ")")
+		case inCall:
+			inCall = !strings.HasSuffix(field, ")")
+		default:
+			deps = append(deps, field)
+		}
+	}
+	return deps
+}
+
+// isJustKeyword reports whether word starts a justfile statement rather
+// than a recipe.
+func isJustKeyword(word string) bool {
+	switch word {
+	case "alias", "export", "import", "mod", "set", "unexport":
+		return true
+	}
+	return false
+}
+
+func (r *justRunner) Exec(target string, args []string) *exec.Cmd {
+	argv := append([]string{"--justfile", r.path, target}, args...)
+	return exec.Command("just", argv...)
+}
+
+func (r *justRunner) DryRun(target string, args []string) *exec.Cmd {
+	argv := append([]string{"--dry-run", "--justfile", r.path, target}, args...)
+	return exec.Command("just", argv...)
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// makeCandidates lists the make implementations looked for on the PATH,
+// in order, on the system goos. On Windows, MinGW installs GNU make as
+// mingw32-make, Visual Studio comes with nmake, and WSL runs the make of a
+// Linux distribution.
+func makeCandidates(goos string) [][]string {
+	if goos == "windows" {
+		return [][]string{{"make"}, {"mingw32-make"}, {"gmake"}, {"nmake"}, {"wsl", "make"}}
+	}
+	return [][]string{{"make"}, {"gmake"}}
+}
+
+// MakeCommand returns the command running make, with its leading
+// arguments: the one in $IMAKE_MAKE, else the first of makeCandidates on
+// the PATH, else plain make so that errors name it.
+var MakeCommand = sync.OnceValue(func() []string {
+	if s := strings.Fields(os.Getenv("IMAKE_MAKE")); len(s) > 0 {
+		return s
+	}
+	for _, c := range makeCandidates(runtime.GOOS) {
+		if _, err := exec.LookPath(c[0]); err == nil {
+			return c
+		}
+	}
+	return []string{"make"}
+})
+
+// makeExec returns the unstarted command running make with args.
+func makeExec(args ...string) *exec.Cmd {
+	argv := MakeCommand()
+	return exec.Command(argv[0], append(argv[1:len(argv):len(argv)], args...)...)
+}
+
+// isNMake reports whether make is Microsoft's nmake, which only knows the
+// basic options of make.
+func isNMake() bool {
+	argv := MakeCommand()
+	name := strings.ToLower(filepath.Base(argv[len(argv)-1]))
+	return strings.TrimSuffix(name, ".exe") == "nmake"
+}
+
+// isWSL reports whether make runs in WSL, where Windows paths mean nothing.
+func isWSL() bool {
+	return strings.ToLower(strings.TrimSuffix(filepath.Base(MakeCommand()[0]), ".exe")) == "wsl"
+}
@@ -0,0 +1,367 @@
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Run states.
+const (
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+	RunCancelled = "cancelled"
+)
+
+// Limits of what a RunManager keeps.
+const (
+	// maxLogLines is how many lines of its output a run keeps; the
+	// earliest are dropped first.
+	maxLogLines = 10000
+	// maxFinishedRuns is how many finished runs are kept to be listed.
+	maxFinishedRuns = 50
+)
+
+// ErrBusy is returned by RunManager.Start when a command already runs for
+// the key.
+var ErrBusy = errors.New("a command is already running for it")
+
+// Run is a command registered with a RunManager. Its fields are set when
+// it is added and must not be changed; Info reports its current state.
+type Run struct {
+	ID      int
+	Key     string // what the command runs for, such as a target or tab
+	Cmd     *exec.Cmd
+	Started time.Time
+
+	// attempts counts the requests to stop the run, each one sending a
+	// stronger signal than the last. It is guarded by the mutex of the
+	// RunManager.
+	attempts int
+
+	mu          sync.Mutex
+	cancelled   bool // asked to stop
+	lines       []string
+	subscribers map[chan string]bool
+	state       string
+	code        int
+	ended       time.Time
+	finished    chan struct{}
+}
+
+// RunInfo describes a run at one moment, as the HTTP API reports it.
+type RunInfo struct {
+	ID       int        `json:"id"`
+	Key      string     `json:"key"`
+	Command  []string   `json:"command"`
+	State    string     `json:"state"`
+	Code     *int       `json:"code,omitempty"` // once finished
+	Started  time.Time  `json:"started"`
+	Ended    *time.Time `json:"ended,omitempty"`
+	Duration float64    `json:"duration"` // seconds, so far while running
+}
+
+// Cancelled reports whether the run was asked to stop.
+func (r *Run) Cancelled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cancelled
+}
+
+// Done is closed once the run has finished.
+func (r *Run) Done() <-chan struct{} {
+	return r.finished
+}
+
+// Info returns the state of the run.
+func (r *Run) Info() RunInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info := RunInfo{ID: r.ID, Key: r.Key, Command: r.Cmd.Args, State: r.state, Started: r.Started}
+	if r.state == RunRunning {
+		info.Duration = time.Since(r.Started).Seconds()
+	} else {
+		code, ended := r.code, r.ended
+		info.Code, info.Ended = &code, &ended
+		info.Duration = ended.Sub(r.Started).Seconds()
+	}
+	return info
+}
+
+// AddLine appends a line of output, without its terminator, to the log of
+// the run and sends it to the subscribers.
+func (r *Run) AddLine(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, line)
+	if len(r.lines) > maxLogLines {
+		r.lines = r.lines[1:]
+	}
+	for ch := range r.subscribers {
+		select {
+		case ch <- line:
+		default:
+			// A subscriber too slow to keep up misses lines rather than
+			// holding up the command.
+		}
+	}
+}
+
+// Subscribe returns the lines logged so far and a channel receiving the
+// next ones, which is closed once the run has finished. stop unsubscribes
+// before that.
+func (r *Run) Subscribe() (lines []string, next <-chan string, stop func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines = append([]string(nil), r.lines...)
+	ch := make(chan string, 256)
+	if r.state != RunRunning {
+		close(ch)
+		return lines, ch, func() {}
+	}
+	r.subscribers[ch] = true
+	return lines, ch, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.subscribers[ch] {
+			delete(r.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// RunManager owns the running commands, whether the UI or the HTTP API
+// started them. Each run gets an ID and a key; a key has at most one run at
+// a time, so two commands never write to the same tab or pane.
+type RunManager struct {
+	mu       sync.Mutex
+	nextID   int
+	active   map[int]*Run
+	finished []*Run
+	held     map[string]int // keys busy between the commands of a sequence
+	onStart  func(r *Run)
+}
+
+// NewRunManager returns a RunManager without runs.
+func NewRunManager() *RunManager {
+	return &RunManager{active: make(map[int]*Run), held: make(map[string]int)}
+}
+
+// OnStart has f called with the runs started with Start from now on, such
+// as those of the HTTP API, for the UI to show them.
+func (m *RunManager) OnStart(f func(r *Run)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onStart = f
+}
+
+// Add registers cmd, already started for key, as running. Its process is
+// interrupted once the run is cancelled.
+func (m *RunManager) Add(key string, cmd *exec.Cmd) *Run {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	r := &Run{
+		ID:          m.nextID,
+		Key:         key,
+		Cmd:         cmd,
+		Started:     time.Now(),
+		subscribers: make(map[chan string]bool),
+		state:       RunRunning,
+		finished:    make(chan struct{}),
+	}
+	m.active[r.ID] = r
+	return r
+}
+
+// Finish records that the command of r exited with code, or -1 if it could
+// not be run, and closes the channels of its subscribers.
+func (m *RunManager) Finish(r *Run, code int) {
+	m.mu.Lock()
+	delete(m.active, r.ID)
+	m.finished = append(m.finished, r)
+	if len(m.finished) > maxFinishedRuns {
+		m.finished = m.finished[1:]
+	}
+	m.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case r.cancelled:
+		r.state = RunCancelled
+	case code == 0:
+		r.state = RunSucceeded
+	default:
+		r.state = RunFailed
+	}
+	r.code, r.ended = code, time.Now()
+	for ch := range r.subscribers {
+		close(ch)
+	}
+	r.subscribers = nil
+	close(r.finished)
+}
+
+// Start runs cmd for key with its output piped into the log of the run,
+// and returns the run once the command started. It fails with ErrBusy if
+// a command already runs for key.
+func (m *RunManager) Start(key string, cmd *exec.Cmd) (*Run, error) {
+	m.mu.Lock()
+	if m.busy(key) {
+		m.mu.Unlock()
+		return nil, ErrBusy
+	}
+	// Keep key busy while the command starts.
+	release := m.hold(key)
+	m.mu.Unlock()
+	defer release()
+
+	SetProcessGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	// Both outputs go to the same log, in the order they are written.
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	r := m.Add(key, cmd)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			r.AddLine(scanner.Text())
+		}
+		io.Copy(io.Discard, stdout)
+		err := cmd.Wait()
+		code := cmd.ProcessState.ExitCode()
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			r.AddLine("Error running command: " + err.Error())
+			code = -1
+		}
+		m.Finish(r, code)
+	}()
+	m.mu.Lock()
+	onStart := m.onStart
+	m.mu.Unlock()
+	if onStart != nil {
+		onStart(r)
+	}
+	return r, nil
+}
+
+// Get returns the run with the ID id, running or among the last finished.
+func (m *RunManager) Get(id int) (*Run, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r, ok := m.active[id]; ok {
+		return r, true
+	}
+	for _, r := range m.finished {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// Runs returns the running runs and the last finished ones by ID.
+func (m *RunManager) Runs() []*Run {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := append([]*Run(nil), m.finished...)
+	for _, r := range m.active {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Hold keeps key busy until the returned func is called, for commands run
+// one after another for it.
+func (m *RunManager) Hold(key string) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hold(key)
+}
+
+func (m *RunManager) hold(key string) func() {
+	m.held[key]++
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.held[key]--; m.held[key] == 0 {
+			delete(m.held, key)
+		}
+	}
+}
+
+// Busy reports whether a command is running for key, or key is held.
+func (m *RunManager) Busy(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.busy(key)
+}
+
+func (m *RunManager) busy(key string) bool {
+	if m.held[key] > 0 {
+		return true
+	}
+	for _, r := range m.active {
+		if r.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the number of commands running.
+func (m *RunManager) Count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.active)
+}
+
+// Cancel cancels r and signals its process, escalating from an interrupt
+// to a kill as it is cancelled again. It reports whether the process was
+// signalled.
+func (m *RunManager) Cancel(r *Run) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cancel(r)
+}
+
+// CancelAll cancels every run like Cancel and returns the runs signalled.
+func (m *RunManager) CancelAll() []*Run {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var signalled []*Run
+	for _, r := range m.active {
+		if m.cancel(r) {
+			signalled = append(signalled, r)
+		}
+	}
+	return signalled
+}
+
+func (m *RunManager) cancel(r *Run) bool {
+	if _, ok := m.active[r.ID]; !ok {
+		return false
+	}
+	r.mu.Lock()
+	r.cancelled = true
+	r.mu.Unlock()
+	if err := InterruptProcess(r.Cmd, r.attempts); err != nil {
+		return false
+	}
+	r.attempts++
+	return true
+}
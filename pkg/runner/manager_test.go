@@ -0,0 +1,200 @@
+package runner
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// shell returns the unstarted command running script with sh.
+func shell(t *testing.T, script string) *exec.Cmd {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	return exec.Command("sh", "-c", script)
+}
+
+// wait waits for r to finish, failing the test after a while.
+func wait(t *testing.T, r *Run) RunInfo {
+	t.Helper()
+	select {
+	case <-r.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatalf("run %d did not finish", r.ID)
+	}
+	return r.Info()
+}
+
+// stopRun cancels r until it finishes, as a command may miss an interrupt
+// sent while it starts.
+func stopRun(t *testing.T, m *RunManager, r *Run) {
+	t.Helper()
+	for attempt := 0; ; attempt++ {
+		m.Cancel(r)
+		select {
+		case <-r.Done():
+			return
+		case <-time.After(time.Second):
+			if attempt == 2 {
+				t.Fatalf("run %d did not stop", r.ID)
+			}
+		}
+	}
+}
+
+func TestRunManagerStart(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		wantState string
+		wantCode  int
+		wantLines []string
+	}{
+		{name: "success", script: "echo one; echo two >&2", wantState: RunSucceeded, wantLines: []string{"one", "two"}},
+		{name: "failure", script: "echo oops; exit 3", wantState: RunFailed, wantCode: 3, wantLines: []string{"oops"}},
+		{name: "no output", script: "true", wantState: RunSucceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewRunManager()
+			r, err := m.Start("build", shell(t, tt.script))
+			if err != nil {
+				t.Fatal(err)
+			}
+			info := wait(t, r)
+			if info.State != tt.wantState || info.Code == nil || *info.Code != tt.wantCode {
+				t.Errorf("state %s, code %v, want %s, %d", info.State, info.Code, tt.wantState, tt.wantCode)
+			}
+			if lines, _, _ := r.Subscribe(); !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("lines %q, want %q", lines, tt.wantLines)
+			}
+			if m.Count() != 0 || m.Busy("build") {
+				t.Error("the finished run is still active")
+			}
+			if got, ok := m.Get(r.ID); !ok || got != r {
+				t.Error("Get does not return the finished run")
+			}
+		})
+	}
+}
+
+func TestRunManagerBusy(t *testing.T) {
+	m := NewRunManager()
+	r, err := m.Start("build", shell(t, "sleep 10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stopRun(t, m, r)
+	if !m.Busy("build") || m.Busy("test") {
+		t.Errorf("Busy(build) = %v, Busy(test) = %v while build runs", m.Busy("build"), m.Busy("test"))
+	}
+	if _, err := m.Start("build", shell(t, "true")); !errors.Is(err, ErrBusy) {
+		t.Errorf("starting a second command for build: %v, want ErrBusy", err)
+	}
+	other, err := m.Start("test", shell(t, "true"))
+	if err != nil {
+		t.Fatalf("starting a command for another key: %v", err)
+	}
+	wait(t, other)
+
+	release := m.Hold("deploy")
+	if !m.Busy("deploy") {
+		t.Error("a held key is not busy")
+	}
+	if _, err := m.Start("deploy", shell(t, "true")); !errors.Is(err, ErrBusy) {
+		t.Errorf("starting a command for a held key: %v, want ErrBusy", err)
+	}
+	release()
+	if m.Busy("deploy") {
+		t.Error("a released key is still busy")
+	}
+}
+
+func TestRunManagerCancel(t *testing.T) {
+	m := NewRunManager()
+	// The first Cancel interrupts the command, which may clean up.
+	r, err := m.Start("build", shell(t, "trap 'echo interrupted; exit 130' INT; echo started; while :; do sleep 0.1; done"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, next, stop := r.Subscribe()
+	defer stop()
+	<-next // started
+	if !m.Cancel(r) {
+		t.Fatal("Cancel did not signal the process")
+	}
+	if !r.Cancelled() {
+		t.Error("the run is not cancelled")
+	}
+	if info := wait(t, r); info.State != RunCancelled {
+		t.Errorf("state %s, want %s", info.State, RunCancelled)
+	}
+	if lines, _, _ := r.Subscribe(); !reflect.DeepEqual(lines, []string{"started", "interrupted"}) {
+		t.Errorf("lines %q, want the command interrupted", lines)
+	}
+	if m.Cancel(r) {
+		t.Error("Cancel signalled a finished run")
+	}
+}
+
+func TestRunManagerCancelEscalates(t *testing.T) {
+	m := NewRunManager()
+	// The shell ignores the interrupt, so only the second Cancel stops it.
+	r, err := m.Start("build", shell(t, "trap '' INT; echo started; sleep 10 & wait"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, next, stop := r.Subscribe()
+	defer stop()
+	<-next
+	m.Cancel(r)
+	select {
+	case <-r.Done():
+		t.Fatal("the run stopped on an ignored interrupt")
+	case <-time.After(200 * time.Millisecond):
+	}
+	m.Cancel(r)
+	if info := wait(t, r); info.State != RunCancelled {
+		t.Errorf("state %s, want %s", info.State, RunCancelled)
+	}
+}
+
+func TestRunSubscribe(t *testing.T) {
+	m := NewRunManager()
+	r, err := m.Start("build", shell(t, "echo one; sleep 0.2; echo two; sleep 0.2; echo three"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, next, stop := r.Subscribe()
+	rest, early, _ := r.Subscribe()
+	for line := range next {
+		got = append(got, line)
+		if line == "two" {
+			// Unsubscribing closes the channel.
+			stop()
+		}
+	}
+	stop()
+	wait(t, r)
+	if len(got) == 0 || got[len(got)-1] != "two" {
+		t.Errorf("lines before stop %q, want them to end with two", got)
+	}
+	for line := range early {
+		rest = append(rest, line)
+	}
+	if want := []string{"one", "two", "three"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("lines of a subscriber kept to the end %q, want %q", rest, want)
+	}
+
+	lines, done, _ := r.Subscribe()
+	if _, open := <-done; open {
+		t.Error("the channel of a finished run is open")
+	}
+	if want := []string{"one", "two", "three"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines of the finished run %q, want %q", lines, want)
+	}
+}
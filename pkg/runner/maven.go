@@ -0,0 +1,150 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// mavenPhases are the phases of the clean and default lifecycles offered
+// for every project. Each phase of the default lifecycle runs the ones
+// before it.
+var mavenPhases = []struct {
+	name, doc string
+}{
+	{"clean", "Remove the files of the previous build"},
+	{"validate", "Check the project is correct"},
+	{"compile", "Compile the sources"},
+	{"test", "Run the unit tests"},
+	{"package", "Package the compiled code, e.g. as a JAR"},
+	{"verify", "Run the integration tests and checks"},
+	{"install", "Install the package into the local repository"},
+	{"deploy", "Copy the package to the remote repository"},
+}
+
+// mavenRunner runs the lifecycle phases and plugin goals of a pom.xml,
+// with the Maven wrapper when the project has one.
+type mavenRunner struct {
+	path string
+}
+
+func (r *mavenRunner) Name() string { return "maven" }
+
+func (r *mavenRunner) File() string { return r.path }
+
+// command returns the mvn command of the project: its wrapper, or mvn
+// from the PATH.
+func (r *mavenRunner) command() string {
+	name := "mvnw"
+	if runtime.GOOS == "windows" {
+		name = "mvnw.cmd"
+	}
+	return wrapper(filepath.Dir(r.path), name, "mvn")
+}
+
+// Discover lists the lifecycle phases, then the goals bound in the
+// executions of each build plugin as "prefix:goal", grouped by plugin.
+// Plugins without executions offer their help goal.
+func (r *mavenRunner) Discover() ([]Target, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []Target
+	for i, phase := range mavenPhases {
+		t := Target{Name: phase.name, Doc: phase.doc, Group: "lifecycle", File: r.path, Line: 1}
+		if i > 1 {
+			t.Deps = []string{mavenPhases[i-1].name}
+		}
+		targets = append(targets, t)
+	}
+
+	// Walk the elements by hand to know the line of each plugin.
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var path []string
+	var prefix string
+	var line int
+	var goals []string
+	inPlugin := func() bool {
+		return len(path) >= 4 && strings.Join(path[:4], "/") == "project/build/plugins/plugin"
+	}
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.path, err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			path = append(path, tok.Name.Local)
+			if len(path) == 4 && inPlugin() {
+				prefix, goals = "", nil
+				line = 1 + bytes.Count(data[:dec.InputOffset()], []byte("\n"))
+			}
+		case xml.CharData:
+			text := strings.TrimSpace(string(tok))
+			switch {
+			case !inPlugin():
+			case len(path) == 5 && path[4] == "artifactId":
+				prefix = mavenPluginPrefix(text)
+			case path[len(path)-1] == "goal" && !contains(goals, text):
+				goals = append(goals, text)
+			}
+		case xml.EndElement:
+			if len(path) == 4 && inPlugin() && prefix != "" {
+				if len(goals) == 0 {
+					goals = []string{"help"}
+				}
+				for _, goal := range goals {
+					targets = append(targets, Target{
+						Name:  prefix + ":" + goal,
+						Doc:   fmt.Sprintf("Run the %s goal of the %s plugin", goal, prefix),
+						Group: prefix,
+						File:  r.path,
+						Line:  line,
+					})
+				}
+			}
+			path = path[:len(path)-1]
+		}
+	}
+	return targets, nil
+}
+
+// mavenPluginPrefix returns the prefix goals of the plugin with the given
+// artifact id are run with, following Maven's naming conventions.
+func mavenPluginPrefix(artifactID string) string {
+	if name, ok := strings.CutPrefix(artifactID, "maven-"); ok {
+		if name, ok := strings.CutSuffix(name, "-plugin"); ok {
+			return name
+		}
+	}
+	if name, ok := strings.CutSuffix(artifactID, "-maven-plugin"); ok {
+		return name
+	}
+	return artifactID
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *mavenRunner) Exec(target string, args []string) *exec.Cmd {
+	argv := append([]string{"-f", r.path, target}, args...)
+	return exec.Command(r.command(), argv...)
+}
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// npmRunner runs the scripts of a package.json with the package manager
+// the project uses.
+type npmRunner struct {
+	path string
+}
+
+func (r *npmRunner) Name() string { return "npm" }
+
+func (r *npmRunner) File() string { return r.path }
+
+// packageManager picks npm, yarn or pnpm based on the lockfile next to the
+// package.json.
+func (r *npmRunner) packageManager() string {
+	dir := filepath.Dir(r.path)
+	switch {
+	case fileExists(filepath.Join(dir, "pnpm-lock.yaml")):
+		return "pnpm"
+	case fileExists(filepath.Join(dir, "yarn.lock")):
+		return "yarn"
+	default:
+		return "npm"
+	}
+}
+
+// Discover lists the scripts in the order they appear in package.json,
+// documented by the script body.
+func (r *npmRunner) Discover() ([]Target, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+	// Walk the tokens by hand: decoding into a map would lose the order.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("%s: expected a JSON object", r.path)
+	}
+	var targets []Target
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.path, err)
+		}
+		if key != "scripts" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, fmt.Errorf("%s: %w", r.path, err)
+			}
+			continue
+		}
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			return nil, fmt.Errorf("%s: scripts must be an object", r.path)
+		}
+		for dec.More() {
+			name, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", r.path, err)
+			}
+			line := 1 + bytes.Count(data[:dec.InputOffset()], []byte("\n"))
+			var body string
+			if err := dec.Decode(&body); err != nil {
+				return nil, fmt.Errorf("%s: script %v: %w", r.path, name, err)
+			}
+			targets = append(targets, Target{Name: name.(string), Doc: body, File: r.path, Line: line})
+		}
+		break
+	}
+	return targets, nil
+}
+
+func (r *npmRunner) Exec(target string, args []string) *exec.Cmd {
+	pm := r.packageManager()
+	argv := []string{"run", target}
+	if pm == "npm" && len(args) > 0 {
+		// npm only forwards arguments placed after "--".
+		argv = append(argv, "--")
+	}
+	cmd := exec.Command(pm, append(argv, args...)...)
+	cmd.Dir = filepath.Dir(r.path)
+	return cmd
+}
@@ -0,0 +1,45 @@
+//go:build !windows
+
+package runner
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// SetProcessGroup starts cmd in its own process group so that signals
+// reach the recipes make spawned as well as make itself.
+func SetProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// InterruptProcess signals the process group of cmd, escalating from
+// SIGINT to SIGTERM to SIGKILL as attempt grows.
+func InterruptProcess(cmd *exec.Cmd, attempt int) error {
+	sig := syscall.SIGINT
+	switch {
+	case attempt == 1:
+		sig = syscall.SIGTERM
+	case attempt > 1:
+		sig = syscall.SIGKILL
+	}
+	return syscall.Kill(-cmd.Process.Pid, sig)
+}
+
+// SetDetached starts cmd in a session of its own, so that it keeps
+// running once imake exits and the terminal closes.
+func SetDetached(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// ProcessAlive reports whether the process pid is still running.
+func ProcessAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// KillDetached terminates the process started with SetDetached as pid,
+// and the processes it started.
+func KillDetached(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGTERM)
+}
@@ -0,0 +1,58 @@
+//go:build windows
+
+package runner
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// Process creation flags and rights of the Windows API.
+const (
+	createNewProcessGroup          = 0x00000200
+	detachedProcess                = 0x00000008
+	processQueryLimitedInformation = 0x1000
+	stillActive                    = 259
+)
+
+// SetProcessGroup is a no-op on Windows.
+func SetProcessGroup(cmd *exec.Cmd) {}
+
+// InterruptProcess stops cmd and the processes it started, such as the
+// recipes of make, with taskkill: politely at first, forcefully once
+// attempt grows or if they refuse. Windows has no SIGINT to send to a
+// child.
+func InterruptProcess(cmd *exec.Cmd, attempt int) error {
+	pid := strconv.Itoa(cmd.Process.Pid)
+	if attempt == 0 && exec.Command("taskkill", "/T", "/PID", pid).Run() == nil {
+		return nil
+	}
+	if exec.Command("taskkill", "/F", "/T", "/PID", pid).Run() == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
+
+// SetDetached starts cmd without a console and in a process group of its
+// own, so that it keeps running once imake exits.
+func SetDetached(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: createNewProcessGroup | detachedProcess}
+}
+
+// ProcessAlive reports whether the process pid is still running.
+func ProcessAlive(pid int) bool {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	return syscall.GetExitCodeProcess(h, &code) == nil && code == stillActive
+}
+
+// KillDetached terminates the process started with SetDetached as pid,
+// and the processes it started.
+func KillDetached(pid int) error {
+	return exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(pid)).Run()
+}
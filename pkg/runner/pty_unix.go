@@ -0,0 +1,50 @@
+//go:build !windows
+
+package runner
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+// StartPTY starts cmd attached to a new pseudo-terminal of cols by rows
+// and returns the terminal, so that tools keep their colors and progress
+// bars. Reading it returns the output of the command; writing to it types
+// into the command. The command runs in its own session, and so in its
+// own process group for InterruptProcess.
+func StartPTY(cmd *exec.Cmd, cols, rows int) (io.ReadWriteCloser, error) {
+	f, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+	if err != nil {
+		return nil, err
+	}
+	return ptyFile{f}, nil
+}
+
+// ResizePTY tells the command on tty, as returned by StartPTY, that its
+// terminal is now cols by rows.
+func ResizePTY(tty io.Writer, cols, rows int) error {
+	p, ok := tty.(ptyFile)
+	if !ok {
+		return ErrNoPTY
+	}
+	return pty.Setsize(p.File, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// ptyFile is the terminal side of a command. Linux reports EIO on reads
+// once the command has exited, which is the end of the output here.
+type ptyFile struct {
+	*os.File
+}
+
+func (p ptyFile) Read(b []byte) (int, error) {
+	n, err := p.File.Read(b)
+	if errors.Is(err, syscall.EIO) {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package runner
+
+import (
+	"io"
+	"os/exec"
+)
+
+// StartPTY is not supported on Windows; commands are run with pipes.
+func StartPTY(cmd *exec.Cmd, cols, rows int) (io.ReadWriteCloser, error) {
+	return nil, ErrNoPTY
+}
+
+// ResizePTY is not supported on Windows.
+func ResizePTY(tty io.Writer, cols, rows int) error {
+	return ErrNoPTY
+}
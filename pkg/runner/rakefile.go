@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// rakefileNames are the file names rake looks for.
+var rakefileNames = []string{"Rakefile", "rakefile", "Rakefile.rb", "rakefile.rb"}
+
+var (
+	rakeTaskRegexp  = regexp.MustCompile(`^rake (\S+?)(\[[^\]]*\])?\s*(?:#\s*(.*))?$`)
+	rakeWhereRegexp = regexp.MustCompile(`^rake (\S+?)(?:\[[^\]]*\])?\s+(\S+?):(\d+)`)
+)
+
+// rakeRunner runs tasks of a Rakefile with rake.
+type rakeRunner struct {
+	path string
+}
+
+func (r *rakeRunner) Name() string { return "rake" }
+
+func (r *rakeRunner) File() string { return r.path }
+
+// Discover asks rake for the tasks, their descriptions, where they are
+// defined and their prerequisites, since a Rakefile is Ruby code.
+func (r *rakeRunner) Discover() ([]Target, error) {
+	tasks, err := r.query("--tasks")
+	if err != nil {
+		return nil, err
+	}
+	var targets []Target
+	index := make(map[string]int)
+	for _, line := range tasks {
+		if m := rakeTaskRegexp.FindStringSubmatch(line); m != nil {
+			index[m[1]] = len(targets)
+			targets = append(targets, Target{Name: m[1], Doc: m[3], File: r.path})
+		}
+	}
+
+	where, err := r.query("--where")
+	if err != nil {
+		return nil, err
+	}
+	abs, _ := filepath.Abs(r.path)
+	for _, line := range where {
+		m := rakeWhereRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		i, ok := index[m[1]]
+		if !ok || targets[i].Line > 0 {
+			continue
+		}
+		if m[2] != abs {
+			targets[i].File = m[2]
+		}
+		targets[i].Line, _ = strconv.Atoi(m[3])
+	}
+
+	prereqs, err := r.query("--prereqs")
+	if err != nil {
+		return nil, err
+	}
+	current := -1
+	for _, line := range prereqs {
+		if name, ok := strings.CutPrefix(line, "rake "); ok {
+			current = -1
+			if i, ok := index[name]; ok {
+				current = i
+			}
+			continue
+		}
+		if current >= 0 && strings.TrimSpace(line) != "" {
+			targets[current].Deps = append(targets[current].Deps, strings.TrimSpace(line))
+		}
+	}
+	return targets, nil
+}
+
+// query runs rake with a listing option for every task, documented or
+// not, and returns the lines it prints.
+func (r *rakeRunner) query(option string) ([]string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("rake", "--rakefile", r.path, "--all", option)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("rake %s: %v: %s", option, err, msg)
+		}
+		return nil, fmt.Errorf("rake %s: %w", option, err)
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+func (r *rakeRunner) Exec(target string, args []string) *exec.Cmd {
+	argv := append([]string{"--rakefile", r.path, target}, args...)
+	return exec.Command("rake", argv...)
+}
+
+func (r *rakeRunner) DryRun(target string, args []string) *exec.Cmd {
+	argv := append([]string{"--dry-run", "--rakefile", r.path, target}, args...)
+	return exec.Command("rake", argv...)
+}
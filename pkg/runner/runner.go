@@ -0,0 +1,543 @@
+// Package runner discovers and runs the targets of the supported build
+// tools: make, go-task, just, npm-style package managers, cargo-make, rake,
+// docker compose, Gradle, Maven, Bazel and the go command.
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+)
+
+// Target is a target, task, recipe or script of a build file.
+type Target = makefile.Target
+
+// Runner discovers and runs the targets of a build tool.
+type Runner interface {
+	// Name identifies the runner, e.g. "make".
+	Name() string
+	// File is the build file the targets are read from.
+	File() string
+	// Discover returns the available targets in definition order.
+	Discover() ([]Target, error)
+	// Exec returns the unstarted command running target with the extra
+	// arguments and variable overrides in args.
+	Exec(target string, args []string) *exec.Cmd
+}
+
+// DryRunner is implemented by runners that can show what a target would
+// do without doing it.
+type DryRunner interface {
+	DryRun(target string, args []string) *exec.Cmd
+}
+
+// SourceLister is implemented by runners whose targets come from more
+// files than File, such as Makefiles with include directives.
+type SourceLister interface {
+	// Sources returns the files read by the last Discover.
+	Sources() []string
+}
+
+// VariableLister is implemented by runners whose build files assign
+// variables, such as Makefiles.
+type VariableLister interface {
+	// Variables returns the variables assigned in the build files, as
+	// read from them.
+	Variables() ([]makefile.Variable, error)
+	// EffectiveVariables asks the build tool for the values of the
+	// variables, by name, with every function and reference evaluated.
+	EffectiveVariables() (map[string]string, error)
+}
+
+// Checker is implemented by runners that can point out the problems of
+// their build files, such as lines they do not understand.
+type Checker interface {
+	// Check returns the problems of the build files, in the order they
+	// were found.
+	Check() ([]makefile.Diagnostic, error)
+}
+
+// DatabaseReader is implemented by runners that can list the targets the
+// build tool itself knows, such as make's database, rather than those found
+// reading the build files.
+type DatabaseReader interface {
+	UseDatabase(on bool)
+}
+
+// FlagSetter is implemented by runners that pass flags of the build tool
+// itself, such as make's -j8, to every command they return.
+type FlagSetter interface {
+	SetFlags(flags []string)
+}
+
+// Scoper is implemented by runners that can list the targets of part of
+// a large repository only, such as Bazel.
+type Scoper interface {
+	// SetScope limits the targets to those matching the patterns in
+	// scope; an empty scope lists every target.
+	SetScope(scope []string)
+}
+
+// ErrNoPTY is returned by StartPTY where pseudo-terminals are not
+// available.
+var ErrNoPTY = errors.New("pseudo-terminals are not supported on this platform")
+
+// Names lists the runners Detect accepts by name.
+var Names = []string{"make", "task", "just", "npm", "cargo-make", "rake", "compose", "gradle", "maven", "bazel", "go"}
+
+// registered holds the runners added with Register, by name.
+var registered = make(map[string]registeredRunner)
+
+type registeredRunner struct {
+	files []string
+	open  func(path string) Runner
+}
+
+// Register adds a runner called name to those Detect, Available,
+// FindProjects and FindUp know, after the built-in ones. files are the
+// names of its build files in priority order and open returns the runner
+// for the build file at path. Register is meant to be called before the
+// UI starts, typically from an init function.
+func Register(name string, files []string, open func(path string) Runner) {
+	if _, ok := registered[name]; !ok {
+		Names = append(Names, name)
+	}
+	registered[name] = registeredRunner{files: files, open: open}
+}
+
+// newRunner returns the runner called name for the first of files that
+// exists, or for the first file if none does.
+func newRunner(name string, files []string) (Runner, bool) {
+	path, found := files[0], false
+	for _, f := range files {
+		if fileExists(f) {
+			path, found = f, true
+			break
+		}
+	}
+	if r, ok := registered[name]; ok {
+		return r.open(path), found
+	}
+	switch name {
+	case "task":
+		return &taskRunner{path: path}, found
+	case "just":
+		return &justRunner{path: path}, found
+	case "npm":
+		return &npmRunner{path: path}, found
+	case "cargo-make":
+		return &cargoMakeRunner{path: path}, found
+	case "rake":
+		return &rakeRunner{path: path}, found
+	case "compose":
+		return &composeRunner{path: path}, found
+	case "gradle":
+		return &gradleRunner{path: path}, found
+	case "maven":
+		return &mavenRunner{path: path}, found
+	case "bazel":
+		return &bazelRunner{path: path}, found
+	case "go":
+		return &goRunner{path: path}, found
+	default:
+		return &makeRunner{path: path}, found
+	}
+}
+
+// buildFiles returns the file names looked up by the runner called name.
+func buildFiles(name string) []string {
+	if r, ok := registered[name]; ok {
+		return r.files
+	}
+	switch name {
+	case "task":
+		return taskfileNames
+	case "just":
+		return justfileNames
+	case "npm":
+		return []string{"package.json"}
+	case "cargo-make":
+		return []string{"Makefile.toml"}
+	case "rake":
+		return rakefileNames
+	case "compose":
+		return composeFileNames
+	case "gradle":
+		return gradleFileNames
+	case "maven":
+		return []string{"pom.xml"}
+	case "bazel":
+		return bazelFileNames
+	case "go":
+		return []string{"go.mod"}
+	default:
+		return []string{"Makefile", "makefile", "GNUmakefile"}
+	}
+}
+
+// Detect picks a runner for the working directory. An explicit
+// Makefile path selects make and an explicit name selects that runner;
+// otherwise the first build file found wins, falling back to make so the
+// usual error is reported.
+func Detect(makefile, name string) (Runner, error) {
+	if makefile != "" {
+		if name != "" && name != "make" {
+			return nil, fmt.Errorf("a Makefile path can only be used with the make runner")
+		}
+		return &makeRunner{path: makefile}, nil
+	}
+	if name != "" {
+		for _, n := range Names {
+			if n == name {
+				r, _ := newRunner(name, buildFiles(name))
+				return r, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown runner %q: must be one of %s", name, strings.Join(Names, ", "))
+	}
+	for _, n := range Names {
+		if r, found := newRunner(n, buildFiles(n)); found {
+			return r, nil
+		}
+	}
+	r, _ := newRunner("make", buildFiles("make"))
+	return r, nil
+}
+
+// Available returns the names of the runners with a build file in the
+// working directory.
+func Available() []string {
+	var names []string
+	for _, n := range Names {
+		if _, found := newRunner(n, buildFiles(n)); found {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// makeRunner runs targets of a Makefile with make.
+type makeRunner struct {
+	path string
+
+	// mu guards the fields below: the HTTP API discovers and runs targets
+	// from its own goroutines while the UI rediscovers them.
+	mu       sync.Mutex
+	sources  []string
+	flags    []string
+	database bool     // complete the targets with make's database
+	targets  []Target // from the last Discover
+}
+
+func (r *makeRunner) Name() string { return "make" }
+
+func (r *makeRunner) File() string { return r.path }
+
+func (r *makeRunner) Discover() ([]Target, error) {
+	targets, sources, err := makefile.ReadWithSources(r.path)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	database, flags := r.database, r.flags
+	r.mu.Unlock()
+	if database {
+		targets = r.withDatabase(targets, flags)
+	}
+	abs, err := filepath.Abs(r.path)
+	if err != nil {
+		return nil, err
+	}
+	sub, subSources := submakeTargets(r.path, "", 0, map[string]bool{abs: true})
+	targets, sources = append(targets, sub...), append(sources, subSources...)
+	r.mu.Lock()
+	r.sources, r.targets = sources, targets
+	r.mu.Unlock()
+	return targets, nil
+}
+
+func (r *makeRunner) UseDatabase(on bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.database = on
+}
+
+// withDatabase returns the targets of make's database, read with flags,
+// documented by those read from the Makefile. If make cannot read the
+// Makefile, the targets read are returned as they are and Check tells why.
+func (r *makeRunner) withDatabase(read []Target, flags []string) []Target {
+	if isNMake() {
+		return read
+	}
+	cmd := makeExec(append(append([]string(nil), flags...),
+		"-p", "-q", "-r", "--no-print-directory", "-f", r.path, "-f", "-", "imake-database")...)
+	cmd.Stdin = strings.NewReader(".PHONY: imake-database\nimake-database:\n")
+	out, err := cmd.Output()
+	// -q exits with 1 as imake-database is never up to date.
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return read
+	}
+	database, err := makefile.ParseDatabase(bytes.NewReader(out))
+	if err != nil {
+		return read
+	}
+	kept := database[:0]
+	for _, t := range database {
+		if t.Name != "imake-database" {
+			kept = append(kept, t)
+		}
+	}
+	return makefile.WithDatabase(read, kept, r.path)
+}
+
+func (r *makeRunner) Sources() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sources
+}
+
+func (r *makeRunner) SetFlags(flags []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flags = flags
+}
+
+// currentFlags returns the flags set with SetFlags.
+func (r *makeRunner) currentFlags() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.flags
+}
+
+func (r *makeRunner) Variables() ([]makefile.Variable, error) {
+	return makefile.ReadVariables(r.path)
+}
+
+// printVariables is a Makefile, read after the project's, whose target
+// prints the variables the makefiles and the command line set.
+const printVariables = `.PHONY: imake-print-variables
+imake-print-variables:
+	@:$(foreach v,$(.VARIABLES),$(if $(filter file override command line,$(origin $(v))),$(info $(v)=$($(v)))))
+`
+
+func (r *makeRunner) EffectiveVariables() (map[string]string, error) {
+	if isNMake() {
+		return nil, errors.New("evaluating variables needs GNU make, not nmake")
+	}
+	cmd := makeExec(append(append([]string(nil), r.currentFlags()...),
+		"-s", "--no-print-directory", "-f", r.path, "-f", "-", "imake-print-variables")...)
+	cmd.Stdin = strings.NewReader(printVariables)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("make: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+	values := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		if name, value, ok := strings.Cut(line, "="); ok && name != "" && !strings.ContainsAny(name, " \t") {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+// makeDiagnosticRegexp matches what make reports about a line of the
+// makefiles, such as "Makefile:12: *** missing separator.  Stop." or
+// "Makefile:20: warning: overriding recipe for target 'build'".
+var makeDiagnosticRegexp = regexp.MustCompile(`^(.+?):(\d+): (?:\*\*\* )?(.*?)(?:\.  Stop\.)?$`)
+
+// Check returns the lines of the makefiles the parser does not understand,
+// along with the errors and warnings of make reading them, by file and
+// line. Where both report a line, the parser's message explains make's.
+func (r *makeRunner) Check() ([]makefile.Diagnostic, error) {
+	found, err := makefile.Check(r.path)
+	if err != nil {
+		return nil, err
+	}
+	if isNMake() {
+		return found, nil
+	}
+	_, sources, err := makefile.ReadWithSources(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Have make read the makefiles without running anything. Its exit code
+	// says nothing here: the target is never up to date for -q.
+	var stderr strings.Builder
+	cmd := makeExec(append(append([]string(nil), r.currentFlags()...),
+		"-q", "--no-print-directory", "-f", r.path, "-f", "-", "imake-check")...)
+	cmd.Stdin = strings.NewReader(".PHONY: imake-check\nimake-check:\n")
+	cmd.Stderr = &stderr
+	cmd.Run()
+
+	var reported []makefile.Diagnostic
+	at := make(map[makefile.Location]int)
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		m := makeDiagnosticRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil || !contains(sources, m[1]) {
+			// Output of $(shell ...) and the like.
+			continue
+		}
+		n, _ := strconv.Atoi(m[2])
+		at[makefile.Location{File: m[1], Line: n}] = len(reported)
+		reported = append(reported, makefile.Diagnostic{File: m[1], Line: n, Message: m[3]})
+	}
+	for _, d := range found {
+		if i, ok := at[makefile.Location{File: d.File, Line: d.Line}]; ok {
+			reported[i].Message += ": " + d.Message
+			continue
+		}
+		reported = append(reported, d)
+	}
+	file := make(map[string]int)
+	for i, source := range sources {
+		file[source] = i
+	}
+	sort.SliceStable(reported, func(i, j int) bool {
+		a, b := reported[i], reported[j]
+		if a.File != b.File {
+			return file[a.File] < file[b.File]
+		}
+		return a.Line < b.Line
+	})
+	return reported, nil
+}
+
+func (r *makeRunner) Exec(target string, args []string) *exec.Cmd {
+	return r.command(nil, target, args)
+}
+
+func (r *makeRunner) DryRun(target string, args []string) *exec.Cmd {
+	return r.command([]string{"-n"}, target, args)
+}
+
+// command returns the make command running target with the options opts,
+// in the environment and directory set by the @env and @cwd annotations
+// of the target. The targets of a Makefile run recursively are run in its
+// directory with -C, as the recursive make does.
+func (r *makeRunner) command(opts []string, target string, args []string) *exec.Cmd {
+	r.mu.Lock()
+	targets, flags := r.targets, r.flags
+	r.mu.Unlock()
+	if targets == nil {
+		// Run without discovering first, as by `imake run`.
+		targets, _ = r.Discover()
+	}
+	t, _ := makefile.Find(targets, target)
+	path, dir := r.path, ""
+	if t.Dir != "" {
+		// The Makefile is not in the directory the target runs from. A
+		// relative path to it works in WSL too.
+		dir = filepath.Join(filepath.Dir(r.path), t.Dir)
+		if rel, err := filepath.Rel(dir, r.path); err == nil {
+			path = rel
+		} else if abs, err := filepath.Abs(r.path); err == nil {
+			path = abs
+		}
+	}
+	makefileArgs := []string{"-f", path, target}
+	if t.Submake != "" {
+		makefileArgs = []string{"-C", filepath.Join(filepath.Dir(r.path), t.Submake), strings.TrimPrefix(target, t.Submake+":")}
+	}
+	if isWSL() {
+		makefileArgs[1] = filepath.ToSlash(makefileArgs[1])
+	}
+	argv := append(append(append([]string(nil), opts...), flags...), makefileArgs...)
+	cmd := makeExec(append(argv, args...)...)
+	cmd.Dir = dir
+	if len(t.Env) > 0 {
+		cmd.Env = append(os.Environ(), t.Env...)
+	}
+	return cmd
+}
+
+// FindProjects returns the directories under root, relative to it, that
+// contain a build file of one of the runners. Hidden directories,
+// node_modules and vendor are skipped.
+func FindProjects(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return fs.SkipDir
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if path != root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+			return fs.SkipDir
+		}
+		for _, n := range Names {
+			if _, found := newRunner(n, inDir(path, buildFiles(n))); found {
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				dirs = append(dirs, rel)
+				break
+			}
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+// FindUp returns the nearest of dir and its parents, at most depth levels
+// up, that contains a build file of the runner called name, or of any
+// runner if name is empty.
+func FindUp(dir, name string, depth int) (string, bool) {
+	names := Names
+	if name != "" {
+		names = []string{name}
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for level := 0; level <= depth; level++ {
+		for _, n := range names {
+			if _, found := newRunner(n, inDir(dir, buildFiles(n))); found {
+				return dir, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", false
+}
+
+// inDir joins each of files to dir.
+func inDir(dir string, files []string) []string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = filepath.Join(dir, f)
+	}
+	return paths
+}
@@ -0,0 +1,180 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// inTempDir makes a new directory, holding the empty files named files,
+// the working directory until the test ends, and returns it.
+func inTempDir(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		makefile string
+		runner   string
+		want     string // name of the runner, "" for an error
+		wantFile string
+	}{
+		{name: "nothing falls back to make", want: "make", wantFile: "Makefile"},
+		{name: "Makefile", files: []string{"Makefile"}, want: "make", wantFile: "Makefile"},
+		{name: "GNUmakefile", files: []string{"GNUmakefile"}, want: "make", wantFile: "GNUmakefile"},
+		{name: "Taskfile", files: []string{"Taskfile.yml"}, want: "task", wantFile: "Taskfile.yml"},
+		{name: "justfile", files: []string{"justfile"}, want: "just", wantFile: "justfile"},
+		{name: "package.json", files: []string{"package.json"}, want: "npm", wantFile: "package.json"},
+		{name: "make comes first", files: []string{"package.json", "Makefile"}, want: "make", wantFile: "Makefile"},
+		{name: "by name", files: []string{"Makefile", "justfile"}, runner: "just", want: "just", wantFile: "justfile"},
+		{name: "by name without its file", runner: "task", want: "task", wantFile: "Taskfile.yml"},
+		{name: "unknown name", runner: "scons"},
+		{name: "Makefile path", makefile: "build/rules.mk", want: "make", wantFile: "build/rules.mk"},
+		{name: "Makefile path with make", makefile: "rules.mk", runner: "make", want: "make", wantFile: "rules.mk"},
+		{name: "Makefile path with another runner", makefile: "rules.mk", runner: "npm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempDir(t, tt.files...)
+			r, err := Detect(tt.makefile, tt.runner)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("Detect(%q, %q) = %s, want an error", tt.makefile, tt.runner, r.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Name() != tt.want || r.File() != tt.wantFile {
+				t.Errorf("Detect(%q, %q) = %s for %s, want %s for %s", tt.makefile, tt.runner, r.Name(), r.File(), tt.want, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestFindUp(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  []string
+		from   string
+		runner string
+		depth  int
+		want   string // relative to the temporary directory, "" if not found
+	}{
+		{name: "in the directory", files: []string{"a/Makefile"}, from: "a", depth: 3, want: "a"},
+		{name: "in a parent", files: []string{"Makefile", "a/b/README"}, from: "a/b", depth: 3, want: "."},
+		{name: "nearest wins", files: []string{"Makefile", "a/justfile", "a/b/c/README"}, from: "a/b/c", depth: 3, want: "a"},
+		{name: "too far up", files: []string{"Makefile", "a/b/c/README"}, from: "a/b/c", depth: 2},
+		{name: "by name", files: []string{"justfile", "a/Makefile", "a/b/README"}, from: "a/b", runner: "just", depth: 3, want: "."},
+		{name: "none", files: []string{"a/README"}, from: "a", depth: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := inTempDir(t, tt.files...)
+			got, found := FindUp(filepath.Join(dir, tt.from), tt.runner, tt.depth)
+			want := ""
+			if tt.want != "" {
+				want = filepath.Join(dir, tt.want)
+			}
+			if found != (want != "") || got != want {
+				t.Errorf("FindUp(%s, %q, %d) = %q, %v, want %q", tt.from, tt.runner, tt.depth, got, found, want)
+			}
+		})
+	}
+}
+
+// TestMakeRunnerConcurrent runs what the HTTP API and the UI do at once,
+// for the race detector.
+func TestMakeRunnerConcurrent(t *testing.T) {
+	dir := inTempDir(t)
+	if err := os.WriteFile(filepath.Join(dir, "Makefile"), []byte("build: ## @cwd src Build\ntest:\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Detect("", "make")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			r.Discover()
+		}()
+		go func() {
+			defer wg.Done()
+			if cmd := r.Exec("build", nil); cmd.Dir != "src" {
+				t.Errorf("Exec(build) runs in %q, want src", cmd.Dir)
+			}
+			r.(SourceLister).Sources()
+		}()
+		go func() {
+			defer wg.Done()
+			r.(FlagSetter).SetFlags([]string{"-j4"})
+		}()
+	}
+	wg.Wait()
+}
+
+// TestBazelRunnerConcurrent is TestMakeRunnerConcurrent for Bazel, whose
+// rules come from the cache so that bazel is not needed.
+func TestBazelRunnerConcurrent(t *testing.T) {
+	dir := inTempDir(t, "MODULE.bazel", "app/BUILD.bazel")
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Setenv("HOME", cache)
+	t.Setenv("LocalAppData", cache)
+	writeBazelCache(bazelCachePath(dir, bazelQuery(nil)), []Target{
+		{Name: "//app:app", Doc: "go_binary"},
+		{Name: "//app:app_test", Doc: "go_test"},
+	})
+	r, err := Detect("", "bazel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			if _, err := r.Discover(); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if cmd := r.Exec("//app:app_test", nil); cmd.Args[1] != "test" {
+				t.Errorf("Exec(//app:app_test) = %q, want bazel test", cmd.Args)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			// The same rules as the default scope, so that the cache is used.
+			r.(Scoper).SetScope([]string{"//..."})
+		}()
+	}
+	wg.Wait()
+}
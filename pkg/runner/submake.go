@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"path/filepath"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+)
+
+// maxSubmakeDepth is how many levels of recursive makes Discover follows.
+const maxSubmakeDepth = 4
+
+// submakeTargets returns the targets of the Makefiles that the Makefile at
+// path runs with "$(MAKE) -C dir", and of those they run in turn, along
+// with the files read. dir is the directory of path relative to the root
+// Makefile, which the targets are named and grouped after, such as
+// "services/api:build" in the group services/api.
+func submakeTargets(path, dir string, depth int, visited map[string]bool) ([]Target, []string) {
+	if depth == maxSubmakeDepth {
+		return nil, nil
+	}
+	subdirs, err := makefile.Submakes(path)
+	if err != nil {
+		return nil, nil
+	}
+	var targets []Target
+	var sources []string
+	for _, sub := range subdirs {
+		rel := filepath.ToSlash(filepath.Join(dir, sub))
+		subpath, ok := findMakefile(filepath.Join(filepath.Dir(path), sub))
+		if !ok {
+			continue
+		}
+		abs, err := filepath.Abs(subpath)
+		if err != nil || visited[abs] {
+			continue
+		}
+		visited[abs] = true
+		read, files, err := makefile.ReadWithSources(subpath)
+		if err != nil {
+			continue
+		}
+		names := make(map[string]bool)
+		for _, t := range read {
+			names[t.Name] = true
+		}
+		prefix := func(deps []string) []string {
+			var prefixed []string
+			for _, dep := range deps {
+				if names[dep] {
+					dep = rel + ":" + dep
+				}
+				prefixed = append(prefixed, dep)
+			}
+			return prefixed
+		}
+		for _, t := range read {
+			t.Name = rel + ":" + t.Name
+			t.Deps, t.OrderOnly = prefix(t.Deps), prefix(t.OrderOnly)
+			// The directory is the section of the Sidebar, so the tree of
+			// Makefiles shows as groups of targets.
+			t.Group, t.Submake, t.Dir = rel, rel, ""
+			targets = append(targets, t)
+		}
+		sources = append(sources, files...)
+		nested, nestedFiles := submakeTargets(subpath, rel, depth+1, visited)
+		targets, sources = append(targets, nested...), append(sources, nestedFiles...)
+	}
+	return targets, sources
+}
+
+// findMakefile returns the Makefile make reads in dir.
+func findMakefile(dir string) (string, bool) {
+	for _, name := range buildFiles("make") {
+		if path := filepath.Join(dir, name); fileExists(path) {
+			return path, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMakeRunnerSubmakes(t *testing.T) {
+	dir := inTempDir(t)
+	files := map[string]string{
+		"Makefile":                    "all:\n\t$(MAKE) -C services/api build\n",
+		"services/api/Makefile":       "build: gen ## Build the API\n\t$(MAKE) -C proto\ngen:\n",
+		"services/api/proto/Makefile": "protos:\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := Detect("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets, err := r.Discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	type target struct {
+		Name, Group string
+		Deps        []string
+	}
+	var got []target
+	for _, t := range targets {
+		got = append(got, target{t.Name, t.Group, t.Deps})
+	}
+	want := []target{
+		{"all", "", nil},
+		{"services/api:build", "services/api", []string{"services/api:gen"}},
+		{"services/api:gen", "services/api", nil},
+		{"services/api/proto:protos", "services/api/proto", nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Discover() = %+v, want %+v", got, want)
+	}
+
+	args := r.Exec("services/api/proto:protos", nil).Args
+	if want := []string{"-C", filepath.Join("services", "api", "proto"), "protos"}; !reflect.DeepEqual(args[len(args)-3:], want) {
+		t.Errorf("Exec() = %q, want it to end with %q", args, want)
+	}
+}
@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// taskfileNames are the file names go-task looks for, in priority order.
+var taskfileNames = []string{"Taskfile.yml", "taskfile.yml", "Taskfile.yaml", "taskfile.yaml"}
+
+// taskRunner runs tasks of a Taskfile with go-task.
+type taskRunner struct {
+	path string
+}
+
+func (r *taskRunner) Name() string { return "task" }
+
+func (r *taskRunner) File() string { return r.path }
+
+// Discover lists the tasks of the Taskfile. Tasks marked internal are
+// skipped since task refuses to run them directly.
+func (r *taskRunner) Discover() ([]Target, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+	// Decode into a node first: a map would lose the order of the tasks.
+	var doc struct {
+		Tasks yaml.Node `yaml:"tasks"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", r.path, err)
+	}
+	if doc.Tasks.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var targets []Target
+	for i := 0; i+1 < len(doc.Tasks.Content); i += 2 {
+		key, value := doc.Tasks.Content[i], doc.Tasks.Content[i+1]
+		var task struct {
+			Desc     string      `yaml:"desc"`
+			Summary  string      `yaml:"summary"`
+			Internal bool        `yaml:"internal"`
+			Deps     []yaml.Node `yaml:"deps"`
+		}
+		// Tasks may be a bare list of commands or a single string.
+		if value.Kind == yaml.MappingNode {
+			if err := value.Decode(&task); err != nil {
+				return nil, fmt.Errorf("%s: task %s: %w", r.path, key.Value, err)
+			}
+		}
+		if task.Internal {
+			continue
+		}
+		doc := task.Desc
+		if doc == "" {
+			doc = strings.TrimSpace(task.Summary)
+		}
+		targets = append(targets, Target{
+			Name: key.Value,
+			Doc:  doc,
+			File: r.path,
+			Line: key.Line,
+			Deps: taskDependencies(task.Deps),
+		})
+	}
+	return targets, nil
+}
+
+// taskDependencies returns the task names of a deps list, whose entries
+// are either plain names or {task: name, vars: ...} mappings.
+func taskDependencies(nodes []yaml.Node) []string {
+	var deps []string
+	for _, n := range nodes {
+		if n.Kind == yaml.ScalarNode {
+			deps = append(deps, n.Value)
+			continue
+		}
+		var dep struct {
+			Task string `yaml:"task"`
+		}
+		if n.Decode(&dep) == nil && dep.Task != "" {
+			deps = append(deps, dep.Task)
+		}
+	}
+	return deps
+}
+
+func (r *taskRunner) Exec(target string, args []string) *exec.Cmd {
+	argv := append([]string{"--taskfile", r.path, target}, args...)
+	return exec.Command("task", argv...)
+}
+
+func (r *taskRunner) DryRun(target string, args []string) *exec.Cmd {
+	argv := append([]string{"--dry", "--taskfile", r.path, target}, args...)
+	return exec.Command("task", argv...)
+}
@@ -0,0 +1,311 @@
+// Package server serves a local HTTP/JSON API of the targets of a runner
+// and of their runs, for editor extensions and web UIs to drive imake:
+//
+//	GET  /targets            list the targets
+//	GET  /runs               list the running and last finished runs
+//	POST /runs               run {"target": "build", "args": ["V=1"]}
+//	GET  /runs/{id}          show a run
+//	GET  /runs/{id}/log      stream the output of a run as server-sent events
+//	POST /runs/{id}/cancel   interrupt a run, killing it when repeated
+//
+// Runs go through a runner.RunManager, which the UI shares when it serves
+// the API too.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+	"github.com/gshireesh/imake/pkg/runner"
+)
+
+// Target is the JSON form of a target.
+type Target struct {
+	Name   string   `json:"name"`
+	Doc    string   `json:"doc,omitempty"`
+	File   string   `json:"file"`
+	Line   int      `json:"line"`
+	Deps   []string `json:"deps,omitempty"`
+	Phony  bool     `json:"phony,omitempty"`
+	Group  string   `json:"group,omitempty"`
+	Badges []string `json:"badges,omitempty"`
+	Kind   string   `json:"kind"`
+}
+
+// kinds name the kinds of targets in the JSON.
+var kinds = map[makefile.Kind]string{
+	makefile.KindTarget:   "target",
+	makefile.KindFile:     "file",
+	makefile.KindPattern:  "pattern",
+	makefile.KindInternal: "internal",
+}
+
+// overrideRegexp matches the variable overrides runs accept as args.
+var overrideRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// reservedVariables cannot be overridden through the API: they change
+// which commands make runs rather than what the target builds.
+var reservedVariables = []string{"SHELL", "MAKEFLAGS", "MAKEFILES", "MFLAGS"}
+
+// runRequest is the body of POST /runs.
+type runRequest struct {
+	Target string   `json:"target"`
+	Args   []string `json:"args"`
+}
+
+// New returns the handler of the API for the targets of r, running them
+// through runs.
+func New(r runner.Runner, runs *runner.RunManager) http.Handler {
+	s := &server{runner: r, runs: runs}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /targets", s.listTargets)
+	mux.HandleFunc("GET /runs", s.listRuns)
+	mux.HandleFunc("POST /runs", s.startRun)
+	mux.HandleFunc("GET /runs/{id}", s.showRun)
+	mux.HandleFunc("GET /runs/{id}/log", s.streamLog)
+	mux.HandleFunc("POST /runs/{id}/cancel", s.cancelRun)
+	return localHost(sameOrigin(mux))
+}
+
+type server struct {
+	runner runner.Runner
+	runs   *runner.RunManager
+}
+
+// localHost refuses the requests whose Host is not this machine: a page
+// of a site whose name resolves to 127.0.0.1 would otherwise be of the
+// same origin as the API. Only loopback names and addresses, and the
+// address the connection came to, are accepted.
+func localHost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		local := false
+		if addr, ok := req.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+			if h, _, err := net.SplitHostPort(addr.String()); err == nil {
+				local = h == strings.Trim(host, "[]")
+			}
+		}
+		if !local && !isLoopbackHost(host) {
+			httpError(w, http.StatusForbidden, fmt.Sprintf("host %q is not allowed", req.Host))
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// sameOrigin refuses the requests web pages of other sites make to the
+// API, which would otherwise let any page visited run targets.
+func sameOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if origin := req.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || u.Host != req.Host {
+				httpError(w, http.StatusForbidden, "cross-origin requests are not allowed")
+				return
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func (s *server) listTargets(w http.ResponseWriter, req *http.Request) {
+	discovered, err := s.runner.Discover()
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	all := req.URL.Query().Has("all")
+	list := make([]Target, 0, len(discovered))
+	for _, t := range discovered {
+		if all || t.Kind == makefile.KindTarget {
+			list = append(list, Target{t.Name, t.Doc, t.File, t.Line, t.Deps, t.Phony, t.Group, t.Badges, kinds[t.Kind]})
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *server) listRuns(w http.ResponseWriter, req *http.Request) {
+	list := make([]runner.RunInfo, 0)
+	for _, r := range s.runs.Runs() {
+		list = append(list, r.Info())
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *server) startRun(w http.ResponseWriter, req *http.Request) {
+	// Forms are refused: browsers send them to other sites without asking.
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType != "application/json" {
+		httpError(w, http.StatusUnsupportedMediaType, "the body must be application/json")
+		return
+	}
+	var body runRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		httpError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+	if err := checkArgs(body.Args); err != nil {
+		httpError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	discovered, err := s.runner.Discover()
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if _, ok := makefile.Find(discovered, body.Target); !ok {
+		httpError(w, http.StatusNotFound, fmt.Sprintf("unknown target %q", body.Target))
+		return
+	}
+	run, err := s.runs.Start(body.Target, s.runner.Exec(body.Target, body.Args))
+	if errors.Is(err, runner.ErrBusy) {
+		httpError(w, http.StatusConflict, body.Target+" is still running")
+		return
+	}
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/runs/%d", run.ID))
+	writeJSON(w, http.StatusCreated, run.Info())
+}
+
+// checkArgs returns an error unless every arg of a run is a variable
+// override such as V=1. Flags, such as --eval or -f, would let a client
+// run any command rather than a target.
+func checkArgs(args []string) error {
+	for _, arg := range args {
+		if !overrideRegexp.MatchString(arg) {
+			return fmt.Errorf("invalid arg %q: only variable overrides such as V=1 are allowed", arg)
+		}
+		if name, _, _ := strings.Cut(arg, "="); slices.Contains(reservedVariables, name) {
+			return fmt.Errorf("invalid arg %q: %s cannot be overridden", arg, name)
+		}
+	}
+	return nil
+}
+
+func (s *server) showRun(w http.ResponseWriter, req *http.Request) {
+	if run, ok := s.run(w, req); ok {
+		writeJSON(w, http.StatusOK, run.Info())
+	}
+}
+
+// streamLog sends the output of a run so far, then the lines it prints as
+// they come, as "line" events, and an "exit" event with the run once it
+// finished.
+func (s *server) streamLog(w http.ResponseWriter, req *http.Request) {
+	run, ok := s.run(w, req)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httpError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	lines, next, stop := run.Subscribe()
+	defer stop()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	send := func(event string, data []byte) {
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	}
+	for _, line := range lines {
+		send("line", quote(line))
+	}
+	flusher.Flush()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case line, open := <-next:
+			if !open {
+				<-run.Done()
+				info, _ := json.Marshal(run.Info())
+				send("exit", info)
+				flusher.Flush()
+				return
+			}
+			send("line", quote(line))
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *server) cancelRun(w http.ResponseWriter, req *http.Request) {
+	run, ok := s.run(w, req)
+	if !ok {
+		return
+	}
+	if !s.runs.Cancel(run) {
+		httpError(w, http.StatusConflict, "the run is not running")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, run.Info())
+}
+
+// run returns the run named by the id in the path of req, writing the
+// error response if there is none.
+func (s *server) run(w http.ResponseWriter, req *http.Request) (*runner.Run, bool) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "invalid run id")
+		return nil, false
+	}
+	run, ok := s.runs.Get(id)
+	if !ok {
+		httpError(w, http.StatusNotFound, fmt.Sprintf("no run %d", id))
+	}
+	return run, ok
+}
+
+// quote returns line as a JSON string, which keeps it on one data line of
+// an event however it is made.
+func quote(line string) []byte {
+	data, _ := json.Marshal(line)
+	return data
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func httpError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// IsLoopback reports whether addr, as given to Listen, only accepts
+// connections from this machine.
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	return err == nil && isLoopbackHost(host)
+}
+
+// isLoopbackHost reports whether host, a name or an address, is this
+// machine.
+func isLoopbackHost(host string) bool {
+	host = strings.Trim(host, "[]")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
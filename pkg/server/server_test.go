@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gshireesh/imake/pkg/runner"
+)
+
+// fakeRunner has the targets named in targets and runs them with true.
+type fakeRunner struct {
+	targets []string
+	execs   [][]string // the args of every Exec
+}
+
+func (r *fakeRunner) Name() string { return "fake" }
+
+func (r *fakeRunner) File() string { return "Makefile" }
+
+func (r *fakeRunner) Discover() ([]runner.Target, error) {
+	var list []runner.Target
+	for _, name := range r.targets {
+		list = append(list, runner.Target{Name: name})
+	}
+	return list, nil
+}
+
+func (r *fakeRunner) Exec(target string, args []string) *exec.Cmd {
+	r.execs = append(r.execs, args)
+	return exec.Command("true")
+}
+
+func TestLocalHost(t *testing.T) {
+	tests := []struct {
+		host  string
+		local string // the address the connection came to
+		want  int
+	}{
+		{host: "localhost:7878", want: http.StatusOK},
+		{host: "127.0.0.1:7878", want: http.StatusOK},
+		{host: "[::1]:7878", want: http.StatusOK},
+		{host: "app.localhost", want: http.StatusOK},
+		{host: "192.168.1.5:7878", local: "192.168.1.5:7878", want: http.StatusOK},
+		{host: "192.168.1.5:7878", want: http.StatusForbidden},
+		{host: "rebind.example.com:7878", local: "127.0.0.1:7878", want: http.StatusForbidden},
+		{host: "localhost.example.com", want: http.StatusForbidden},
+	}
+	handler := New(&fakeRunner{}, runner.NewRunManager())
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/runs", nil)
+			req.Host = tt.host
+			if tt.local != "" {
+				addr, err := net.ResolveTCPAddr("tcp", tt.local)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, addr))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("GET /runs with Host %s: %d, want %d", tt.host, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartRunArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want int
+	}{
+		{name: "overrides", args: `["V=1", "GOFLAGS=-race"]`, want: http.StatusCreated},
+		{name: "none", args: `[]`, want: http.StatusCreated},
+		{name: "eval", args: `["--eval=x:;touch /tmp/x"]`, want: http.StatusBadRequest},
+		{name: "makefile", args: `["-f", "/tmp/x.mk"]`, want: http.StatusBadRequest},
+		{name: "target", args: `["clean"]`, want: http.StatusBadRequest},
+		{name: "shell", args: `["SHELL=/tmp/x"]`, want: http.StatusBadRequest},
+		{name: "makeflags", args: `["MAKEFLAGS=--eval=x"]`, want: http.StatusBadRequest},
+		{name: "makefiles", args: `["MAKEFILES=/tmp/x.mk"]`, want: http.StatusBadRequest},
+		{name: "appending", args: `["V+=1"]`, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.want == http.StatusCreated && runtime.GOOS == "windows" {
+				t.Skip("needs true")
+			}
+			r := &fakeRunner{targets: []string{"build"}}
+			runs := runner.NewRunManager()
+			req := httptest.NewRequest("POST", "http://localhost:7878/runs", strings.NewReader(`{"target": "build", "args": `+tt.args+`}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			New(r, runs).ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("POST /runs with args %s: %d %s, want %d", tt.args, w.Code, w.Body, tt.want)
+			}
+			if ran := len(r.execs) > 0; ran != (tt.want == http.StatusCreated) {
+				t.Errorf("the target ran: %v", ran)
+			}
+			for _, run := range runs.Runs() {
+				<-run.Done()
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package ui
+
+// successText, failureText and runningText color status markers such as
+// "✓" or "✗ 2". With accessibility on, success and failure differ in more
+// than red and green: they are blue and orange, which color-blind users
+// tell apart, and failures are bold and underlined too.
+func successText(text string) string {
+	if config.Accessibility {
+		return "\x1b[34;1m" + text + "\x1b[0m"
+	}
+	return "\x1b[32m" + text + "\x1b[0m"
+}
+
+func failureText(text string) string {
+	if config.Accessibility {
+		return "\x1b[38;5;208;1;4m" + text + "\x1b[0m"
+	}
+	return "\x1b[31m" + text + "\x1b[0m"
+}
+
+func runningText(text string) string {
+	if config.Accessibility {
+		return "\x1b[33;1m" + text + "\x1b[0m"
+	}
+	return "\x1b[33m" + text + "\x1b[0m"
+}
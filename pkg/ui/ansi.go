@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ANSI handling modes for command output.
+const (
+	ANSIRender = "render" // translate colors into what gocui can display
+	ANSIStrip  = "strip"  // remove every escape sequence
+)
+
+// escapeRegexp matches CSI sequences (colors, cursor movement, erase...),
+// OSC sequences (window titles, hyperlinks), charset designations and any
+// other stray escape.
+var escapeRegexp = regexp.MustCompile(`\x1b(\[[0-9;?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)|[()#][0-9A-Za-z]|[^\[\]]|$)`)
+
+// filterANSI prepares a line of command output for the output view
+// according to mode.
+func filterANSI(line, mode string) string {
+	return escapeRegexp.ReplaceAllStringFunc(line, func(seq string) string {
+		if mode == ANSIRender && strings.HasPrefix(seq, "\x1b[") && strings.HasSuffix(seq, "m") {
+			return translateSGR(seq[2 : len(seq)-1])
+		}
+		return ""
+	})
+}
+
+// translateSGR rewrites the parameters of a "select graphic rendition"
+// sequence as one sequence per attribute, since gocui only understands
+// simple parameter lists. Bright and 24-bit colors are mapped onto the
+// 256 color palette.
+func translateSGR(params string) string {
+	if params == "" {
+		return "\x1b[0m"
+	}
+	fields := strings.Split(params, ";")
+	codes := make([]int, 0, len(fields))
+	for _, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			n = 0
+		}
+		codes = append(codes, n)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(codes); i++ {
+		c := codes[i]
+		switch {
+		case (c == 38 || c == 48) && i+2 < len(codes) && codes[i+1] == 5:
+			fmt.Fprintf(&b, "\x1b[%d;5;%dm", c, codes[i+2])
+			i += 2
+		case (c == 38 || c == 48) && i+4 < len(codes) && codes[i+1] == 2:
+			fmt.Fprintf(&b, "\x1b[%d;5;%dm", c, rgbTo256(codes[i+2], codes[i+3], codes[i+4]))
+			i += 4
+		case c >= 90 && c <= 97:
+			fmt.Fprintf(&b, "\x1b[38;5;%dm", c-90+8)
+		case c >= 100 && c <= 107:
+			fmt.Fprintf(&b, "\x1b[48;5;%dm", c-100+8)
+		case c == 0 || c == 1 || c == 4 || c == 7 || c == 39 || c == 49 ||
+			(c >= 30 && c <= 37) || (c >= 40 && c <= 47):
+			fmt.Fprintf(&b, "\x1b[%dm", c)
+		}
+	}
+	return b.String()
+}
+
+// rgbTo256 returns the closest color of the 6x6x6 cube of the 256 color
+// palette.
+func rgbTo256(r, g, b int) int {
+	scale := func(v int) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return (v - 35) / 40
+	}
+	return 16 + 36*scale(r) + 6*scale(g) + scale(b)
+}
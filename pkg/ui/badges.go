@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+	"github.com/jroimartin/gocui"
+)
+
+// badgeColors are the colors of the common badges; the others get one of
+// otherBadgeColors by their name, so a badge keeps its color.
+var (
+	badgeColors = map[string]string{
+		"test":   "32",
+		"deploy": "35",
+		"docker": "34",
+		"ci":     "36",
+		"lint":   "33",
+	}
+	otherBadgeColors = []string{"38;5;69", "38;5;136", "38;5;168", "38;5;71", "38;5;139", "38;5;173"}
+)
+
+// badgeFilter is the badge the Sidebar is narrowed to, or "".
+var badgeFilter string
+
+// formatBadge returns badge as a colored tag for the Sidebar.
+func formatBadge(badge string) string {
+	color, ok := badgeColors[badge]
+	if !ok {
+		h := fnv.New32a()
+		h.Write([]byte(badge))
+		color = otherBadgeColors[h.Sum32()%uint32(len(otherBadgeColors))]
+	}
+	return "\x1b[" + color + "m" + badge + "\x1b[0m"
+}
+
+// targetBadges returns the badges of target as tags, each after a space.
+func targetBadges(target string) string {
+	t, ok := makefile.Find(targets, target)
+	if !ok {
+		return ""
+	}
+	s := ""
+	for _, badge := range t.Badges {
+		s += " " + formatBadge(badge)
+	}
+	return s
+}
+
+// hasBadge reports whether t is shown while the Sidebar is narrowed to
+// badgeFilter.
+func hasBadge(t makefile.Target) bool {
+	return badgeFilter == "" || slices.Contains(t.Badges, badgeFilter)
+}
+
+// allBadges returns the badges of the targets in the order they first
+// appear.
+func allBadges() []string {
+	var badges []string
+	seen := make(map[string]bool)
+	for _, t := range targets {
+		for _, badge := range t.Badges {
+			if !seen[badge] {
+				seen[badge] = true
+				badges = append(badges, badge)
+			}
+		}
+	}
+	return badges
+}
+
+// cycleBadgeFilter narrows the Sidebar to the targets with the next badge,
+// and back to every target after the last one.
+func cycleBadgeFilter(g *gocui.Gui, v *gocui.View) error {
+	badges := allBadges()
+	if len(badges) == 0 {
+		return showWarning(g, "badges: no target has a badge such as @test in its doc")
+	}
+	next := badges[0]
+	for i, badge := range badges {
+		if badge == badgeFilter {
+			next = ""
+			if i+1 < len(badges) {
+				next = badges[i+1]
+			}
+		}
+	}
+	badgeFilter = next
+	setTargets(targets)
+	if err := refreshSidebar(g); err != nil {
+		return err
+	}
+	if badgeFilter == "" {
+		return showNotice("showing every target")
+	}
+	return showNotice(fmt.Sprintf("showing the %s targets (@ for the next badge)", formatBadge(badgeFilter)))
+}
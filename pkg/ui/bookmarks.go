@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Bookmarks are lines of a tab's output, counted from the first line of
+// the run so they stay put when old lines are dropped. A line of the
+// Command Output view is line shownDropped+i of the run.
+
+// bookmarkLine returns the line of the Command Output view to bookmark:
+// the end of the selection while selecting, else the top line shown.
+func bookmarkLine(v *gocui.View) int {
+	if selection.active {
+		return selection.cursor
+	}
+	_, oy := v.Origin()
+	return oy
+}
+
+// toggleBookmark bookmarks the current line of the output, or removes its
+// bookmark.
+func toggleBookmark(g *gocui.Gui, v *gocui.View) error {
+	if activeTab < 0 || v == nil || v.Name() != "command" {
+		return nil
+	}
+	t := tabs[activeTab]
+	line := t.shownDropped + bookmarkLine(v)
+	i, found := slices.BinarySearch(t.bookmarks, line)
+	if found {
+		t.bookmarks = slices.Delete(t.bookmarks, i, i+1)
+		return showNotice(fmt.Sprintf("removed bookmark at line %d", line+1))
+	}
+	t.bookmarks = slices.Insert(t.bookmarks, i, line)
+	return showNotice(fmt.Sprintf("bookmarked line %d (%d bookmarks, ' to jump)", line+1, len(t.bookmarks)))
+}
+
+// nextBookmark and prevBookmark scroll the output to the next or previous
+// bookmark, wrapping around at the ends.
+func nextBookmark(g *gocui.Gui, v *gocui.View) error {
+	return jumpBookmark(g, v, 1)
+}
+
+func prevBookmark(g *gocui.Gui, v *gocui.View) error {
+	return jumpBookmark(g, v, -1)
+}
+
+func jumpBookmark(g *gocui.Gui, v *gocui.View, dir int) error {
+	if activeTab < 0 || v == nil || v.Name() != "command" {
+		return nil
+	}
+	t := tabs[activeTab]
+	// Bookmarks of dropped lines are gone.
+	i, _ := slices.BinarySearch(t.bookmarks, t.shownDropped)
+	marks := t.bookmarks[i:]
+	if len(marks) == 0 {
+		return showNotice("no bookmarks (m to add one)")
+	}
+
+	current := t.shownDropped + bookmarkLine(v)
+	n := len(marks)
+	i, found := slices.BinarySearch(marks, current)
+	switch {
+	case dir > 0 && found:
+		i = (i + 1) % n
+	case dir > 0:
+		i %= n
+	default:
+		i = (i - 1 + n) % n
+	}
+	line := marks[i] - t.shownDropped
+	if selection.active {
+		if err := moveSelection(v, line-selection.cursor); err != nil {
+			return err
+		}
+	} else {
+		v.Autoscroll = false
+		if err := v.SetOrigin(0, line); err != nil {
+			return err
+		}
+	}
+	return showNotice(fmt.Sprintf("bookmark %d/%d: line %d", i+1, n, marks[i]+1))
+}
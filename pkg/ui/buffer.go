@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jroimartin/gocui"
+)
+
+// defaultOutputLines is how many lines of output each tab keeps unless the
+// config says otherwise.
+const defaultOutputLines = 10000
+
+// outputLines is how many lines of output each tab keeps, or 0 to keep
+// them all. It is set from the config on startup.
+var outputLines = defaultOutputLines
+
+// outputBuffer keeps the output of a run, up to outputLines lines: once it
+// is full, each new line drops the oldest one.
+type outputBuffer struct {
+	lines   [][]byte // ring of complete lines, without their newline
+	start   int      // index of the oldest line in lines
+	partial []byte   // the last line until its newline is written
+	dropped int      // lines dropped since the last Reset
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			b.partial = append(b.partial, p...)
+			return n, nil
+		}
+		b.push(append(b.partial, p[:i]...))
+		b.partial = nil
+		p = p[i+1:]
+	}
+}
+
+func (b *outputBuffer) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+// push adds a complete line, dropping the oldest one if the buffer is full.
+// The ring only wraps once it is full, so start stays 0 until then.
+func (b *outputBuffer) push(line []byte) {
+	if outputLines <= 0 || len(b.lines) < outputLines {
+		b.lines = append(b.lines, line)
+		return
+	}
+	b.lines[b.start] = line
+	b.start = (b.start + 1) % len(b.lines)
+	b.dropped++
+}
+
+// Bytes returns the retained output, oldest line first.
+func (b *outputBuffer) Bytes() []byte {
+	var out bytes.Buffer
+	out.Grow(b.Len())
+	for i := range b.lines {
+		out.Write(b.lines[(b.start+i)%len(b.lines)])
+		out.WriteByte('\n')
+	}
+	out.Write(b.partial)
+	return out.Bytes()
+}
+
+func (b *outputBuffer) String() string {
+	return string(b.Bytes())
+}
+
+// Len returns the size of the retained output in bytes.
+func (b *outputBuffer) Len() int {
+	n := len(b.partial)
+	for _, line := range b.lines {
+		n += len(line) + 1
+	}
+	return n
+}
+
+// Lines returns the number of retained lines, counting an unfinished last
+// line.
+func (b *outputBuffer) Lines() int {
+	if len(b.partial) > 0 {
+		return len(b.lines) + 1
+	}
+	return len(b.lines)
+}
+
+func (b *outputBuffer) Reset() {
+	*b = outputBuffer{}
+}
+
+// dumpOutput writes the output the active tab retains, without ANSI
+// escapes, to a file in the log directory.
+func dumpOutput(g *gocui.Gui, v *gocui.View) error {
+	if activeTab < 0 {
+		return showWarning(g, "dump: no output to write")
+	}
+	t := tabs[activeTab]
+	path := filepath.Join(logDir, logFileName(t.name, "output")+".log")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		return showWarning(g, fmt.Sprintf("dump: %v", err))
+	}
+	if err := os.WriteFile(path, []byte(filterANSI(t.buf.String(), ANSIStrip)), 0o644); err != nil {
+		return showWarning(g, fmt.Sprintf("dump: %v", err))
+	}
+	msg := fmt.Sprintf("wrote %d lines of %s to %s", t.buf.Lines(), t.name, path)
+	if t.buf.dropped > 0 {
+		msg += fmt.Sprintf(" (%d earlier lines were dropped)", t.buf.dropped)
+	}
+	return showWarning(g, msg)
+}
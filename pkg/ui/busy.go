@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// tabsRunning reports whether a tab's command is running.
+func tabsRunning() bool {
+	for _, t := range tabs {
+		if t.status == tabRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// layoutBusy shows a "running <target>…" banner below the Command Output
+// view while the command of the active tab runs, so there is feedback
+// before its first output. It is called by the manager, which removes the
+// banner once the command is done.
+func layoutBusy(g *gocui.Gui) error {
+	if activeTab < 0 || tabs[activeTab].status != tabRunning {
+		if err := g.DeleteView("busy"); err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+			return err
+		}
+		return nil
+	}
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	if y1-y0 < 4 {
+		return nil
+	}
+	if _, err := g.SetView("command", x0, y0, x1, y1-1); err != nil {
+		return err
+	}
+	v, err := g.SetView("busy", x0-1, y1-1, x1+1, y1+1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	v.Frame = false
+	t := tabs[activeTab]
+	v.Clear()
+	fmt.Fprintf(v, "\x1b[33m%c running %s…\x1b[0m \x1b[38;5;8m%s\x1b[0m", spinnerFrame(), t.name, time.Since(t.started).Truncate(time.Second))
+	return nil
+}
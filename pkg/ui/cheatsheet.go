@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// actionLabels describe the actions the palette does not list.
+var actionLabels = map[string]string{
+	"cursor_down":           "Select the next target",
+	"cursor_up":             "Select the previous target",
+	"cursor_top":            "Select the first target",
+	"cursor_bottom":         "Select the last target",
+	"focus_next":            "Focus the next pane",
+	"prev_tab":              "Previous output tab",
+	"scroll_down":           "Scroll the output down",
+	"scroll_up":             "Scroll the output up",
+	"scroll_page_up":        "Scroll the output up a page",
+	"scroll_page_down":      "Scroll the output down a page",
+	"scroll_half_page_up":   "Scroll the output up half a page",
+	"scroll_half_page_down": "Scroll the output down half a page",
+	"scroll_top":            "Jump to the top of the output",
+	"scroll_bottom":         "Jump to the bottom of the output",
+	"select_lines":          "Select lines of the output",
+	"run":                   "Run the selected target",
+	"edit":                  "Open the target's definition in the editor",
+	"mark":                  "Mark the target for a parallel run or the queue",
+	"grow_sidebar":          "Widen the Sidebar",
+	"shrink_sidebar":        "Narrow the Sidebar",
+	"zoom_output":           "Zoom the output to the whole screen",
+	"toggle_help":           "Show/hide the help and hints panes",
+	"interact":              "Type input to the running command",
+	"palette":               "Command palette",
+	"keys":                  "Show this list of keys",
+}
+
+// keyCategories group the actions in the cheat sheet. Actions left out
+// are listed under "Other".
+var keyCategories = []struct {
+	title   string
+	actions []string
+}{
+	{"Targets", []string{"cursor_down", "cursor_up", "cursor_top", "cursor_bottom", "search", "toggle_hidden", "badge_filter", "sort", "favorite", "reload"}},
+	{"Running", []string{"run", "run_with_args", "dry_run", "rerun", "run_sudo", "mark", "run_marked", "run_queue", "close_runs", "detach", "jobs", "shell", "interact", "record_macro", "cancel", "make_flags"}},
+	{"Output", []string{"scroll_down", "scroll_up", "scroll_page_up", "scroll_page_down", "scroll_half_page_up", "scroll_half_page_down", "scroll_top", "scroll_bottom", "select_lines", "copy_output", "copy_report", "save_report", "page_output", "dump_output", "fold_sections", "bookmark", "next_bookmark", "prev_bookmark"}},
+	{"Tabs and runs", []string{"next_tab", "prev_tab", "close_tab", "compare", "diff_previous", "problems", "history", "stats"}},
+	{"Build file", []string{"edit", "graph", "recipe", "variables", "diagnostics", "export_help", "projects", "recent_projects", "hosts"}},
+	{"Layout and settings", []string{"focus_next", "grow_sidebar", "shrink_sidebar", "zoom_output", "toggle_help", "toggle_log", "dotenv", "timestamps"}},
+	{"General", []string{"palette", "keys", "quit"}},
+}
+
+// actionLabel describes the action called name.
+func actionLabel(name string) string {
+	for _, pa := range paletteActions {
+		if pa.name == name {
+			return pa.label
+		}
+	}
+	if label, ok := actionLabels[name]; ok {
+		return label
+	}
+	return strings.ReplaceAll(name, "_", " ")
+}
+
+// renderKeys writes the cheat sheet of the current keys to v, grouped by
+// category, with the remapped keys of the config.
+func renderKeys(v *gocui.View) {
+	byName := make(map[string]action)
+	for _, a := range actions {
+		byName[a.name] = a
+	}
+	listed := make(map[string]bool)
+	section := func(title string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+		fmt.Fprintf(v, "\x1b[36;1m%s\x1b[0m\n", title)
+		for _, name := range names {
+			a, ok := byName[name]
+			if !ok {
+				continue
+			}
+			listed[name] = true
+			keys := fmt.Sprintf("%-14s", strings.Join(actionKeys(a), " / "))
+			if strings.TrimSpace(keys) == "" {
+				keys = fmt.Sprintf("\x1b[38;5;8m%-14s\x1b[0m", "unbound")
+			}
+			fmt.Fprintf(v, "  %s %s\n", keys, actionLabel(name))
+		}
+		fmt.Fprintln(v)
+	}
+	for _, c := range keyCategories {
+		section(c.title, c.actions)
+	}
+	var other []string
+	for _, a := range actions {
+		if !listed[a.name] {
+			other = append(other, a.name)
+		}
+	}
+	section("Other", other)
+	fmt.Fprintln(v, "\x1b[36;1mEverywhere\x1b[0m")
+	fmt.Fprintln(v, "  1-9            Show output tab 1 to 9")
+	fmt.Fprintln(v, "  esc            Close the open panel or prompt")
+	fmt.Fprintln(v, "  double-click   Run a target, open an entry or fold a section")
+	fmt.Fprintln(v, "\n\x1b[38;5;8mRemap keys with keybindings: in the config, e.g. run: [enter, r].\x1b[0m")
+}
+
+// toggleKeys opens or closes the cheat sheet of the keys in the middle of
+// the screen.
+func toggleKeys(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("keys"); err == nil {
+		return closeKeys(g, v)
+	}
+	x0, y0, x1, y1 := keysPosition(g)
+	kv, err := g.SetView("keys", x0, y0, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	kv.Title = "Keys (Up/Down to scroll, Esc or ? to close)"
+	kv.Clear()
+	renderKeys(kv)
+	if _, err := g.SetViewOnTop("keys"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("keys")
+	return err
+}
+
+// keysPosition returns where the cheat sheet goes: most of the screen.
+func keysPosition(g *gocui.Gui) (x0, y0, x1, y1 int) {
+	maxX, maxY := g.Size()
+	width, height := min(maxX-2, 90), maxY-4
+	x0, y0 = (maxX-width)/2, 1
+	return x0, y0, x0 + width, y0 + height
+}
+
+func closeKeys(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("keys"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
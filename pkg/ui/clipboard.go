@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// selection is the range of lines selected in the Command Output view,
+// from anchor, where it started, to cursor, which moves with the scroll
+// keys. Both are lines of the view's buffer.
+var selection struct {
+	active         bool
+	anchor, cursor int
+}
+
+// selectedRange returns the first and last selected lines.
+func selectedRange() (int, int) {
+	return min(selection.anchor, selection.cursor), max(selection.anchor, selection.cursor)
+}
+
+// startSelection selects the last line of text shown in the Command Output
+// view, to be extended with the scroll keys and copied with y. Colors are
+// not shown while selecting.
+func startSelection(g *gocui.Gui, v *gocui.View) error {
+	if v == nil || v.Name() != "command" {
+		return nil
+	}
+	if selection.active {
+		return endSelection(g, v)
+	}
+	lines := v.BufferLines()
+	if len(lines) == 0 {
+		return nil
+	}
+	_, height := v.Size()
+	_, oy := v.Origin()
+	last := min(oy+height, len(lines)) - 1
+	for last > 0 && lines[last] == "" {
+		last--
+	}
+	selection.active, selection.anchor, selection.cursor = true, last, last
+	v.Autoscroll = false
+	return renderSelection(v)
+}
+
+// moveSelection moves the end of the selection by delta lines, scrolling
+// to keep it in view.
+func moveSelection(v *gocui.View, delta int) error {
+	lines := len(v.BufferLines())
+	selection.cursor = max(0, min(selection.cursor+delta, lines-1))
+	_, height := v.Size()
+	ox, oy := v.Origin()
+	switch {
+	case selection.cursor < oy:
+		oy = selection.cursor
+	case selection.cursor >= oy+height:
+		oy = selection.cursor - height + 1
+	}
+	if err := v.SetOrigin(ox, oy); err != nil {
+		return err
+	}
+	return renderSelection(v)
+}
+
+// renderSelection redraws v as plain text with the selected lines in
+// reverse video.
+func renderSelection(v *gocui.View) error {
+	lines := v.BufferLines()
+	first, last := selectedRange()
+	for i := first; i <= last && i < len(lines); i++ {
+		lines[i] = "\x1b[7m" + lines[i] + "\x1b[0m"
+	}
+	v.Clear()
+	_, err := fmt.Fprint(v, strings.Join(lines, "\n"))
+	return err
+}
+
+// endSelection leaves selection mode, bringing back the colors of the
+// output.
+func endSelection(g *gocui.Gui, v *gocui.View) error {
+	if !selection.active {
+		return nil
+	}
+	selection.active = false
+	if activeTab >= 0 {
+		_, oy := outputView.Origin()
+		showTab(activeTab)
+		outputView.Autoscroll = false
+		return outputView.SetOrigin(0, oy)
+	}
+	lines := v.BufferLines()
+	v.Clear()
+	_, err := fmt.Fprint(v, strings.Join(lines, "\n"))
+	return err
+}
+
+// copyOutput copies the selected lines to the clipboard, or the whole
+// output of the focused pane when nothing is selected.
+func copyOutput(g *gocui.Gui, v *gocui.View) error {
+	var text string
+	switch {
+	case selection.active:
+		lines := outputView.BufferLines()
+		first, last := selectedRange()
+		text = strings.Join(lines[first:min(last+1, len(lines))], "\n") + "\n"
+		if err := endSelection(g, v); err != nil {
+			return err
+		}
+	case v != nil && v.Name() == "compare":
+		text = filterANSI(string(compare.output()), ANSIStrip)
+	case activeTab >= 0:
+		text = filterANSI(tabs[activeTab].buf.String(), ANSIStrip)
+	default:
+		return nil
+	}
+	via, err := copyToClipboard(text)
+	if err != nil {
+		return showWarning(g, fmt.Sprintf("copy: %v", err))
+	}
+	return showWarning(g, fmt.Sprintf("copied %d lines to the clipboard (%s)", strings.Count(text, "\n"), via))
+}
+
+// copyToClipboard puts text on the system clipboard with the first
+// clipboard helper found, or with an OSC 52 escape asking the terminal to
+// do it, which also works over ssh. It returns how it was copied.
+func copyToClipboard(text string) (string, error) {
+	var helpers [][]string
+	switch {
+	case os.Getenv("SSH_TTY") != "":
+		// The helpers would copy to the clipboard of the remote machine.
+	case runtime.GOOS == "darwin":
+		helpers = [][]string{{"pbcopy"}}
+	case runtime.GOOS == "windows":
+		helpers = [][]string{{"clip"}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			helpers = append(helpers, []string{"wl-copy"})
+		}
+		helpers = append(helpers, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+	}
+	for _, helper := range helpers {
+		if _, err := exec.LookPath(helper[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(helper[0], helper[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("%s: %w", helper[0], err)
+		}
+		return helper[0], nil
+	}
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	if _, err := os.Stdout.WriteString(seq); err != nil {
+		return "", err
+	}
+	return "OSC 52", nil
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jroimartin/gocui"
+)
+
+// compareSource is a run buffer the compare pane can show: the output of a
+// tab, or of the run before it.
+type compareSource struct {
+	tab      *outputTab
+	previous bool
+}
+
+// compare is what the compare pane shows, with a nil tab while the output
+// area is not split, and compareView the pane itself.
+var (
+	compare     compareSource
+	compareView *gocui.View
+)
+
+// compareSources lists every buffer the compare pane can show, each tab
+// followed by its previous run.
+func compareSources() []compareSource {
+	var sources []compareSource
+	for _, t := range tabs {
+		sources = append(sources, compareSource{tab: t})
+		if t.previous != nil {
+			sources = append(sources, compareSource{tab: t, previous: true})
+		}
+	}
+	return sources
+}
+
+func (s compareSource) title() string {
+	if s.previous {
+		return fmt.Sprintf("%s, previous run %s", s.tab.name, s.tab.previous.status)
+	}
+	return fmt.Sprintf("%s %s", s.tab.name, s.tab.status)
+}
+
+func (s compareSource) output() []byte {
+	if s.previous {
+		return s.tab.previous.output
+	}
+	return s.tab.buf.Bytes()
+}
+
+// layoutCompare splits the Command Output area, showing the compare pane
+// below the Command Output view. It is called by the manager so the split
+// follows terminal resizes.
+func layoutCompare(g *gocui.Gui) error {
+	if compare.tab == nil {
+		return nil
+	}
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	if y1-y0 < 6 {
+		return nil
+	}
+	middle := y0 + (y1-y0)/2
+	if _, err := g.SetView("command", x0, y0, x1, middle); err != nil {
+		return err
+	}
+	v, err := g.SetView("compare", x0, middle+1, x1, y1)
+	if errors.Is(err, gocui.ErrUnknownView) {
+		v.Wrap = outputView.Wrap
+		compareView = v
+		redrawCompare()
+	} else if err != nil {
+		return err
+	}
+	v.Title = fmt.Sprintf(" %s (Tab to switch, Esc to close) ", compare.title())
+	return nil
+}
+
+// toggleCompare splits the output area to show another run buffer below
+// the active tab, starting with the previous run of the active tab if it
+// has one, or closes the split.
+func toggleCompare(g *gocui.Gui, v *gocui.View) error {
+	if compare.tab != nil {
+		return closeCompare(g, v)
+	}
+	sources := compareSources()
+	if len(sources) < 2 {
+		return showWarning(g, "compare: needs two runs to compare")
+	}
+	active := tabs[activeTab]
+	compare = sources[0]
+	for _, s := range sources {
+		if s.tab == active && s.previous {
+			compare = s
+			break
+		}
+		if s.tab != active && compare.tab == active {
+			compare = s
+		}
+	}
+	if err := layoutCompare(g); err != nil {
+		return err
+	}
+	if compareView == nil {
+		compare = compareSource{}
+		return showWarning(g, "compare: the output area is too small to split")
+	}
+	_, err := g.SetCurrentView("compare")
+	return err
+}
+
+func closeCompare(g *gocui.Gui, v *gocui.View) error {
+	compare, compareView = compareSource{}, nil
+	if err := g.DeleteView("compare"); err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	if v != nil && v.Name() == "compare" {
+		_, err := g.SetCurrentView("Sidebar")
+		return err
+	}
+	return nil
+}
+
+// cycleCompare shows the next (delta 1) or previous (delta -1) run buffer
+// in the compare pane.
+func cycleCompare(g *gocui.Gui, delta int) error {
+	sources := compareSources()
+	i := 0
+	for j, s := range sources {
+		if s == compare {
+			i = j
+		}
+	}
+	compare = sources[(i+delta+len(sources))%len(sources)]
+	redrawCompare()
+	return nil
+}
+
+// redrawCompare shows the buffer of compare again after it changed.
+func redrawCompare() {
+	if compareView == nil {
+		return
+	}
+	compareView.Clear()
+	compareView.Autoscroll = true
+	compareView.Write(compare.output())
+}
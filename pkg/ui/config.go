@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the user configuration read from config.yaml.
+type Config struct {
+	// Vim selects the vim keybinding profile.
+	Vim bool `yaml:"vim"`
+	// Accessibility tells status apart by symbols and emphasis, not by red
+	// and green alone, and defaults to the high-contrast theme.
+	Accessibility bool `yaml:"accessibility"`
+	// Watch reloads the targets when the build files change. It is on
+	// unless set to false.
+	Watch *bool `yaml:"watch"`
+	// DotEnv loads .env and .env.local from the directory commands run in
+	// into their environment. It is on unless set to false.
+	DotEnv *bool `yaml:"dotenv"`
+	// Log tees the output of each run into .imake/logs/.
+	Log bool `yaml:"log"`
+	// Timestamps prefixes output lines with the time since the command
+	// started and shows how long it took.
+	Timestamps bool `yaml:"timestamps"`
+	// NotifyAfter is how long a run must take for its completion to be
+	// notified on the desktop. It defaults to 30s; 0 turns it off.
+	NotifyAfter *time.Duration `yaml:"notify_after"`
+	// PTY runs commands under a pseudo-terminal so they keep their colors
+	// and progress bars. It is on unless set to false, which runs them
+	// with pipes and shows stderr in red.
+	PTY *bool `yaml:"pty"`
+	// MakeFlags are passed to make before the target, e.g. [-j8].
+	MakeFlags []string `yaml:"make_flags"`
+	// MakeDatabase lists the targets of make's database (make -qp), which
+	// include rules defined by $(eval ...) and computed names, documented
+	// from the Makefile.
+	MakeDatabase bool `yaml:"make_database"`
+	// BazelScope limits the Bazel rules listed to those matching target
+	// patterns such as //services/..., since querying a whole monorepo is
+	// slow.
+	BazelScope []string `yaml:"bazel_scope"`
+	// OutputLines is how many lines of output each tab keeps. It defaults
+	// to 10000; 0 keeps every line.
+	OutputLines *int `yaml:"output_lines"`
+	// BeforeRun and AfterRun are shell commands run before and after each
+	// target, in its tab. A failing BeforeRun stops the run; AfterRun finds
+	// the exit code of the target in $IMAKE_EXIT_CODE.
+	BeforeRun string `yaml:"before_run"`
+	AfterRun  string `yaml:"after_run"`
+	// Detach is how b runs targets detached from imake: "tmux" in a tmux
+	// session, or "background" as a process writing to .imake/logs. It
+	// defaults to tmux when imake runs inside tmux.
+	Detach string `yaml:"detach"`
+	// KeepGoing runs the rest of a queue after one of its targets failed.
+	KeepGoing bool `yaml:"keep_going"`
+	// Hosts maps names to remote hosts, "user@host:/dir", that commands
+	// can be run on over ssh.
+	Hosts map[string]string `yaml:"hosts"`
+	// Highlights color the output lines matching a pattern, before the
+	// built-in rules for errors, warnings and passes.
+	Highlights []HighlightRule `yaml:"highlights"`
+	// Theme holds the colors, or names a built-in theme.
+	Theme Theme `yaml:"theme"`
+	// Layout holds the pane sizes.
+	Layout LayoutConfig `yaml:"layout"`
+	// Keybindings maps action names to the keys triggering them.
+	Keybindings map[string]KeyList `yaml:"keybindings"`
+}
+
+// KeyList is one or more key names. In YAML it may be written as a single
+// string or as a list.
+type KeyList []string
+
+func (k *KeyList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*k = KeyList{node.Value}
+		return nil
+	}
+	var keys []string
+	if err := node.Decode(&keys); err != nil {
+		return err
+	}
+	*k = keys
+	return nil
+}
+
+// config is the loaded configuration; configErrors collects problems found
+// while loading it so they can be shown once the UI is up.
+var (
+	config       Config
+	configErrors []string
+)
+
+// configPath returns the location of config.yaml, following the XDG base
+// directory spec.
+func configPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "imake", "config.yaml"), nil
+}
+
+// loadConfig reads the configuration file. A missing file is not an error.
+func loadConfig() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
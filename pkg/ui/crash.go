@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// maxRecentLogs is how many lines of imake's own log are kept for the
+// crash log.
+const maxRecentLogs = 200
+
+// recentLogs holds the last lines imake logged while the UI was up, which
+// would otherwise be drawn over.
+var recentLogs struct {
+	sync.Mutex
+	lines []string
+}
+
+// logRecorder is the output of the log package while the UI is up.
+type logRecorder struct{}
+
+func (logRecorder) Write(p []byte) (int, error) {
+	recentLogs.Lock()
+	defer recentLogs.Unlock()
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		recentLogs.lines = append(recentLogs.lines, line)
+	}
+	if n := len(recentLogs.lines); n > maxRecentLogs {
+		recentLogs.lines = recentLogs.lines[n-maxRecentLogs:]
+	}
+	return len(p), nil
+}
+
+// recordLogs keeps what imake logs from now on out of the terminal. The
+// returned func writes the lines logged meanwhile to w, the former output
+// of the log package, once the UI is closed.
+func recordLogs() func() {
+	w := log.Writer()
+	log.SetOutput(logRecorder{})
+	return func() {
+		log.SetOutput(w)
+		recentLogs.Lock()
+		defer recentLogs.Unlock()
+		for _, line := range recentLogs.lines {
+			fmt.Fprintln(w, line)
+		}
+		recentLogs.lines = nil
+	}
+}
+
+// CrashError is returned by Run when the UI panicked. The terminal is
+// given back and the commands still running are stopped by then.
+type CrashError struct {
+	Value any    // the value the UI panicked with
+	Stack []byte // the stack trace of the panic
+	// Log is the crash log the panic, its stack trace and the recent logs
+	// were written to, or "" if LogErr tells why it could not be.
+	Log    string
+	LogErr error
+}
+
+func (e *CrashError) Error() string {
+	return fmt.Sprintf("imake crashed: %v", e.Value)
+}
+
+// crashGui is the GUI whose main loop a crash in another goroutine ends.
+var crashGui *gocui.Gui
+
+// crashOnce reports a single crash when several goroutines panic, and
+// crashErr is that report.
+var (
+	crashOnce sync.Once
+	crashErr  *CrashError
+)
+
+// recoverCrash is deferred by the goroutines of the UI. On a panic, it
+// reports the crash and ends the main loop, making Run return the
+// *CrashError.
+func recoverCrash() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err := crash(r, debug.Stack())
+	if crashGui != nil {
+		crashGui.Update(func(*gocui.Gui) error { return err })
+	}
+}
+
+// crash stops the commands still running and writes the panic r, raised
+// at stack, to the crash log, the first time it is called, and returns the
+// *CrashError Run returns.
+func crash(r any, stack []byte) *CrashError {
+	crashOnce.Do(func() {
+		runs.CancelAll()
+		crashErr = &CrashError{Value: r, Stack: stack}
+		crashErr.Log, crashErr.LogErr = writeCrashLog(r, stack)
+	})
+	return crashErr
+}
+
+// crashLogPath returns the location of crash.log, following the XDG base
+// directory spec.
+func crashLogPath() (string, error) {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(dir, "imake", "crash.log"), nil
+}
+
+// writeCrashLog replaces the crash log with the report of the panic r,
+// raised at stack, and returns its path.
+func writeCrashLog(r any, stack []byte) (string, error) {
+	path, err := crashLogPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	dir, _ := os.Getwd()
+	fmt.Fprintf(f, "imake crashed at %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "%s %s/%s, in %s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH, dir)
+	if backend != nil {
+		fmt.Fprintf(f, "runner %s, build file %s\n", backend.Name(), backend.File())
+	}
+	fmt.Fprintf(f, "\npanic: %v\n\n%s", r, stack)
+
+	recentLogs.Lock()
+	if len(recentLogs.lines) > 0 {
+		fmt.Fprintf(f, "\nrecent logs:\n%s\n", strings.Join(recentLogs.lines, "\n"))
+	}
+	recentLogs.Unlock()
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/jroimartin/gocui"
+)
+
+// diagnostics are the problems of the build files found by the last check,
+// such as lines the parser left out. They are only touched from the gocui
+// main loop.
+var diagnostics []makefile.Diagnostic
+
+// checkBuildFiles looks for problems in the build files of the runner in
+// the background, as make reading them may take a while.
+func checkBuildFiles(g *gocui.Gui) {
+	checker, ok := backend.(runner.Checker)
+	if !ok {
+		diagnostics = nil
+		return
+	}
+	checked := backend
+	go func() {
+		defer recoverCrash()
+		found, err := checker.Check()
+		g.Update(func(g *gocui.Gui) error {
+			if backend != checked {
+				// Another project was opened meanwhile.
+				return nil
+			}
+			if err != nil {
+				// Discover reports the build file it cannot read.
+				found = nil
+			}
+			diagnostics = found
+			return nil
+		})
+	}()
+}
+
+// formatDiagnostic describes d on one line of the diagnostics panel.
+func formatDiagnostic(d makefile.Diagnostic) string {
+	return fmt.Sprintf("\x1b[36m%s:%d\x1b[0m  %s", d.File, d.Line, d.Message)
+}
+
+// toggleDiagnostics opens or closes the panel listing the problems of the
+// build files on top of the Command Output view.
+func toggleDiagnostics(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("diagnostics"); err == nil {
+		return closeDiagnostics(g, v)
+	}
+	if _, ok := backend.(runner.Checker); !ok {
+		return showWarning(g, fmt.Sprintf("diagnostics: %s build files are not checked", backend.Name()))
+	}
+	if len(diagnostics) == 0 {
+		return showWarning(g, fmt.Sprintf("diagnostics: no problems found in %s", backend.File()))
+	}
+
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	dv, err := g.SetView("diagnostics", x0, y0, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	dv.Title = fmt.Sprintf("Build file problems of %s (Enter to edit, Esc to close)", backend.File())
+	dv.Highlight = true
+	dv.Clear()
+	for _, d := range diagnostics {
+		fmt.Fprintln(dv, formatDiagnostic(d))
+	}
+	if _, err := g.SetViewOnTop("diagnostics"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("diagnostics")
+	return err
+}
+
+func closeDiagnostics(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("diagnostics"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+// editDiagnostic opens the build file at the selected problem in the
+// editor, then reloads the targets, which checks the files again.
+func editDiagnostic(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(diagnostics) {
+		return nil
+	}
+	d := diagnostics[cy+oy]
+	if err := closeDiagnostics(g, v); err != nil {
+		return err
+	}
+	if err := runEditor(g, d.File, d.Line); err != nil {
+		return err
+	}
+	return reloadTargets(g)
+}
@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+const (
+	// diffContext is how many unchanged lines surround each change.
+	diffContext = 3
+	// maxDiffEdits bounds the work of diffLines; outputs differing more
+	// are not worth a line by line diff.
+	maxDiffEdits = 5000
+)
+
+// diffOp is a line of a diff: ' ' if it is in both outputs, '-' if only in
+// the first one, '+' if only in the second one.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns the shortest edit turning a into b, with Myers'
+// algorithm, or false if it takes more than maxDiffEdits lines.
+func diffLines(a, b []string) ([]diffOp, bool) {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		if d > maxDiffEdits {
+			return nil, false
+		}
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(a, b, trace, offset), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrackDiff walks the trace of diffLines back from the end of both
+// outputs to list the edit.
+func backtrackDiff(a, b []string, trace [][]int, offset int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{'+', b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{'-', a[x]})
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// unifiedDiff formats ops as the hunks of a unified diff, colored, with
+// diffContext lines of context.
+func unifiedDiff(ops []diffOp) []string {
+	var out []string
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// A hunk spans the changes closer than twice the context.
+		start, end := max(0, i-diffContext), i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end = min(len(ops), end+diffContext)
+
+		oldLine, newLine := 1, 1
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		var lines []string
+		for _, op := range ops[start:end] {
+			switch op.kind {
+			case '-':
+				oldCount++
+				lines = append(lines, "\x1b[31m-"+op.line+"\x1b[0m")
+			case '+':
+				newCount++
+				lines = append(lines, "\x1b[32m+"+op.line+"\x1b[0m")
+			default:
+				oldCount, newCount = oldCount+1, newCount+1
+				lines = append(lines, " "+op.line)
+			}
+		}
+		out = append(out, fmt.Sprintf("\x1b[36m@@ -%d,%d +%d,%d @@\x1b[0m", oldLine, oldCount, newLine, newCount))
+		out = append(out, lines...)
+		i = end
+	}
+	return out
+}
+
+// diffableLines splits output into lines without escapes or timestamps,
+// which differ between any two runs.
+func diffableLines(output []byte) []string {
+	lines := strings.Split(strings.TrimSuffix(filterANSI(string(output), ANSIStrip), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = timestampRegexp.ReplaceAllString(line, "")
+	}
+	return lines
+}
+
+// diffPrevious shows how the output of the active tab changed since the
+// previous run of its target, as a unified diff in a tab of its own.
+func diffPrevious(g *gocui.Gui, v *gocui.View) error {
+	if activeTab < 0 {
+		return showWarning(g, "diff: no run to compare")
+	}
+	t := tabs[activeTab]
+	if source, ok := strings.CutSuffix(t.name, " (diff)"); ok {
+		// Diff the run shown in the diff tab again.
+		for _, other := range tabs {
+			if other.name == source {
+				t = other
+			}
+		}
+	}
+	if t.previous == nil {
+		return showWarning(g, fmt.Sprintf("diff: %s has no previous run", t.name))
+	}
+	if t.status == tabRunning {
+		return showWarning(g, fmt.Sprintf("diff: %s is still running", t.name))
+	}
+	ops, ok := diffLines(diffableLines(t.previous.output), diffableLines(t.buf.Bytes()))
+	if !ok {
+		return showWarning(g, fmt.Sprintf("diff: the runs of %s differ in more than %d lines", t.name, maxDiffEdits))
+	}
+
+	if err := closeRunPanes(g); err != nil {
+		return err
+	}
+	name, previous, current := t.name, t.previous.status, t.status
+	tab := openTab(name + " (diff)")
+	tab.reset()
+	fmt.Fprintf(tab, "\x1b[31m--- %s, previous run %s\x1b[0m\n", name, previous)
+	fmt.Fprintf(tab, "\x1b[32m+++ %s, last run %s\x1b[0m\n", name, current)
+	hunks := unifiedDiff(ops)
+	if len(hunks) == 0 {
+		fmt.Fprintln(tab, "\x1b[38;5;8mthe output did not change\x1b[0m")
+	}
+	for _, line := range hunks {
+		fmt.Fprintln(tab, line)
+	}
+	outputView.Title = tabStrip()
+	return nil
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// dotenvFiles are the env files loaded from the directory commands run
+// in, later ones overriding earlier ones.
+var dotenvFiles = []string{".env", ".env.local"}
+
+// dotenv loads dotenvFiles into the environment of the commands. It
+// starts out as set in the config and is toggled with the dotenv action.
+var dotenv bool
+
+// parseDotenv reads the variables of an env file: NAME=value lines,
+// optionally starting with export, with values quoted or not. Comments
+// start with #. Double-quoted values understand \n, \t, \" and \\.
+func parseDotenv(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var env []string
+	lineNo := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("%s:%d: expected NAME=value", path, lineNo)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, `"`):
+			end := strings.LastIndex(value, `"`)
+			unquoted, err := strconv.Unquote(value[:max(end+1, 1)])
+			if end == 0 || err != nil {
+				return nil, fmt.Errorf("%s:%d: unterminated or invalid quoted value", path, lineNo)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, "'"):
+			end := strings.LastIndex(value, "'")
+			if end == 0 {
+				return nil, fmt.Errorf("%s:%d: unterminated quoted value", path, lineNo)
+			}
+			value = value[1:end]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		env = append(env, name+"="+value)
+	}
+	return env, scanner.Err()
+}
+
+// loadedDotenvFiles returns the dotenvFiles present in the working
+// directory, or none while dotenv is off.
+func loadedDotenvFiles() []string {
+	if !dotenv {
+		return nil
+	}
+	var files []string
+	for _, name := range dotenvFiles {
+		if fileExists(name) {
+			files = append(files, name)
+		}
+	}
+	return files
+}
+
+// applyDotenv adds the variables of the env files to the environment of
+// cmd. Variables set in imake's environment or by cmd itself win, as with
+// other dotenv tools.
+func applyDotenv(cmd *exec.Cmd) error {
+	files := loadedDotenvFiles()
+	if len(files) == 0 {
+		return nil
+	}
+	values := make(map[string]string)
+	var names []string
+	for _, path := range files {
+		env, err := parseDotenv(path)
+		if err != nil {
+			return err
+		}
+		for _, kv := range env {
+			name, value, _ := strings.Cut(kv, "=")
+			if _, ok := values[name]; !ok {
+				names = append(names, name)
+			}
+			values[name] = value
+		}
+	}
+
+	base := cmd.Env
+	if base == nil {
+		base = os.Environ()
+	}
+	set := make(map[string]bool)
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		set[name] = true
+	}
+	env := append([]string(nil), base...)
+	for _, name := range names {
+		if !set[name] {
+			env = append(env, name+"="+values[name])
+		}
+	}
+	cmd.Env = env
+	return nil
+}
+
+// toggleDotenv turns loading the env files on or off.
+func toggleDotenv(g *gocui.Gui, v *gocui.View) error {
+	dotenv = !dotenv
+	if !dotenv {
+		return showNotice("env files off")
+	}
+	if files := loadedDotenvFiles(); len(files) > 0 {
+		return showNotice("env files on, loading " + strings.Join(files, ", "))
+	}
+	return showNotice("env files on, but there is no " + strings.Join(dotenvFiles, " or "))
+}
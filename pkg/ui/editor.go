@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+	"github.com/jroimartin/gocui"
+	"github.com/nsf/termbox-go"
+)
+
+// editorCommand returns the editor configured in $VISUAL or $EDITOR, split
+// into words so that values like "code -w" work, or vi.
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if words := strings.Fields(os.Getenv(name)); len(words) > 0 {
+			return words
+		}
+	}
+	return []string{"vi"}
+}
+
+// editTarget suspends the UI and opens the build file at the definition of
+// the selected target in the editor, resuming once the editor exits.
+func editTarget(g *gocui.Gui, v *gocui.View) error {
+	t, ok := makefile.Find(targets, selectedTarget(v))
+	if !ok {
+		return nil
+	}
+	if err := runEditor(g, t.File, t.Line); err != nil {
+		return err
+	}
+	return reloadTargets(g)
+}
+
+// runEditor suspends the UI while the editor edits file at line, which is
+// left out if it is 0. The editor failing is reported as a warning.
+func runEditor(g *gocui.Gui, file string, line int) error {
+	args := editorCommand()
+	if line > 0 {
+		args = append(args, fmt.Sprintf("+%d", line))
+	}
+	args = append(args, file)
+	runErr, err := runInTerminal(exec.Command(args[0], args[1:]...))
+	if err != nil {
+		return err
+	}
+	if runErr != nil {
+		return showWarning(g, fmt.Sprintf("editor: %v", runErr))
+	}
+	return nil
+}
+
+// runInTerminal runs cmd in the terminal in place of the UI until it
+// exits, reading the terminal unless it has an input of its own. runErr is
+// the error of cmd and err that of taking the terminal back.
+func runInTerminal(cmd *exec.Cmd) (runErr, err error) {
+	if cmd.Stdin == nil {
+		cmd.Stdin = os.Stdin
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// gocui has no way to suspend, so hand the terminal over by closing
+	// termbox and take it back by initializing it again. The main loop
+	// keeps polling for events, which arrive again once it is back.
+	termbox.Close()
+	runErr = cmd.Run()
+	if err := termbox.Init(); err != nil {
+		return runErr, err
+	}
+	termbox.SetInputMode(termbox.InputEsc | termbox.InputMouse)
+	termbox.SetOutputMode(termbox.Output256)
+	return runErr, nil
+}
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+	"github.com/jroimartin/gocui"
+)
+
+// exportHelp shows the documentation of the targets in a tab of its own,
+// to be copied or written to a file with dump_output: as a help rule to
+// paste into a Makefile, or as text for other build tools.
+func exportHelp(g *gocui.Gui, v *gocui.View) error {
+	if err := closeRunPanes(g); err != nil {
+		return err
+	}
+	tab := openTab("export-help")
+	tab.reset()
+	format := makefile.HelpText
+	if backend.Name() == "make" {
+		format = makefile.HelpRecipe
+		fmt.Fprintf(tab, "\x1b[38;5;8m# paste into %s to keep `make help` in sync\x1b[0m\n", backend.File())
+	}
+	if err := makefile.WriteHelp(tab, targets, format); err != nil {
+		return showWarning(g, fmt.Sprintf("export-help: %v", err))
+	}
+	outputView.Title = tabStrip()
+	return nil
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/jroimartin/gocui"
+	"gopkg.in/yaml.v3"
+)
+
+// projectConfigFile holds the settings of one project, next to its build
+// file in the directory commands run in.
+const projectConfigFile = ".imake.yaml"
+
+// ProjectConfig is the per-project configuration read from .imake.yaml.
+type ProjectConfig struct {
+	// Favorites are the pinned targets listed at the top of the Sidebar.
+	Favorites []string `yaml:"favorites,omitempty"`
+	// Presets maps targets to argument lists offered when running them,
+	// e.g. deploy: ["ENV=staging", "ENV=prod"].
+	Presets map[string][]string `yaml:"presets,omitempty"`
+	// Hooks maps targets to commands run before and after them, after
+	// the before_run and after_run hooks of the config.
+	Hooks map[string]Hooks `yaml:"hooks,omitempty"`
+	// Sudo lists the targets run under sudo, such as install-system.
+	Sudo []string `yaml:"sudo,omitempty"`
+	// Ignore lists globs of targets the Sidebar hides like internal ones,
+	// e.g. "ci-only-*".
+	Ignore []string `yaml:"ignore,omitempty"`
+	// Macros maps F1-F12, as "f1" to "f12", to the steps of the macro
+	// they replay, recorded with Q.
+	Macros map[string][]MacroStep `yaml:"macros,omitempty"`
+}
+
+// project is the configuration of the current project.
+var project ProjectConfig
+
+// loadProjectConfig reads .imake.yaml from the current directory. A
+// missing file is not an error.
+func loadProjectConfig() error {
+	project = ProjectConfig{}
+	data, err := os.ReadFile(projectConfigFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return fmt.Errorf("%s: %w", projectConfigFile, err)
+	}
+	for _, pattern := range project.Ignore {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s: ignore: %q: %w", projectConfigFile, pattern, err)
+		}
+	}
+	return nil
+}
+
+// isIgnored reports whether name matches an ignore glob of the project.
+func isIgnored(name string) bool {
+	for _, pattern := range project.Ignore {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func saveProjectConfig() error {
+	data, err := yaml.Marshal(project)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(projectConfigFile, data, 0o644)
+}
+
+func isFavorite(name string) bool {
+	for _, f := range project.Favorites {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleFavorite pins or unpins the selected target and saves the
+// favorites of the project.
+func toggleFavorite(g *gocui.Gui, v *gocui.View) error {
+	name := selectedTarget(v)
+	if name == "" {
+		return nil
+	}
+	if isFavorite(name) {
+		favorites := project.Favorites[:0]
+		for _, f := range project.Favorites {
+			if f != name {
+				favorites = append(favorites, f)
+			}
+		}
+		project.Favorites = favorites
+	} else {
+		project.Favorites = append(project.Favorites, name)
+	}
+	if err := saveProjectConfig(); err != nil {
+		if err := showWarning(g, fmt.Sprintf("favorites: %v", err)); err != nil {
+			return err
+		}
+	}
+	setTargets(targets)
+	return refreshSidebar(g)
+}
@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jroimartin/gocui"
+)
+
+// fuzzyScore reports whether every rune of pattern appears in s in order,
+// and scores the match the way fzf does: consecutive runs and matches at
+// the start of a word are rewarded, gaps are penalised.
+func fuzzyScore(pattern, s string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	p := []rune(strings.ToLower(pattern))
+	text := []rune(s)
+
+	score, pi, last := 0, 0, -1
+	for i, r := range text {
+		if pi == len(p) {
+			break
+		}
+		if unicode.ToLower(r) != p[pi] {
+			continue
+		}
+		score += 16
+		switch {
+		case i == 0:
+			score += 24
+		case !unicode.IsLetter(text[i-1]) && !unicode.IsDigit(text[i-1]):
+			score += 16
+		case unicode.IsUpper(r) && unicode.IsLower(text[i-1]):
+			score += 12
+		}
+		if last >= 0 {
+			if i == last+1 {
+				score += 16
+			} else {
+				score -= i - last - 1
+			}
+		}
+		last = i
+		pi++
+	}
+	if pi < len(p) {
+		return 0, false
+	}
+	// Prefer shorter candidates among otherwise equal matches.
+	return score - len(text)/4, true
+}
+
+// fuzzyFilter returns the names matching pattern, best match first.
+func fuzzyFilter(pattern string, names []string) []string {
+	type match struct {
+		name  string
+		score int
+	}
+	var matches []match
+	for _, name := range names {
+		if score, ok := fuzzyScore(pattern, name); ok {
+			matches = append(matches, match{name, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	filtered := make([]string, len(matches))
+	for i, m := range matches {
+		filtered[i] = m.name
+	}
+	return filtered
+}
+
+// filterEditor narrows the Sidebar on every keystroke in the filter view.
+type filterEditor struct {
+	g *gocui.Gui
+}
+
+func (e *filterEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	gocui.DefaultEditor.Edit(v, key, ch, mod)
+	sidebar, err := e.g.View("Sidebar")
+	if err != nil {
+		return
+	}
+	renderSidebar(sidebar, fuzzyFilter(strings.TrimSpace(v.Buffer()), targetNames), false)
+}
+
+func openFilter(g *gocui.Gui, v *gocui.View) error {
+	x0, _, x1, y1, err := g.ViewPosition("Sidebar")
+	if err != nil {
+		return err
+	}
+	filter, err := g.SetView("filter", x0, y1-2, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	filter.Title = "Filter"
+	filter.Editable = true
+	filter.Editor = &filterEditor{g: g}
+	if _, err := g.SetViewOnTop("filter"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("filter")
+	return err
+}
+
+func filterCursorDown(g *gocui.Gui, v *gocui.View) error {
+	sidebar, err := g.View("Sidebar")
+	if err != nil {
+		return err
+	}
+	return cursorDown(g, sidebar)
+}
+
+func filterCursorUp(g *gocui.Gui, v *gocui.View) error {
+	sidebar, err := g.View("Sidebar")
+	if err != nil {
+		return err
+	}
+	return cursorUp(g, sidebar)
+}
+
+// acceptFilter closes the filter and selects the highlighted match in the
+// full target list.
+func acceptFilter(g *gocui.Gui, v *gocui.View) error {
+	sidebar, err := g.View("Sidebar")
+	if err != nil {
+		return err
+	}
+	selected := selectedTarget(sidebar)
+	if err := clearFilter(g, v); err != nil {
+		return err
+	}
+	return selectTarget(sidebar, selected)
+}
+
+// clearFilter closes the filter and restores the full target list.
+func clearFilter(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("filter"); err != nil {
+		return err
+	}
+	sidebar, err := g.SetCurrentView("Sidebar")
+	if err != nil {
+		return err
+	}
+	return renderSidebar(sidebar, targetNames, true)
+}
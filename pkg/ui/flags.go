@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/jroimartin/gocui"
+)
+
+// makeFlags are passed to make before the target for the rest of the
+// session, e.g. -j8 or -k. They start as make_flags from the config.
+var makeFlags []string
+
+// applyMakeFlags hands makeFlags to the backend if it takes flags, and
+// the Bazel scope of the config if it can be scoped.
+func applyMakeFlags() {
+	if f, ok := backend.(runner.FlagSetter); ok {
+		f.SetFlags(makeFlags)
+	}
+	if s, ok := backend.(runner.Scoper); ok {
+		s.SetScope(config.BazelScope)
+	}
+	if d, ok := backend.(runner.DatabaseReader); ok && config.MakeDatabase {
+		d.UseDatabase(true)
+	}
+}
+
+// openFlagsPrompt opens a prompt to change the make flags of the session.
+func openFlagsPrompt(g *gocui.Gui, v *gocui.View) error {
+	if _, ok := backend.(runner.FlagSetter); !ok {
+		return showWarning(g, fmt.Sprintf("%s does not take flags", backend.Name()))
+	}
+
+	maxX, maxY := g.Size()
+	prompt, err := g.SetView("flags", maxX/6, maxY/2-1, maxX*5/6, maxY/2+1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	prompt.Title = "make flags, e.g. -j8 -k (Enter to apply, Esc to cancel)"
+	prompt.Editable = true
+	prompt.Editor = gocui.DefaultEditor
+	prompt.Clear()
+	flags := strings.Join(makeFlags, " ")
+	fmt.Fprint(prompt, flags)
+	if width, _ := prompt.Size(); len(flags) < width {
+		if err := prompt.SetCursor(len(flags), 0); err != nil {
+			return err
+		}
+	}
+	if _, err := g.SetViewOnTop("flags"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("flags")
+	return err
+}
+
+// applyFlagsPrompt uses the flags typed in the prompt for the next runs.
+func applyFlagsPrompt(g *gocui.Gui, v *gocui.View) error {
+	makeFlags = strings.Fields(v.Buffer())
+	applyMakeFlags()
+	if err := closeFlagsPrompt(g, v); err != nil {
+		return err
+	}
+	if len(makeFlags) == 0 {
+		return showWarning(g, "make flags cleared")
+	}
+	return showWarning(g, "make flags: "+strings.Join(makeFlags, " "))
+}
+
+func closeFlagsPrompt(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("flags"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
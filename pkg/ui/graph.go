@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+	"github.com/jroimartin/gocui"
+)
+
+// writeDependencyTree prints the prerequisites of name as a tree. Targets
+// that are not rules (plain files) are dimmed and cycles are cut short.
+func writeDependencyTree(w io.Writer, name, prefix string, seen map[string]bool) {
+	t, ok := makefile.Find(targets, name)
+	if !ok {
+		return
+	}
+	for i, dep := range t.Deps {
+		branch, indent := "├── ", "│   "
+		if i == len(t.Deps)-1 {
+			branch, indent = "└── ", "    "
+		}
+		_, isRule := makefile.Find(targets, dep)
+		switch {
+		case seen[dep]:
+			fmt.Fprintf(w, "%s%s%s \x1b[33m(cycle)\x1b[0m\n", prefix, branch, dep)
+		case !isRule:
+			fmt.Fprintf(w, "%s%s\x1b[38;5;8m%s\x1b[0m\n", prefix, branch, dep)
+		default:
+			fmt.Fprintf(w, "%s%s%s\n", prefix, branch, dep)
+			seen[dep] = true
+			writeDependencyTree(w, dep, prefix+indent, seen)
+			delete(seen, dep)
+		}
+	}
+}
+
+// dependents returns the targets listing name as a prerequisite.
+func dependents(name string) []string {
+	var names []string
+	for _, t := range targets {
+		for _, dep := range t.Deps {
+			if dep == name {
+				names = append(names, t.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// toggleGraph opens or closes an overlay showing what the selected target
+// depends on and which targets depend on it.
+func toggleGraph(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("graph"); err == nil {
+		return closeGraph(g, v)
+	}
+	name := selectedTarget(v)
+	if name == "" {
+		return nil
+	}
+
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	gv, err := g.SetView("graph", x0, y0, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	gv.Title = "Dependencies of " + name + " (Esc to close)"
+	gv.Clear()
+
+	fmt.Fprintln(gv, name)
+	writeDependencyTree(gv, name, "", map[string]bool{name: true})
+	fmt.Fprintln(gv)
+	fmt.Fprintln(gv, "Required by:")
+	users := dependents(name)
+	if len(users) == 0 {
+		fmt.Fprintln(gv, "  (none)")
+	}
+	for _, user := range users {
+		fmt.Fprintf(gv, "  %s\n", user)
+	}
+
+	if _, err := g.SetViewOnTop("graph"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("graph")
+	return err
+}
+
+func closeGraph(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("graph"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+func graphScrollDown(g *gocui.Gui, v *gocui.View) error {
+	ox, oy := v.Origin()
+	if oy+1 < len(v.BufferLines()) {
+		return v.SetOrigin(ox, oy+1)
+	}
+	return nil
+}
+
+func graphScrollUp(g *gocui.Gui, v *gocui.View) error {
+	ox, oy := v.Origin()
+	if oy > 0 {
+		return v.SetOrigin(ox, oy-1)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// HighlightRule colors the lines of command output matching Pattern, a
+// regular expression, in Color: a color name or palette number like in
+// the theme, or "none" to leave them as they are. Bold and Underline
+// emphasize them, whatever their color.
+type HighlightRule struct {
+	Pattern   string `yaml:"pattern"`
+	Color     string `yaml:"color"`
+	Bold      bool   `yaml:"bold"`
+	Underline bool   `yaml:"underline"`
+}
+
+// defaultHighlights make failures stand out in long logs. They apply after
+// the rules of the config.
+var defaultHighlights = []HighlightRule{
+	{Pattern: `\b(error|Error|ERROR|FAIL|panic)\b`, Color: "red"},
+	{Pattern: `(?i)\bwarning\b`, Color: "yellow"},
+	{Pattern: `\b(PASS|ok)\b`, Color: "green"},
+}
+
+// accessibleHighlights replace defaultHighlights with accessibility on:
+// failures are orange and underlined, passes blue.
+var accessibleHighlights = []HighlightRule{
+	{Pattern: `\b(error|Error|ERROR|FAIL|panic)\b`, Color: "208", Bold: true, Underline: true},
+	{Pattern: `(?i)\bwarning\b`, Color: "yellow", Bold: true},
+	{Pattern: `\b(PASS|ok)\b`, Color: "33"},
+}
+
+// highlight is a compiled HighlightRule, with escape empty for "none".
+type highlight struct {
+	re     *regexp.Regexp
+	escape string
+}
+
+// highlights are the rules of the config followed by the default ones.
+// They are set on startup and only read afterwards.
+var highlights []highlight
+
+// loadHighlights compiles the highlight rules of the config and the
+// default ones, reporting and skipping the invalid ones.
+func loadHighlights() []string {
+	var problems []string
+	highlights = nil
+	defaults := defaultHighlights
+	if config.Accessibility {
+		defaults = accessibleHighlights
+	}
+	for i, rule := range append(append([]HighlightRule(nil), config.Highlights...), defaults...) {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("highlights[%d]: %v", i, err))
+			continue
+		}
+		h := highlight{re: re}
+		var params []string
+		if color := strings.ToLower(rule.Color); color != "none" {
+			c, err := parseColor(rule.Color)
+			if err != nil || c == gocui.ColorDefault {
+				problems = append(problems, fmt.Sprintf("highlights[%d]: unknown color %q", i, rule.Color))
+				continue
+			}
+			params = append(params, fmt.Sprintf("38;5;%d", c-1))
+		}
+		if rule.Bold {
+			params = append(params, "1")
+		}
+		if rule.Underline {
+			params = append(params, "4")
+		}
+		if len(params) > 0 {
+			h.escape = "\x1b[" + strings.Join(params, ";") + "m"
+		}
+		highlights = append(highlights, h)
+	}
+	return problems
+}
+
+// highlightColor returns the escape coloring line according to the first
+// highlight rule it matches, or "" to leave it alone. Lines the command
+// colored itself are left alone.
+func highlightColor(line string) string {
+	if strings.Contains(line, "\x1b[") {
+		return ""
+	}
+	for _, h := range highlights {
+		if h.re.MatchString(line) {
+			return h.escape
+		}
+	}
+	return ""
+}
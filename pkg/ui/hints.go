@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// messageTimeout is how long a message stays in the footer.
+const messageTimeout = 6 * time.Second
+
+// message is the confirmation or error shown in the hints pane, or in the
+// status bar while the pane is collapsed, until it expires. It is only
+// touched from the gocui main loop.
+var message struct {
+	text    string
+	failed  bool
+	expires time.Time
+}
+
+// setMessage shows text in the footer for messageTimeout; an empty text
+// clears it.
+func setMessage(text string, failed bool) {
+	message.text = text
+	message.failed = failed
+	message.expires = time.Now().Add(messageTimeout)
+}
+
+// showNotice shows text in the hints pane for a few seconds, e.g. the
+// targets added and removed by the last reload that changed them.
+func showNotice(text string) error {
+	setMessage(text, false)
+	return nil
+}
+
+// currentMessage returns the message, colored, or "" once it expired.
+func currentMessage() string {
+	if message.text == "" || time.Now().After(message.expires) {
+		return ""
+	}
+	if message.failed {
+		return failureText(message.text)
+	}
+	return message.text
+}
+
+// focusHints returns the keys of the actions of the focused view, such as
+// "e edit", for the hints pane. Moving with the arrow keys goes without
+// saying.
+func focusHints(g *gocui.Gui) []string {
+	current := g.CurrentView()
+	if current == nil {
+		return nil
+	}
+	hints := []string{"\x1b[36m?\x1b[0m all keys"}
+	for _, a := range actions {
+		keys := actionKeys(a)
+		if a.view != current.Name() || len(keys) == 0 || keys[0] == "up" || keys[0] == "down" {
+			continue
+		}
+		hints = append(hints, fmt.Sprintf("\x1b[36m%s\x1b[0m %s", keys[0], strings.ReplaceAll(a.name, "_", " ")))
+	}
+	if len(hints) == 1 && current.Name() != "command" {
+		// Panels and prompts on top of the panes.
+		hints = append(hints, "\x1b[36mesc\x1b[0m close")
+	}
+	return hints
+}
+
+// updateHints redraws the hints pane beside the help pane: the message,
+// then the keys of the focused view.
+func updateHints(g *gocui.Gui) error {
+	v, err := g.View("hints")
+	if errors.Is(err, gocui.ErrUnknownView) {
+		// Collapsed.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	v.Wrap = true
+	v.Clear()
+	if text := currentMessage(); text != "" {
+		fmt.Fprintln(v, text)
+	}
+	fmt.Fprint(v, strings.Join(focusHints(g), "  "))
+	return nil
+}
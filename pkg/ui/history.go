@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// maxHistory is the number of runs kept in the history file.
+const maxHistory = 100
+
+// HistoryEntry is a single completed run.
+type HistoryEntry struct {
+	Makefile string        `json:"makefile"` // build file the target belongs to
+	Target   string        `json:"target"`
+	Args     []string      `json:"args,omitempty"`
+	ExitCode int           `json:"exit_code"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+}
+
+// history holds past runs, oldest first. It is only touched from the gocui
+// main loop.
+var history []HistoryEntry
+
+// historyPath returns the location of the history file, following the XDG
+// base directory spec.
+func historyPath() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "imake", "history.json"), nil
+}
+
+func loadHistory() error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &history)
+}
+
+func saveHistory() error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// addHistory records a finished run and persists the history and the
+// statistics of the target.
+func addHistory(entry HistoryEntry) error {
+	history = append(history, entry)
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	if err := saveHistory(); err != nil {
+		return err
+	}
+	return addStats(entry)
+}
+
+// projectHistory returns the runs of the current Makefile, newest first.
+func projectHistory() []HistoryEntry {
+	makefile := absBuildFile()
+	var entries []HistoryEntry
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Makefile == makefile {
+			entries = append(entries, history[i])
+		}
+	}
+	return entries
+}
+
+// absBuildFile returns the absolute path of the runner's build file.
+func absBuildFile() string {
+	abs, err := filepath.Abs(backend.File())
+	if err != nil {
+		return backend.File()
+	}
+	return abs
+}
+
+func formatHistoryEntry(e HistoryEntry) string {
+	status := successText("✓")
+	if e.ExitCode != 0 {
+		status = failureText(fmt.Sprintf("✗ %d", e.ExitCode))
+	}
+	command := strings.Join(append([]string{e.Target}, e.Args...), " ")
+	return fmt.Sprintf("%s  %-30s %8s  %s", e.Started.Format("01-02 15:04"), command,
+		e.Duration.Round(100*time.Millisecond), status)
+}
+
+// toggleHistory opens or closes the history overlay on top of the
+// Command Output view.
+func toggleHistory(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("history"); err == nil {
+		return closeHistory(g, v)
+	}
+
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	hv, err := g.SetView("history", x0, y0, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	hv.Title = "History (Enter to re-run, Esc to close)"
+	hv.Highlight = true
+	hv.Clear()
+	for _, e := range projectHistory() {
+		fmt.Fprintln(hv, formatHistoryEntry(e))
+	}
+	if _, err := g.SetViewOnTop("history"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("history")
+	return err
+}
+
+func closeHistory(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("history"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+// rerunHistory runs the selected history entry again.
+func rerunHistory(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	entries := projectHistory()
+	if cy+oy >= len(entries) {
+		return nil
+	}
+	entry := entries[cy+oy]
+	if err := closeHistory(g, v); err != nil {
+		return err
+	}
+	return runTarget(g, entry.Target, entry.Args)
+}
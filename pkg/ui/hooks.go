@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Hooks are shell commands run around a target: Before runs first and
+// stops the run if it fails, After runs once the target exited.
+type Hooks struct {
+	Before string `yaml:"before,omitempty"`
+	After  string `yaml:"after,omitempty"`
+}
+
+// targetHooks returns the hooks to run before and after target, those of
+// the config first, then those of the target in the project config.
+func targetHooks(target string) (before, after []string) {
+	if config.BeforeRun != "" {
+		before = append(before, config.BeforeRun)
+	}
+	if config.AfterRun != "" {
+		after = append(after, config.AfterRun)
+	}
+	if h, ok := project.Hooks[target]; ok {
+		if h.Before != "" {
+			before = append(before, h.Before)
+		}
+		if h.After != "" {
+			after = append(after, h.After)
+		}
+	}
+	return before, after
+}
+
+// hookCommand returns the command running hook with the shell. It finds
+// the target in $IMAKE_TARGET and, after the run, its exit code in
+// $IMAKE_EXIT_CODE.
+func hookCommand(hook, target string, code int) *exec.Cmd {
+	cmd := shellCommand(hook)
+	cmd.Env = append(os.Environ(), "IMAKE_TARGET="+target)
+	if code >= 0 {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("IMAKE_EXIT_CODE=%d", code))
+	}
+	return cmd
+}
+
+// runHooks runs hooks one after another for the run of target in tab,
+// writing to out, code being the exit code of the target once it ran or
+// -1. next is then called with 0, or with the exit code of the first hook
+// that failed, skipping the others.
+func runHooks(g *gocui.Gui, tab *outputTab, out io.Writer, hooks []string, target string, code int, next func(g *gocui.Gui, code int)) {
+	if len(hooks) == 0 {
+		next(g, 0)
+		return
+	}
+	_, err := startCommand(g, tab.name, out, hookCommand(hooks[0], target, code), func(g *gocui.Gui, hookCode int, started time.Time) {
+		if hookCode != 0 {
+			next(g, hookCode)
+			return
+		}
+		runHooks(g, tab, out, hooks[1:], target, code, next)
+	})
+	if err != nil {
+		fmt.Fprintln(out, "Error running hook:", err)
+		next(g, -1)
+	}
+}
@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// remoteHost is the name of the configured host commands run on, or ""
+// to run them locally.
+var remoteHost string
+
+// hostNames lists the entries of the open host switcher: "" for the local
+// machine, then the configured hosts by name.
+var hostNames []string
+
+// parseHost splits a host of the config, "user@host:/dir", into the ssh
+// destination and the directory, which is empty to use the home directory.
+func parseHost(spec string) (string, string) {
+	at := strings.LastIndex(spec, "@")
+	if i := strings.Index(spec[at+1:], ":"); i >= 0 {
+		return spec[:at+1+i], spec[at+2+i:]
+	}
+	return spec, ""
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:@+,") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// remoteCommand returns cmd as run on the selected remote host over ssh,
+// or cmd itself when running locally. A terminal is always allocated on
+// the host so that cancelling, which ends ssh, hangs up the command. The
+// host's directory stands for the working directory, so the directory of
+// cmd and its program, such as the absolute path of gradlew, are rebased
+// onto it; commands using local paths outside of it cannot run remotely.
+func remoteCommand(cmd *exec.Cmd) (*exec.Cmd, error) {
+	if remoteHost == "" {
+		return cmd, nil
+	}
+	host, dir := parseHost(config.Hosts[remoteHost])
+	cmdDir, err := remotePath(cmd.Dir)
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string(nil), cmd.Args...)
+	if filepath.IsAbs(args[0]) {
+		program, err := remotePath(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if program, err = filepath.Rel(cmdDir, program); err != nil {
+			return nil, err
+		}
+		if program = filepath.ToSlash(program); !strings.Contains(program, "/") {
+			program = "./" + program
+		}
+		args[0] = program
+	}
+	var words []string
+	for _, arg := range append(envOverrides(cmd), args...) {
+		words = append(words, shellQuote(arg))
+	}
+	script := strings.Join(words, " ")
+	if cmdDir != "." {
+		script = "cd " + shellQuote(cmdDir) + " && " + script
+	}
+	if dir != "" {
+		script = "cd " + shellQuote(dir) + " && " + script
+	}
+	return exec.Command("ssh", "-tt", host, script), nil
+}
+
+// remotePath returns the local path p, "" standing for the working
+// directory, relative to the working directory, failing if it is outside
+// of it.
+func remotePath(p string) (string, error) {
+	if !filepath.IsAbs(p) {
+		return filepath.ToSlash(filepath.Clean(p)), nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(wd, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of %s, so it has no counterpart on %s", p, wd, remoteHost)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// toggleHosts opens or closes the host switcher on top of the Command
+// Output view.
+func toggleHosts(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("hosts"); err == nil {
+		return closeHosts(g, v)
+	}
+	if len(config.Hosts) == 0 {
+		return showWarning(g, "hosts: no hosts in the config")
+	}
+	hostNames = []string{""}
+	for name := range config.Hosts {
+		hostNames = append(hostNames, name)
+	}
+	sort.Strings(hostNames[1:])
+
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	hv, err := g.SetView("hosts", x0, y0, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	hv.Title = "Hosts (Enter to switch, Esc to close)"
+	hv.Highlight = true
+	hv.Clear()
+	for i, name := range hostNames {
+		line := "local"
+		if name != "" {
+			line = fmt.Sprintf("%-20s \x1b[38;5;8m%s\x1b[0m", name, config.Hosts[name])
+		}
+		if name == remoteHost {
+			line += " \x1b[33m*\x1b[0m"
+			if err := selectLine(hv, i); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(hv, line)
+	}
+	if _, err := g.SetViewOnTop("hosts"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("hosts")
+	return err
+}
+
+func closeHosts(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("hosts"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+// switchHost makes the selected host the one commands run on.
+func switchHost(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(hostNames) {
+		return nil
+	}
+	remoteHost = hostNames[cy+oy]
+	if err := closeHosts(g, v); err != nil {
+		return err
+	}
+	if remoteHost == "" {
+		return showWarning(g, "running commands locally")
+	}
+	return showWarning(g, fmt.Sprintf("running commands on %s (%s)", remoteHost, config.Hosts[remoteHost]))
+}
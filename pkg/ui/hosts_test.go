@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRemoteCommand(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(saved Config, host string) { config, remoteHost = saved, host }(config, remoteHost)
+	config.Hosts = map[string]string{"box": "dev@box:/srv/app"}
+	remoteHost = "box"
+
+	tests := []struct {
+		name string
+		args []string
+		dir  string
+		want string // script run over ssh, "" for an error
+	}{
+		{name: "no directory", args: []string{"make", "build"}, want: "cd /srv/app && make build"},
+		{name: "relative directory", args: []string{"make", "test"}, dir: "src", want: "cd /srv/app && cd src && make test"},
+		{name: "absolute directory in the project", args: []string{"go", "test", "./..."}, dir: filepath.Join(wd, "sub", "mod"), want: "cd /srv/app && cd sub/mod && go test ./..."},
+		{name: "the project itself", args: []string{"bazel", "build", "//:app"}, dir: wd, want: "cd /srv/app && bazel build //:app"},
+		{name: "absolute program", args: []string{filepath.Join(wd, "gradlew"), "-p", ".", "build"}, want: "cd /srv/app && ./gradlew -p . build"},
+		{name: "absolute program from a subdirectory", args: []string{filepath.Join(wd, "gradlew"), "build"}, dir: "app", want: "cd /srv/app && cd app && ../gradlew build"},
+		{name: "directory outside of the project", args: []string{"go", "build"}, dir: filepath.Dir(wd)},
+		{name: "program outside of the project", args: []string{filepath.Join(filepath.Dir(wd), "gradlew")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(tt.args[0], tt.args[1:]...)
+			cmd.Dir = tt.dir
+			remote, err := remoteCommand(cmd)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("remoteCommand() = %q, want an error", remote.Args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"ssh", "-tt", "dev@box", tt.want}; !reflect.DeepEqual(remote.Args, want) {
+				t.Errorf("remoteCommand() = %q, want %q", remote.Args, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// commandInput is the standard input of a running command.
+type commandInput struct {
+	w   io.Writer
+	tty bool // w is a pseudo-terminal, which echoes and edits lines itself
+}
+
+// interactTab is the tab whose command receives the keys typed in the
+// "interact" view, or nil when not interacting.
+var interactTab *outputTab
+
+// openInteract forwards the keyboard to the command running in the active
+// tab, e.g. to answer a prompt, until Esc is pressed.
+func openInteract(g *gocui.Gui, v *gocui.View) error {
+	if activeTab < 0 || tabs[activeTab].input == nil {
+		return showWarning(g, "interact: no command is running in this tab")
+	}
+	tab := tabs[activeTab]
+
+	x0, _, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	iv, err := g.SetView("interact", x0, y1-2, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	iv.Title = fmt.Sprintf("Input to %s (Esc to detach)", tab.name)
+	iv.Editable = true
+	iv.Editor = &inputEditor{tab: tab}
+	interactTab = tab
+	if _, err := g.SetViewOnTop("interact"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("interact")
+	return err
+}
+
+// closeInteract stops forwarding the keyboard and goes back to the
+// Sidebar. The command keeps running.
+func closeInteract(g *gocui.Gui, v *gocui.View) error {
+	interactTab = nil
+	if err := g.DeleteView("interact"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+// inputEditor sends the keys typed in the "interact" view to the command
+// of tab. A pseudo-terminal gets every key as it is typed; a command
+// reading a pipe gets whole lines, edited in the view first.
+type inputEditor struct {
+	tab *outputTab
+}
+
+func (e *inputEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	in := e.tab.input
+	if in == nil {
+		return
+	}
+	var err error
+	switch {
+	case in.tty:
+		_, err = in.w.Write(terminalKey(key, ch))
+	case key == gocui.KeyEnter:
+		line := strings.TrimSuffix(v.Buffer(), "\n")
+		v.Clear()
+		v.SetCursor(0, 0)
+		v.SetOrigin(0, 0)
+		// Echo the line as a terminal would.
+		fmt.Fprintln(e.tab, line)
+		_, err = io.WriteString(in.w, line+"\n")
+	default:
+		gocui.DefaultEditor.Edit(v, key, ch, mod)
+	}
+	if err != nil {
+		fmt.Fprintf(e.tab, "\x1b[33minteract: %v\x1b[0m\n", err)
+	}
+}
+
+// terminalKey returns the bytes a terminal sends for a key.
+func terminalKey(key gocui.Key, ch rune) []byte {
+	if ch != 0 {
+		return []byte(string(ch))
+	}
+	switch key {
+	case gocui.KeySpace:
+		return []byte(" ")
+	case gocui.KeyArrowUp:
+		return []byte("\x1b[A")
+	case gocui.KeyArrowDown:
+		return []byte("\x1b[B")
+	case gocui.KeyArrowRight:
+		return []byte("\x1b[C")
+	case gocui.KeyArrowLeft:
+		return []byte("\x1b[D")
+	case gocui.KeyDelete:
+		return []byte("\x1b[3~")
+	}
+	// Enter, Backspace and the Ctrl keys are control characters.
+	if key < 0x20 || key == gocui.KeyBackspace2 {
+		return []byte{byte(key)}
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/jroimartin/gocui"
+)
+
+// Ways of running a target detached from imake.
+const (
+	detachTmux       = "tmux"
+	detachBackground = "background"
+)
+
+// tmuxNameRegexp matches the characters tmux does not allow in session
+// names.
+var tmuxNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Job is a target running detached from imake, in a tmux session or as a
+// background process writing to a log file, which outlives imake.
+type Job struct {
+	Target   string    `json:"target"`
+	Makefile string    `json:"makefile"` // build file the target belongs to
+	Mode     string    `json:"mode"`     // detachTmux or detachBackground
+	Session  string    `json:"session,omitempty"`
+	PID      int       `json:"pid,omitempty"`
+	Log      string    `json:"log,omitempty"`
+	Started  time.Time `json:"started"`
+}
+
+// alive reports whether the job is still running.
+func (j Job) alive() bool {
+	if j.Mode == detachTmux {
+		return exec.Command("tmux", "has-session", "-t", "="+j.Session).Run() == nil
+	}
+	return runner.ProcessAlive(j.PID)
+}
+
+// jobs lists the detached targets of every project, oldest first. It is
+// only touched from the gocui main loop.
+var jobs []Job
+
+// jobsPath returns the location of the jobs file, next to the sessions.
+func jobsPath() (string, error) {
+	path, err := sessionPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(filepath.Dir(path)), "jobs.json"), nil
+}
+
+func loadJobs() error {
+	path, err := jobsPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &jobs)
+}
+
+func saveJobs() error {
+	path, err := jobsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// detachMode returns how targets are detached: as the config says, else
+// in tmux when imake runs inside it, else in the background.
+func detachMode() string {
+	switch config.Detach {
+	case detachTmux, detachBackground:
+		return config.Detach
+	}
+	if os.Getenv("TMUX") != "" {
+		return detachTmux
+	}
+	return detachBackground
+}
+
+// detachTarget runs the selected target detached from imake, so that
+// servers and watchers keep running once it exits.
+func detachTarget(g *gocui.Gui, v *gocui.View) error {
+	target := selectedTarget(v)
+	if target == "" {
+		return nil
+	}
+	if remoteHost != "" {
+		return showWarning(g, "detach: only local targets can be detached")
+	}
+	if err := loadJobs(); err != nil {
+		return showWarning(g, fmt.Sprintf("jobs: %v", err))
+	}
+	for _, j := range jobs {
+		if j.Target == target && j.Makefile == absBuildFile() && j.alive() {
+			return showWarning(g, fmt.Sprintf("detach: %s is running already (J lists the jobs)", target))
+		}
+	}
+
+	cmd := backend.Exec(target, nil)
+	if err := applyDotenv(cmd); err != nil {
+		return showWarning(g, fmt.Sprintf("detach: %v", err))
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if cmd.Dir != "" {
+		dir = filepath.Join(dir, cmd.Dir)
+	}
+	job := Job{Target: target, Makefile: absBuildFile(), Mode: detachMode(), Started: time.Now()}
+	if job.Mode == detachTmux {
+		job.Session = "imake-" + strings.Trim(tmuxNameRegexp.ReplaceAllString(filepath.Base(dir)+"-"+target, "-"), "-")
+		args := []string{"new-session", "-d", "-s", job.Session, "-c", dir}
+		for _, kv := range envOverrides(cmd) {
+			args = append(args, "-e", kv)
+		}
+		out, err := exec.Command("tmux", append(append(args, "--"), cmd.Args...)...).CombinedOutput()
+		if err != nil {
+			return showWarning(g, fmt.Sprintf("detach: tmux: %v: %s", err, strings.TrimSpace(string(out))))
+		}
+	} else {
+		if err := os.MkdirAll(logDir, 0o755); err != nil {
+			return showWarning(g, fmt.Sprintf("detach: %v", err))
+		}
+		job.Log, _ = filepath.Abs(filepath.Join(logDir, logFileName(target, "job")+".log"))
+		log, err := os.Create(job.Log)
+		if err != nil {
+			return showWarning(g, fmt.Sprintf("detach: %v", err))
+		}
+		defer log.Close()
+		cmd.Stdout, cmd.Stderr = log, log
+		runner.SetDetached(cmd)
+		if err := cmd.Start(); err != nil {
+			return showWarning(g, fmt.Sprintf("detach: %v", err))
+		}
+		job.PID = cmd.Process.Pid
+		// Reap it if it exits while imake runs.
+		go cmd.Wait()
+	}
+
+	jobs = append(jobs, job)
+	if err := saveJobs(); err != nil {
+		return showWarning(g, fmt.Sprintf("jobs: %v", err))
+	}
+	return showNotice(fmt.Sprintf("%s is running detached (%s); J lists the jobs", target, describeJob(job)))
+}
+
+// describeJob tells where job runs.
+func describeJob(j Job) string {
+	if j.Mode == detachTmux {
+		return "tmux session " + j.Session
+	}
+	return fmt.Sprintf("pid %d", j.PID)
+}
+
+// formatJob describes a job on one line of the jobs panel.
+func formatJob(j Job) string {
+	status := successText("●")
+	if !j.alive() {
+		status = "\x1b[38;5;8m○ exited\x1b[0m"
+	}
+	line := fmt.Sprintf("%s %s  %s  since %s", status, j.Target, describeJob(j), j.Started.Format("01-02 15:04"))
+	if j.Makefile != absBuildFile() {
+		line += "  \x1b[38;5;8m" + displayPath(filepath.Dir(j.Makefile), filepath.Base(j.Makefile)) + "\x1b[0m"
+	}
+	return line
+}
+
+// toggleJobs opens or closes the panel listing the detached targets on
+// top of the Command Output view.
+func toggleJobs(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("jobs"); err == nil {
+		return closeJobs(g, v)
+	}
+	if err := loadJobs(); err != nil {
+		return showWarning(g, fmt.Sprintf("jobs: %v", err))
+	}
+	if len(jobs) == 0 {
+		return showWarning(g, "jobs: no detached targets (b runs one detached)")
+	}
+
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	jv, err := g.SetView("jobs", x0, y0, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	jv.Title = "Jobs (Enter attaches, x kills or forgets, Esc closes)"
+	jv.Highlight = true
+	renderJobs(jv)
+	if _, err := g.SetViewOnTop("jobs"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("jobs")
+	return err
+}
+
+func renderJobs(v *gocui.View) {
+	v.Clear()
+	for _, j := range jobs {
+		fmt.Fprintln(v, formatJob(j))
+	}
+}
+
+func closeJobs(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("jobs"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+// selectedJob returns the index of the job under the cursor of the jobs
+// panel, or -1.
+func selectedJob(v *gocui.View) int {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(jobs) {
+		return -1
+	}
+	return cy + oy
+}
+
+// attachJob shows the selected job: its tmux session, which takes over
+// the terminal until it is detached from, or its log, followed in a tab.
+func attachJob(g *gocui.Gui, v *gocui.View) error {
+	i := selectedJob(v)
+	if i < 0 {
+		return nil
+	}
+	j := jobs[i]
+	if err := closeJobs(g, v); err != nil {
+		return err
+	}
+	if j.Mode == detachTmux {
+		if !j.alive() {
+			return showWarning(g, fmt.Sprintf("jobs: the tmux session of %s has exited", j.Target))
+		}
+		if os.Getenv("TMUX") != "" {
+			if out, err := exec.Command("tmux", "switch-client", "-t", "="+j.Session).CombinedOutput(); err != nil {
+				return showWarning(g, fmt.Sprintf("jobs: tmux: %v: %s", err, strings.TrimSpace(string(out))))
+			}
+			return nil
+		}
+		runErr, err := runInTerminal(exec.Command("tmux", "attach-session", "-t", "="+j.Session))
+		if err != nil {
+			return err
+		}
+		if runErr != nil {
+			return showWarning(g, fmt.Sprintf("jobs: tmux: %v", runErr))
+		}
+		return nil
+	}
+
+	if err := closeRunPanes(g); err != nil {
+		return err
+	}
+	name := j.Target + " (job)"
+	tab := openTab(name)
+	if runs.Busy(name) {
+		// The log is followed already.
+		return nil
+	}
+	tab.reset()
+	return startTabCommand(g, tab, tab, followCommand(j.Log), nil)
+}
+
+// followCommand returns the command printing the file at path and what
+// is appended to it, until it is cancelled.
+func followCommand(path string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("powershell", "-NoProfile", "-Command", "Get-Content", "-Wait", "-Path", path)
+	}
+	return exec.Command("tail", "-n", "+1", "-f", path)
+}
+
+// killJob stops the selected job, or forgets it if it has exited.
+func killJob(g *gocui.Gui, v *gocui.View) error {
+	i := selectedJob(v)
+	if i < 0 {
+		return nil
+	}
+	j := jobs[i]
+	if j.alive() {
+		var err error
+		if j.Mode == detachTmux {
+			err = exec.Command("tmux", "kill-session", "-t", "="+j.Session).Run()
+		} else {
+			err = runner.KillDetached(j.PID)
+		}
+		if err != nil {
+			return showWarning(g, fmt.Sprintf("jobs: killing %s: %v", j.Target, err))
+		}
+	}
+	jobs = append(jobs[:i], jobs[i+1:]...)
+	if err := saveJobs(); err != nil {
+		return showWarning(g, fmt.Sprintf("jobs: %v", err))
+	}
+	if len(jobs) == 0 {
+		return closeJobs(g, v)
+	}
+	renderJobs(v)
+	if i >= len(jobs) {
+		return cursorUp(g, v)
+	}
+	return nil
+}
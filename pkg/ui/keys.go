@@ -0,0 +1,555 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jroimartin/gocui"
+)
+
+// action is a user-facing command that can be bound to keys in the config.
+type action struct {
+	name    string
+	view    string   // view the keys apply to, "" for all views
+	keys    []string // default keys
+	handler func(*gocui.Gui, *gocui.View) error
+}
+
+// actions lists every remappable action with its default keys.
+var actions = []action{
+	{"cursor_down", "Sidebar", []string{"down"}, cursorDown},
+	{"cursor_up", "Sidebar", []string{"up"}, cursorUp},
+	{"cursor_top", "", nil, cursorTop},
+	{"cursor_bottom", "", nil, cursorBottom},
+	{"focus_next", "", []string{"ctrl+w"}, focusNext},
+	{"next_tab", "", []string{"tab"}, nextTab},
+	{"prev_tab", "", nil, prevTab},
+	{"close_tab", "Sidebar", []string{"x"}, closeTab},
+	{"scroll_down", "command", []string{"down"}, scrollLineDown},
+	{"scroll_up", "command", []string{"up"}, scrollLineUp},
+	{"select_lines", "command", []string{"V"}, startSelection},
+	{"copy_output", "command", []string{"y"}, copyOutput},
+	{"copy_report", "", []string{"Y"}, copyReport},
+	{"fold_sections", "", []string{"F"}, foldSections},
+	{"bookmark", "command", []string{"m"}, toggleBookmark},
+	{"next_bookmark", "command", []string{"'"}, nextBookmark},
+	{"prev_bookmark", "command", []string{"\""}, prevBookmark},
+	{"run", "Sidebar", []string{"enter"}, executeCommand},
+	{"run_with_args", "Sidebar", []string{"a"}, openArgsPrompt},
+	{"dry_run", "Sidebar", []string{"d"}, dryRunTarget},
+	{"edit", "Sidebar", []string{"e"}, editTarget},
+	{"make_flags", "Sidebar", []string{"m"}, openFlagsPrompt},
+	{"search", "Sidebar", []string{"/"}, openFilter},
+	{"mark", "Sidebar", []string{"space"}, toggleMark},
+	{"favorite", "Sidebar", []string{"f"}, toggleFavorite},
+	{"run_marked", "Sidebar", []string{"p"}, runMarked},
+	{"run_queue", "Sidebar", []string{"R"}, runQueue},
+	{"rerun", "", []string{"r"}, rerunLast},
+	{"close_runs", "Sidebar", []string{"esc"}, closeRunPanesHandler},
+	{"toggle_hidden", "Sidebar", []string{"."}, toggleHidden},
+	{"badge_filter", "Sidebar", []string{"@"}, cycleBadgeFilter},
+	{"sort", "Sidebar", []string{"#"}, cycleSort},
+	{"reload", "", []string{"ctrl+r"}, reloadHandler},
+	{"history", "Sidebar", []string{"h"}, toggleHistory},
+	{"run_sudo", "Sidebar", []string{"u"}, runSudo},
+	{"detach", "Sidebar", []string{"b"}, detachTarget},
+	{"jobs", "Sidebar", []string{"J"}, toggleJobs},
+	{"stats", "Sidebar", []string{"S"}, toggleStats},
+	{"variables", "Sidebar", []string{"$"}, toggleVariables},
+	{"graph", "Sidebar", []string{"g"}, toggleGraph},
+	{"recipe", "Sidebar", []string{"v"}, toggleRecipe},
+	{"export_help", "Sidebar", []string{"E"}, exportHelp},
+	{"problems", "Sidebar", []string{"P"}, toggleProblems},
+	{"diagnostics", "Sidebar", []string{"W"}, toggleDiagnostics},
+	{"projects", "Sidebar", []string{"o"}, toggleProjects},
+	{"recent_projects", "", []string{"ctrl+o"}, toggleRecent},
+	{"hosts", "Sidebar", []string{"H"}, toggleHosts},
+	{"toggle_log", "Sidebar", []string{"L"}, toggleLog},
+	{"dotenv", "Sidebar", []string{"N"}, toggleDotenv},
+	{"timestamps", "Sidebar", []string{"t"}, toggleTimestamps},
+	{"page_output", "", []string{"O"}, pageOutput},
+	{"dump_output", "Sidebar", []string{"D"}, dumpOutput},
+	{"save_report", "Sidebar", []string{"M"}, saveReport},
+	{"scroll_page_up", "", []string{"pgup"}, scrollPageUp},
+	{"scroll_page_down", "", []string{"pgdn"}, scrollPageDown},
+	{"scroll_half_page_up", "", []string{"ctrl+u"}, scrollHalfPageUp},
+	{"scroll_half_page_down", "", []string{"ctrl+d"}, scrollHalfPageDown},
+	{"scroll_top", "", []string{"home"}, scrollTop},
+	{"scroll_bottom", "", []string{"end"}, scrollBottom},
+	{"grow_sidebar", "", []string{">"}, resizeSidebar(1)},
+	{"shrink_sidebar", "", []string{"<"}, resizeSidebar(-1)},
+	{"zoom_output", "", []string{"z"}, toggleZoom},
+	{"compare", "", []string{"s"}, toggleCompare},
+	{"diff_previous", "", []string{"="}, diffPrevious},
+	{"shell", "", []string{":"}, openShellPrompt},
+	{"record_macro", "", []string{"Q"}, toggleRecording},
+	{"toggle_help", "", []string{"i"}, toggleHelpPane},
+	{"interact", "", []string{"I"}, openInteract},
+	{"cancel", "", []string{"ctrl+k"}, cancelCommand},
+	{"quit", "", []string{"ctrl+c"}, quit},
+}
+
+func init() {
+	// The palette and the cheat sheet list the other actions, so they
+	// cannot be part of the table literal without an initialization cycle.
+	actions = append(actions,
+		action{"palette", "", []string{"ctrl+p"}, openPalette},
+		action{"keys", "", []string{"?"}, toggleKeys},
+	)
+}
+
+var namedKeys = map[string]gocui.Key{
+	"up":        gocui.KeyArrowUp,
+	"down":      gocui.KeyArrowDown,
+	"left":      gocui.KeyArrowLeft,
+	"right":     gocui.KeyArrowRight,
+	"enter":     gocui.KeyEnter,
+	"esc":       gocui.KeyEsc,
+	"space":     gocui.KeySpace,
+	"tab":       gocui.KeyTab,
+	"backspace": gocui.KeyBackspace2,
+	"delete":    gocui.KeyDelete,
+	"insert":    gocui.KeyInsert,
+	"home":      gocui.KeyHome,
+	"end":       gocui.KeyEnd,
+	"pgup":      gocui.KeyPgup,
+	"pgdn":      gocui.KeyPgdn,
+	"f1":        gocui.KeyF1,
+	"f2":        gocui.KeyF2,
+	"f3":        gocui.KeyF3,
+	"f4":        gocui.KeyF4,
+	"f5":        gocui.KeyF5,
+	"f6":        gocui.KeyF6,
+	"f7":        gocui.KeyF7,
+	"f8":        gocui.KeyF8,
+	"f9":        gocui.KeyF9,
+	"f10":       gocui.KeyF10,
+	"f11":       gocui.KeyF11,
+	"f12":       gocui.KeyF12,
+}
+
+// parseKey converts a key name such as "enter", "ctrl+k" or "j" into the
+// value gocui.SetKeybinding expects. A doubled character such as "gg"
+// is returned as its rune with double set.
+func parseKey(name string) (key interface{}, double bool, err error) {
+	if runes := []rune(name); len(runes) == 2 && runes[0] == runes[1] {
+		return runes[0], true, nil
+	}
+	key, err = parseSingleKey(name)
+	return key, false, err
+}
+
+func parseSingleKey(name string) (interface{}, error) {
+	lower := strings.ToLower(name)
+	if key, ok := namedKeys[lower]; ok {
+		return key, nil
+	}
+	if letter, ok := strings.CutPrefix(lower, "ctrl+"); ok {
+		if len(letter) == 1 && letter[0] >= 'a' && letter[0] <= 'z' {
+			return gocui.Key(letter[0] - 'a' + 1), nil
+		}
+		return nil, fmt.Errorf("unknown key %q", name)
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		return r, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", name)
+}
+
+// actionKeys returns the keys bound to a, honouring the config and the
+// vim profile.
+func actionKeys(a action) []string {
+	if keys, ok := config.Keybindings[a.name]; ok {
+		return keys
+	}
+	if keys, ok := vimKeys[a.name]; ok && config.Vim {
+		return keys
+	}
+	return a.keys
+}
+
+// validateKeybindings reports unknown actions, unknown keys and keys bound
+// to more than one action in the config.
+func validateKeybindings() []string {
+	var problems []string
+	known := make(map[string]bool)
+	for _, a := range actions {
+		known[a.name] = true
+	}
+	var names []string
+	for name := range config.Keybindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !known[name] {
+			problems = append(problems, fmt.Sprintf("keybindings: unknown action %q", name))
+		}
+	}
+
+	used := make(map[string]string)
+	for _, a := range actions {
+		for _, k := range actionKeys(a) {
+			key, _, err := parseKey(k)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("keybindings.%s: %v", a.name, err))
+				continue
+			}
+			id := fmt.Sprintf("%s/%v", a.view, key)
+			if other, ok := used[id]; ok {
+				problems = append(problems, fmt.Sprintf("keybindings.%s: %q is already bound to %s", a.name, k, other))
+				continue
+			}
+			used[id] = a.name
+		}
+	}
+	return problems
+}
+
+// typeThrough wraps the handler of a global character key so the character
+// is typed into prompts instead of triggering the action there.
+func typeThrough(r rune, handler func(*gocui.Gui, *gocui.View) error) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if v != nil && v.Editable && v.Editor != nil {
+			v.Editor.Edit(v, 0, r, gocui.ModNone)
+			return nil
+		}
+		return handler(g, v)
+	}
+}
+
+func keybindings(g *gocui.Gui) error {
+	for _, a := range actions {
+		for _, k := range actionKeys(a) {
+			key, double, err := parseKey(k)
+			if err != nil {
+				// Reported by validateKeybindings.
+				continue
+			}
+			handler := recordAction(a.name, a.handler)
+			if double {
+				handler = doubleTap(key.(rune), handler)
+			}
+			if r, ok := key.(rune); ok && a.view == "" {
+				handler = typeThrough(r, handler)
+			}
+			if err := g.SetKeybinding(a.view, key, gocui.ModNone, handler); err != nil {
+				return err
+			}
+			// The compare pane scrolls like the Command Output.
+			if a.view == "command" {
+				if err := g.SetKeybinding("compare", key, gocui.ModNone, handler); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// F1-F12 replay the macros of the project, unless an action has them.
+	reservedMacroKeys = nil
+	for _, a := range actions {
+		for _, k := range actionKeys(a) {
+			if slices.Contains(macroKeys, k) {
+				reservedMacroKeys = append(reservedMacroKeys, k)
+			}
+		}
+	}
+	for _, k := range macroKeys {
+		if slices.Contains(reservedMacroKeys, k) {
+			continue
+		}
+		for _, view := range []string{"Sidebar", "command"} {
+			if err := g.SetKeybinding(view, namedKeys[k], gocui.ModNone, replayMacro(k)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The keys opening an overlay also close it.
+	overlays := map[string]struct {
+		view    string
+		handler func(*gocui.Gui, *gocui.View) error
+	}{
+		"history":     {"history", closeHistory},
+		"stats":       {"stats", closeStats},
+		"graph":       {"graph", closeGraph},
+		"recipe":      {"recipe", closeRecipe},
+		"problems":    {"problems", closeProblems},
+		"diagnostics": {"diagnostics", closeDiagnostics},
+		"projects":    {"projects", closeProjects},
+		"hosts":       {"hosts", closeHosts},
+		"jobs":        {"jobs", closeJobs},
+	}
+	for _, a := range actions {
+		overlay, ok := overlays[a.name]
+		if !ok {
+			continue
+		}
+		for _, k := range actionKeys(a) {
+			if key, _, err := parseKey(k); err == nil {
+				if err := g.SetKeybinding(overlay.view, key, gocui.ModNone, overlay.handler); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := g.SetKeybinding("filter", gocui.KeyArrowDown, gocui.ModNone, filterCursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("filter", gocui.KeyArrowUp, gocui.ModNone, filterCursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("filter", gocui.KeyEnter, gocui.ModNone, recordPrompt("filter", acceptFilter)); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("filter", gocui.KeyEsc, gocui.ModNone, clearFilter); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("vars", gocui.KeyArrowDown, gocui.ModNone, variablesCursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("vars", gocui.KeyArrowUp, gocui.ModNone, variablesCursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("vars", gocui.KeyEnter, gocui.ModNone, editVariable); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("vars", gocui.KeyCtrlE, gocui.ModNone, evaluateVariables); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("vars", gocui.KeyEsc, gocui.ModNone, closeVariables); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("palette", gocui.KeyArrowDown, gocui.ModNone, paletteCursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("palette", gocui.KeyArrowUp, gocui.ModNone, paletteCursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("palette", gocui.KeyEnter, gocui.ModNone, runPaletteEntry); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("palette", gocui.KeyEsc, gocui.ModNone, closePalette); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("setup", gocui.KeyArrowDown, gocui.ModNone, setupCursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("setup", gocui.KeyArrowUp, gocui.ModNone, cursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("setup", gocui.KeyEnter, gocui.ModNone, pickSetup); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("setup", gocui.KeyEsc, gocui.ModNone, quit); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("presets", gocui.KeyArrowDown, gocui.ModNone, presetCursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("presets", gocui.KeyArrowUp, gocui.ModNone, cursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("presets", gocui.KeyEnter, gocui.ModNone, recordPrompt("presets", runPreset)); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("presets", gocui.KeyEsc, gocui.ModNone, closePresets); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("history", gocui.KeyArrowDown, gocui.ModNone, cursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("history", gocui.KeyArrowUp, gocui.ModNone, cursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("history", gocui.KeyEnter, gocui.ModNone, rerunHistory); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("history", gocui.KeyEsc, gocui.ModNone, closeHistory); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("keys", gocui.KeyArrowDown, gocui.ModNone, graphScrollDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("keys", gocui.KeyArrowUp, gocui.ModNone, graphScrollUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("keys", gocui.KeyEsc, gocui.ModNone, closeKeys); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("diagnostics", gocui.KeyArrowDown, gocui.ModNone, cursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("diagnostics", gocui.KeyArrowUp, gocui.ModNone, cursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("diagnostics", gocui.KeyEnter, gocui.ModNone, editDiagnostic); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("diagnostics", gocui.KeyEsc, gocui.ModNone, closeDiagnostics); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("jobs", gocui.KeyArrowDown, gocui.ModNone, cursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("jobs", gocui.KeyArrowUp, gocui.ModNone, cursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("jobs", gocui.KeyEnter, gocui.ModNone, attachJob); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("jobs", 'x', gocui.ModNone, killJob); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("jobs", gocui.KeyEsc, gocui.ModNone, closeJobs); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("stats", gocui.KeyArrowDown, gocui.ModNone, cursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("stats", gocui.KeyArrowUp, gocui.ModNone, cursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("stats", gocui.KeyEsc, gocui.ModNone, closeStats); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("projects", gocui.KeyArrowDown, gocui.ModNone, cursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("projects", gocui.KeyArrowUp, gocui.ModNone, cursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("projects", gocui.KeyEnter, gocui.ModNone, switchProject); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("projects", gocui.KeyEsc, gocui.ModNone, closeProjects); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("recent", gocui.KeyArrowDown, gocui.ModNone, cursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("recent", gocui.KeyArrowUp, gocui.ModNone, cursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("recent", gocui.KeyEnter, gocui.ModNone, switchRecent); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("recent", gocui.KeyEsc, gocui.ModNone, closeRecent); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("hosts", gocui.KeyArrowDown, gocui.ModNone, cursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("hosts", gocui.KeyArrowUp, gocui.ModNone, cursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("hosts", gocui.KeyEnter, gocui.ModNone, switchHost); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("hosts", gocui.KeyEsc, gocui.ModNone, closeHosts); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("graph", gocui.KeyArrowDown, gocui.ModNone, graphScrollDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("graph", gocui.KeyArrowUp, gocui.ModNone, graphScrollUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("graph", gocui.KeyEsc, gocui.ModNone, closeGraph); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("interact", gocui.KeyEsc, gocui.ModNone, closeInteract); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("flags", gocui.KeyEnter, gocui.ModNone, recordPrompt("flags", applyFlagsPrompt)); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("flags", gocui.KeyEsc, gocui.ModNone, closeFlagsPrompt); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("recipe", gocui.KeyArrowDown, gocui.ModNone, graphScrollDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("recipe", gocui.KeyArrowUp, gocui.ModNone, graphScrollUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("recipe", gocui.KeyEsc, gocui.ModNone, closeRecipe); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("problems", gocui.KeyArrowDown, gocui.ModNone, cursorDown); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("problems", gocui.KeyArrowUp, gocui.ModNone, cursorUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("problems", gocui.KeyEnter, gocui.ModNone, editProblem); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("problems", gocui.KeyEsc, gocui.ModNone, closeProblems); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("compare", gocui.KeyEsc, gocui.ModNone, closeCompare); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("command", gocui.KeyEsc, gocui.ModNone, endSelection); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("args", gocui.KeyEnter, gocui.ModNone, recordPrompt("args", executeWithArgs)); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("args", gocui.KeyEsc, gocui.ModNone, closeArgsPrompt); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("sudo", gocui.KeyEnter, gocui.ModNone, submitSudoPassword); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("sudo", gocui.KeyEsc, gocui.ModNone, cancelSudoPrompt); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("shell", gocui.KeyEnter, gocui.ModNone, recordPrompt("shell", executeShell)); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("shell", gocui.KeyEsc, gocui.ModNone, closeShellPrompt); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("macro", gocui.KeyEnter, gocui.ModNone, saveMacro); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("macro", gocui.KeyEsc, gocui.ModNone, closeMacroPrompt); err != nil {
+		return err
+	}
+	for n := 1; n <= 9; n++ {
+		for _, view := range []string{"Sidebar", "command"} {
+			if err := g.SetKeybinding(view, rune('0'+n), gocui.ModNone, selectTab(n)); err != nil {
+				return err
+			}
+		}
+	}
+	clicks := map[string]func(*gocui.Gui, *gocui.View) error{
+		"Sidebar":  click(executeCommand),
+		"command":  click(toggleSection),
+		"compare":  click(nil),
+		"history":  click(rerunHistory),
+		"projects": click(switchProject),
+		"recent":   click(switchRecent),
+		"presets":  click(runPreset),
+		"setup":    click(pickSetup),
+	}
+	for view, handler := range clicks {
+		if err := g.SetKeybinding(view, gocui.MouseLeft, gocui.ModNone, handler); err != nil {
+			return err
+		}
+	}
+	if err := g.SetKeybinding("command", gocui.MouseWheelUp, gocui.ModNone, scrollWheelUp); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("command", gocui.MouseWheelDown, gocui.ModNone, scrollWheelDown); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jroimartin/gocui"
+)
+
+// logDir is where run logs are written, relative to the directory the
+// commands run in.
+var logDir = filepath.Join(".imake", "logs")
+
+// logging tees the output of each run into a log file. It starts out as
+// set in the config and is toggled with the toggle_log action.
+var logging bool
+
+// plainWriter writes to w with ANSI escapes removed, so log files read
+// well in an editor.
+type plainWriter struct {
+	w io.Writer
+}
+
+func (p plainWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(p.w, filterANSI(string(b), ANSIStrip)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// logFileName returns the name, without extension, of a file of kind,
+// such as "report", written for target now. The characters that separate
+// paths or are not allowed in file names on some platforms, as in
+// services/api:build, and whitespace are replaced with underscores. Plain
+// run logs have no kind.
+func logFileName(target, kind string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || unicode.IsSpace(r) {
+			return '_'
+		}
+		return r
+	}, target)
+	if kind != "" {
+		name += "-" + kind
+	}
+	return name + "-" + time.Now().Format("20060102-150405")
+}
+
+// teeLog returns a writer copying to out and, when logging is on, to a new
+// log file for target, along with a func closing the log. Failing to
+// create the log is reported in out and the run goes on without it.
+func teeLog(out io.Writer, target string) (io.Writer, func()) {
+	if !logging {
+		return out, func() {}
+	}
+	path := filepath.Join(logDir, logFileName(target, "")+".log")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		fmt.Fprintf(out, "\x1b[33mlog: %v\x1b[0m\n", err)
+		return out, func() {}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(out, "\x1b[33mlog: %v\x1b[0m\n", err)
+		return out, func() {}
+	}
+	fmt.Fprintf(out, "\x1b[38;5;8m# log: %s\x1b[0m\n", path)
+	return io.MultiWriter(out, plainWriter{f}), func() { f.Close() }
+}
+
+// toggleLog turns writing run logs on or off.
+func toggleLog(g *gocui.Gui, v *gocui.View) error {
+	logging = !logging
+	state := "off"
+	if logging {
+		state = "on, writing to " + logDir
+	}
+	return showWarning(g, "logging "+state)
+}
+
+// openLogs opens the log directory in the desktop file manager.
+func openLogs(g *gocui.Gui) error {
+	if _, err := os.Stat(logDir); err != nil {
+		return showWarning(g, fmt.Sprintf("logs: %v", err))
+	}
+	opener := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		opener = "open"
+	}
+	cmd := exec.Command(opener, logDir)
+	if err := cmd.Start(); err != nil {
+		return showWarning(g, fmt.Sprintf("logs: %v", err))
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLogFileName(t *testing.T) {
+	tests := []struct {
+		target, kind string
+		want         string // without the time
+	}{
+		{target: "build", want: "build-"},
+		{target: "build", kind: "report", want: "build-report-"},
+		{target: "services/api:build", kind: "output", want: "services_api_build-output-"},
+		{target: `pkg\api:test`, want: "pkg_api_test-"},
+		{target: "run server", kind: "job", want: "run_server-job-"},
+	}
+	for _, tt := range tests {
+		name := logFileName(tt.target, tt.kind)
+		if !strings.HasPrefix(name, tt.want) || len(name) != len(tt.want)+len("20060102-150405") {
+			t.Errorf("logFileName(%q, %q) = %q, want %q and the time", tt.target, tt.kind, name, tt.want)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// MacroStep is a step of a recorded macro: an action, run with Target
+// selected in the Sidebar if set, or the Text submitted in the Prompt view,
+// such as the filter or the arguments of a target.
+type MacroStep struct {
+	Action string `yaml:"action,omitempty"`
+	Prompt string `yaml:"prompt,omitempty"`
+	Text   string `yaml:"text,omitempty"`
+	Target string `yaml:"target,omitempty"`
+}
+
+// macroKeys are the keys macros are saved on and replayed with.
+var macroKeys = []string{"f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8", "f9", "f10", "f11", "f12"}
+
+// macroStepDelay is how long a macro waits after a step before checking
+// whether it started a command, and between checks while one runs.
+const macroStepDelay = 100 * time.Millisecond
+
+// unrecorded lists the actions left out of macros: moving around, as each
+// step records its target instead, and the keys that only show help.
+var unrecorded = map[string]bool{
+	"record_macro": true, "cursor_down": true, "cursor_up": true, "cursor_top": true,
+	"cursor_bottom": true, "scroll_down": true, "scroll_up": true, "scroll_page_up": true,
+	"scroll_page_down": true, "scroll_half_page_up": true, "scroll_half_page_down": true,
+	"scroll_top": true, "scroll_bottom": true, "palette": true, "keys": true, "quit": true,
+}
+
+// macroPrompts are the prompts whose submitted text or choice macros
+// record, by view, with the handler submitting them.
+var macroPrompts = map[string]func(*gocui.Gui, *gocui.View) error{
+	"filter":  acceptFilter,
+	"args":    executeWithArgs,
+	"flags":   applyFlagsPrompt,
+	"shell":   executeShell,
+	"presets": runPreset,
+}
+
+// macroChoices are the pickers whose choice macros record, by view, with
+// the entries they list. Choices are recorded and replayed by their index
+// in these, never by the text on screen.
+var macroChoices = map[string]func() []string{
+	"presets": func() []string { return presetChoices },
+}
+
+// choiceAt returns the entry at index i of the picker view.
+func choiceAt(view string, i int) (string, bool) {
+	choices, ok := macroChoices[view]
+	if !ok {
+		return "", false
+	}
+	if list := choices(); i >= 0 && i < len(list) {
+		return list[i], true
+	}
+	return "", false
+}
+
+// choiceIndex returns the index of choice among the entries of the picker
+// view, or -1 if it does not list it.
+func choiceIndex(view, choice string) int {
+	choices, ok := macroChoices[view]
+	if !ok {
+		return -1
+	}
+	return slices.Index(choices(), choice)
+}
+
+// recording holds the steps of the macro being recorded, and replaying
+// is set while a macro is replayed. Both are only touched from the gocui
+// main loop.
+var (
+	recording struct {
+		on    bool
+		steps []MacroStep
+	}
+	replaying bool
+)
+
+// reservedMacroKeys lists the keys of macroKeys bound to actions, which
+// macros cannot use. It is set by keybindings.
+var reservedMacroKeys []string
+
+// recordAction has handler, bound to the action called name, add a step
+// to the macro being recorded.
+func recordAction(name string, handler func(*gocui.Gui, *gocui.View) error) func(*gocui.Gui, *gocui.View) error {
+	if unrecorded[name] {
+		return handler
+	}
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if recording.on {
+			step := MacroStep{Action: name}
+			if v != nil && v.Name() == "Sidebar" {
+				step.Target = selectedTarget(v)
+			}
+			recording.steps = append(recording.steps, step)
+		}
+		return handler(g, v)
+	}
+}
+
+// recordPrompt has handler, submitting the prompt view, add what was
+// typed or chosen in it to the macro being recorded.
+func recordPrompt(view string, handler func(*gocui.Gui, *gocui.View) error) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if recording.on {
+			step := MacroStep{Prompt: view}
+			if v.Editable {
+				step.Text = strings.TrimSpace(v.Buffer())
+			} else {
+				_, cy := v.Cursor()
+				_, oy := v.Origin()
+				step.Text, _ = choiceAt(view, cy+oy)
+			}
+			if sidebar, err := g.View("Sidebar"); err == nil {
+				step.Target = selectedTarget(sidebar)
+			}
+			recording.steps = append(recording.steps, step)
+		}
+		return handler(g, v)
+	}
+}
+
+// toggleRecording starts recording a macro, or stops and asks for the key
+// to save it on.
+func toggleRecording(g *gocui.Gui, v *gocui.View) error {
+	if replaying {
+		return showWarning(g, "macro: wait for the macro being replayed to finish")
+	}
+	if !recording.on {
+		recording.on, recording.steps = true, nil
+		return showNotice("recording a macro, Q again to stop")
+	}
+	recording.on = false
+	if len(recording.steps) == 0 {
+		return showNotice("macro: nothing recorded")
+	}
+	return openMacroPrompt(g)
+}
+
+// openMacroPrompt asks for the key to save the recorded macro on,
+// offering the first one without a macro.
+func openMacroPrompt(g *gocui.Gui) error {
+	free := macroKeys[0]
+	for _, key := range macroKeys {
+		if _, ok := project.Macros[key]; !ok && !slices.Contains(reservedMacroKeys, key) {
+			free = key
+			break
+		}
+	}
+	maxX, maxY := g.Size()
+	prompt, err := g.SetView("macro", maxX/6, maxY/2-1, maxX*5/6, maxY/2+1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	prompt.Title = fmt.Sprintf("Save the macro of %d steps on F1-F12 (Enter to save, Esc to drop it)", len(recording.steps))
+	prompt.Editable = true
+	prompt.Editor = gocui.DefaultEditor
+	prompt.Clear()
+	fmt.Fprint(prompt, strings.ToUpper(free))
+	if err := prompt.SetCursor(len(free), 0); err != nil {
+		return err
+	}
+	if _, err := g.SetViewOnTop("macro"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("macro")
+	return err
+}
+
+// saveMacro saves the recorded macro on the key typed in the prompt v, in
+// the project config.
+func saveMacro(g *gocui.Gui, v *gocui.View) error {
+	key := strings.ToLower(strings.TrimSpace(v.Buffer()))
+	if !slices.Contains(macroKeys, key) {
+		v.Title = fmt.Sprintf("%q is not one of F1-F12 (Enter to save, Esc to drop the macro)", strings.TrimSpace(v.Buffer()))
+		return nil
+	}
+	if slices.Contains(reservedMacroKeys, key) {
+		v.Title = fmt.Sprintf("%s runs an action (Enter to save, Esc to drop the macro)", strings.ToUpper(key))
+		return nil
+	}
+	if err := closeMacroPrompt(g, v); err != nil {
+		return err
+	}
+	if project.Macros == nil {
+		project.Macros = make(map[string][]MacroStep)
+	}
+	project.Macros[key] = recording.steps
+	recording.steps = nil
+	if err := saveProjectConfig(); err != nil {
+		return showWarning(g, fmt.Sprintf("macro: %v", err))
+	}
+	return showNotice(fmt.Sprintf("macro saved: %s replays %s", strings.ToUpper(key), macroSummary(project.Macros[key])))
+}
+
+func closeMacroPrompt(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("macro"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+// macroSummary describes steps on one line, e.g. `search → filter "proto"
+// → run gen-proto`.
+func macroSummary(steps []MacroStep) string {
+	parts := make([]string, len(steps))
+	for i, step := range steps {
+		switch {
+		case step.Prompt != "":
+			parts[i] = fmt.Sprintf("%s %q", step.Prompt, step.Text)
+		case step.Target != "":
+			parts[i] = step.Action + " " + step.Target
+		default:
+			parts[i] = step.Action
+		}
+	}
+	return strings.Join(parts, " → ")
+}
+
+// replayMacro returns the handler replaying the macro saved on key.
+func replayMacro(key string) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		steps, ok := project.Macros[key]
+		switch {
+		case !ok:
+			return showWarning(g, fmt.Sprintf("macro: none on %s, Q records one", strings.ToUpper(key)))
+		case recording.on:
+			return showWarning(g, "macro: stop recording first (Q)")
+		case replaying:
+			return showWarning(g, "macro: one is already being replayed")
+		}
+		replaying = true
+		return replayStep(g, key, steps, 0)
+	}
+}
+
+// replayStep replays the steps of the macro on key from i on. Each step
+// waits for the commands started before it to finish, and a command that
+// fails stops the macro.
+func replayStep(g *gocui.Gui, key string, steps []MacroStep, i int) error {
+	name := "macro " + strings.ToUpper(key)
+	if i == len(steps) {
+		replaying = false
+		return showNotice(name + " done")
+	}
+	before := runState.started
+	if err := runMacroStep(g, steps[i]); err != nil {
+		replaying = false
+		return showWarning(g, fmt.Sprintf("%s: step %d, %s: %v", name, i+1, macroSummary(steps[i:i+1]), err))
+	}
+	go func() {
+		defer recoverCrash()
+		for {
+			time.Sleep(macroStepDelay)
+			next := make(chan bool)
+			g.Update(func(g *gocui.Gui) error {
+				if runState.active > 0 {
+					next <- false
+					return nil
+				}
+				next <- true
+				if runState.started.After(before) && runState.lastCode != 0 {
+					replaying = false
+					return showWarning(g, fmt.Sprintf("%s stopped: %s exited with %d", name, runState.command, runState.lastCode))
+				}
+				return replayStep(g, key, steps, i+1)
+			})
+			if <-next {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// runMacroStep does what step recorded.
+func runMacroStep(g *gocui.Gui, step MacroStep) error {
+	sidebar, err := g.View("Sidebar")
+	if err != nil {
+		return err
+	}
+	if step.Prompt != "" {
+		submit, ok := macroPrompts[step.Prompt]
+		if !ok {
+			return fmt.Errorf("unknown prompt")
+		}
+		v, err := g.View(step.Prompt)
+		if err != nil {
+			return fmt.Errorf("the prompt is not open")
+		}
+		if v.Editable {
+			v.Clear()
+			fmt.Fprint(v, step.Text)
+			if step.Prompt == "filter" {
+				if err := refreshSidebar(g); err != nil {
+					return err
+				}
+			}
+		} else if i := choiceIndex(step.Prompt, step.Text); i >= 0 {
+			if err := selectLine(v, i); err != nil {
+				return err
+			}
+		} else {
+			return fmt.Errorf("no choice %q", step.Text)
+		}
+		if step.Prompt == "filter" && step.Target != "" {
+			if err := selectShown(sidebar, step.Target); err != nil {
+				return err
+			}
+		}
+		return submit(g, v)
+	}
+
+	i := slices.IndexFunc(actions, func(a action) bool { return a.name == step.Action })
+	if i < 0 {
+		return fmt.Errorf("unknown action")
+	}
+	a := actions[i]
+	if step.Target != "" {
+		if err := selectShown(sidebar, step.Target); err != nil {
+			return err
+		}
+	}
+	v := g.CurrentView()
+	if a.view != "" {
+		if v, err = g.SetCurrentView(a.view); err != nil {
+			return err
+		}
+	}
+	return a.handler(g, v)
+}
+
+// selectShown selects target in the Sidebar v, failing if it is not
+// listed.
+func selectShown(v *gocui.View, target string) error {
+	if err := selectTarget(v, target); err != nil {
+		return err
+	}
+	if selectedTarget(v) != target {
+		return fmt.Errorf("%s is not listed", target)
+	}
+	return nil
+}
+
+// macroStatus describes the recording in progress for the status bar, or
+// returns "".
+func macroStatus() string {
+	if !recording.on {
+		return ""
+	}
+	return failureText("● recording") + " macro, " + strconv.Itoa(len(recording.steps)) + " steps (Q stops)"
+}
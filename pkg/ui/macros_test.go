@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/jroimartin/gocui"
+)
+
+// TestRecordPromptChoice checks that the choice of a picker is recorded
+// from the entries it lists, not from the lines on screen: the view here
+// has none.
+func TestRecordPromptChoice(t *testing.T) {
+	defer func(choices []string) { presetChoices, recording.on, recording.steps = choices, false, nil }(presetChoices)
+	presetChoices = []string{presetNone, "-j4 V=1", presetCustom}
+	recording.on, recording.steps = true, nil
+
+	// The picker is scrolled down a line, with the cursor at its top.
+	v := &gocui.View{}
+	if err := v.SetOrigin(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	submitted := false
+	submit := recordPrompt("presets", func(*gocui.Gui, *gocui.View) error {
+		submitted = true
+		return nil
+	})
+	if err := submit(&gocui.Gui{}, v); err != nil {
+		t.Fatal(err)
+	}
+	if !submitted {
+		t.Error("the picker was not submitted")
+	}
+	if len(recording.steps) != 1 || recording.steps[0] != (MacroStep{Prompt: "presets", Text: "-j4 V=1"}) {
+		t.Fatalf("recorded %+v, want the choice -j4 V=1", recording.steps)
+	}
+	if i := choiceIndex("presets", recording.steps[0].Text); i != 1 {
+		t.Errorf("the recorded choice is replayed as entry %d, want 1", i)
+	}
+}
+
+func TestChoices(t *testing.T) {
+	defer func(choices []string) { presetChoices = choices }(presetChoices)
+	presetChoices = []string{presetNone, "-j4", presetCustom}
+	tests := []struct {
+		view   string
+		i      int
+		want   string
+		wantOK bool
+	}{
+		{view: "presets", i: 0, want: presetNone, wantOK: true},
+		{view: "presets", i: 2, want: presetCustom, wantOK: true},
+		{view: "presets", i: 3},
+		{view: "presets", i: -1},
+		{view: "hosts", i: 0},
+	}
+	for _, tt := range tests {
+		got, ok := choiceAt(tt.view, tt.i)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("choiceAt(%q, %d) = %q, %v, want %q, %v", tt.view, tt.i, got, ok, tt.want, tt.wantOK)
+		}
+	}
+	if i := choiceIndex("presets", "-j8"); i != -1 {
+		t.Errorf("choiceIndex of a choice not listed = %d, want -1", i)
+	}
+}
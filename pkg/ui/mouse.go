@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// doubleClickDelay is how quickly a second click on the same line must
+// follow the first to count as a double click.
+const doubleClickDelay = 400 * time.Millisecond
+
+var lastClick struct {
+	view string
+	line int
+	at   time.Time
+}
+
+// click returns a left click handler that focuses the clicked view and
+// selects the clicked line. A double click on a line calls open, if not
+// nil.
+func click(open func(*gocui.Gui, *gocui.View) error) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if _, err := g.SetCurrentView(v.Name()); err != nil {
+			return err
+		}
+		// gocui has moved the cursor to the click; keep it on a line
+		// with content.
+		_, cy := v.Cursor()
+		_, oy := v.Origin()
+		if last := lineCount(v) - 1 - oy; cy > last {
+			cy = max(last, 0)
+		}
+		if err := v.SetCursor(0, cy); err != nil {
+			return err
+		}
+
+		line := cy + oy
+		double := lastClick.view == v.Name() && lastClick.line == line &&
+			time.Since(lastClick.at) < doubleClickDelay
+		lastClick.view, lastClick.line, lastClick.at = v.Name(), line, time.Now()
+		if double && open != nil {
+			lastClick.view = ""
+			return open(g, v)
+		}
+		return nil
+	}
+}
+
+// lineCount returns the number of lines written to v, not counting the
+// empty line after a trailing newline.
+func lineCount(v *gocui.View) int {
+	lines := v.BufferLines()
+	if n := len(lines); n > 0 && lines[n-1] == "" {
+		return n - 1
+	}
+	return len(lines)
+}
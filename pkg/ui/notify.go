@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// defaultNotifyAfter is how long a run must take before its completion is
+// notified, unless set in the config.
+const defaultNotifyAfter = 30 * time.Second
+
+// notifyFinished sends a desktop notification for a run of target that
+// took longer than the configured threshold.
+func notifyFinished(target string, code int, started time.Time) {
+	threshold := defaultNotifyAfter
+	if config.NotifyAfter != nil {
+		threshold = *config.NotifyAfter
+	}
+	elapsed := time.Since(started)
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+	status := "succeeded"
+	if code != 0 {
+		status = fmt.Sprintf("failed (exit code %d)", code)
+	}
+	notify("imake: "+target, fmt.Sprintf("%s %s after %s", target, status, elapsed.Round(time.Second)))
+}
+
+// notify shows a desktop notification with notify-send or osascript,
+// ringing the terminal bell when neither is available.
+func notify(title, message string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		cmd = exec.Command("osascript", "-e", script)
+	default:
+		if _, err := exec.LookPath("notify-send"); err == nil {
+			cmd = exec.Command("notify-send", title, message)
+		}
+	}
+	if cmd == nil || cmd.Start() != nil {
+		os.Stdout.WriteString("\a")
+		return
+	}
+	go cmd.Wait()
+}
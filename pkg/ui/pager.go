@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// pagerCommand returns the pager configured in $PAGER, split into words
+// like the editor, or less -R (more on Windows).
+func pagerCommand() []string {
+	if words := strings.Fields(os.Getenv("PAGER")); len(words) > 0 {
+		return words
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"more"}
+	}
+	return []string{"less", "-R"}
+}
+
+// pageOutput suspends the UI and shows the whole output kept of the active
+// tab in the pager, resuming once the pager exits.
+func pageOutput(g *gocui.Gui, v *gocui.View) error {
+	if activeTab < 0 {
+		return showWarning(g, "pager: no output to show")
+	}
+	t := tabs[activeTab]
+	args := pagerCommand()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(t.buf.String())
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		// Like git, let less pass the colors of the output through.
+		cmd.Env = append(os.Environ(), "LESS=R")
+	}
+	runErr, err := runInTerminal(cmd)
+	if err != nil {
+		return err
+	}
+	if runErr != nil {
+		return showWarning(g, fmt.Sprintf("pager: %v", runErr))
+	}
+	if t.buf.dropped > 0 {
+		return showNotice(fmt.Sprintf("the pager showed the last %d lines of %s; %d earlier lines were dropped", t.buf.Lines(), t.name, t.buf.dropped))
+	}
+	return nil
+}
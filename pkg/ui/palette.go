@@ -0,0 +1,260 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/jroimartin/gocui"
+)
+
+// paletteEntry is a command offered by the command palette.
+type paletteEntry struct {
+	label string
+	keys  string // keys of the matching action, shown as a hint
+	run   func(g *gocui.Gui) error
+}
+
+// paletteActions are the actions listed in the palette, with their labels.
+var paletteActions = []struct{ name, label string }{
+	{"run_with_args", "Run selected target with arguments"},
+	{"dry_run", "Dry run selected target"},
+	{"make_flags", "Set make flags (-j8, -k, ...)"},
+	{"run_marked", "Run marked targets in parallel"},
+	{"run_queue", "Run marked targets one after another"},
+	{"close_runs", "Close parallel runs and the finished queue"},
+	{"cancel", "Cancel running commands"},
+	{"reload", "Reload targets"},
+	{"search", "Filter targets"},
+	{"favorite", "Pin/unpin selected target"},
+	{"toggle_hidden", "Show/hide file, pattern, internal and ignored targets"},
+	{"badge_filter", "Show only the targets with the next badge, e.g. @test"},
+	{"sort", "Sort the targets by name, last run or run count, or as in the file"},
+	{"rerun", "Re-run the last target"},
+	{"copy_output", "Copy the output to the clipboard"},
+	{"copy_report", "Copy a Markdown report of the run to the clipboard"},
+	{"fold_sections", "Fold the output of recursive makes that passed"},
+	{"bookmark", "Bookmark the top line of the output"},
+	{"next_bookmark", "Jump to the next bookmark in the output"},
+	{"prev_bookmark", "Jump to the previous bookmark in the output"},
+	{"history", "Show run history"},
+	{"run_sudo", "Run the target under sudo"},
+	{"detach", "Run the target detached, in tmux or the background"},
+	{"jobs", "List the detached targets"},
+	{"stats", "Show run statistics of the targets"},
+	{"variables", "Show the variables of the Makefile"},
+	{"graph", "Show dependency graph"},
+	{"recipe", "Show recipe of selected target"},
+	{"problems", "Show problems of the last failed run"},
+	{"diagnostics", "Show problems of the build files"},
+	{"export_help", "Export the documentation of the targets"},
+	{"projects", "Switch project"},
+	{"recent_projects", "Switch to a recent project"},
+	{"hosts", "Switch the host commands run on"},
+	{"next_tab", "Next output tab"},
+	{"close_tab", "Close output tab"},
+	{"compare", "Compare two runs in a split"},
+	{"diff_previous", "Diff the output with the previous run of the target"},
+	{"shell", "Run a shell command in a tab"},
+	{"record_macro", "Start/stop recording a macro, replayed with F1-F12"},
+	{"toggle_log", "Turn run logs on/off"},
+	{"dotenv", "Turn loading .env files on/off"},
+	{"timestamps", "Turn output timestamps on/off"},
+	{"page_output", "Show the whole output in $PAGER"},
+	{"dump_output", "Write the output of the active tab to a file"},
+	{"save_report", "Write a Markdown report of the run to a file"},
+	{"quit", "Quit"},
+}
+
+// paletteItems are the entries of the open palette and paletteShown the
+// ones matching its query, in display order.
+var paletteItems, paletteShown []paletteEntry
+
+// paletteEntries lists everything the palette can do right now.
+func paletteEntries() []paletteEntry {
+	var entries []paletteEntry
+	for _, name := range targetNames {
+		target := name
+		entries = append(entries, paletteEntry{
+			label: "Run " + target,
+			run:   func(g *gocui.Gui) error { return runTarget(g, target, nil) },
+		})
+	}
+	for _, pa := range paletteActions {
+		for _, a := range actions {
+			if a.name != pa.name {
+				continue
+			}
+			handler := a.handler
+			entries = append(entries, paletteEntry{
+				label: pa.label,
+				keys:  strings.Join(actionKeys(a), "/"),
+				run: func(g *gocui.Gui) error {
+					sidebar, err := g.View("Sidebar")
+					if err != nil {
+						return err
+					}
+					return handler(g, sidebar)
+				},
+			})
+		}
+	}
+	for _, key := range macroKeys {
+		if steps, ok := project.Macros[key]; ok {
+			entries = append(entries, paletteEntry{
+				label: "Replay macro: " + macroSummary(steps),
+				keys:  key,
+				run: func(g *gocui.Gui) error {
+					return replayMacro(key)(g, nil)
+				},
+			})
+		}
+	}
+	entries = append(entries,
+		paletteEntry{label: "Turn watching on/off", run: toggleWatch},
+		paletteEntry{label: "Open logs", run: openLogs},
+	)
+	for _, name := range runner.Available() {
+		if name == backend.Name() {
+			continue
+		}
+		n := name
+		entries = append(entries, paletteEntry{
+			label: "Switch backend to " + n,
+			run: func(g *gocui.Gui) error {
+				r, err := runner.Detect("", n)
+				if err != nil {
+					return showWarning(g, err.Error())
+				}
+				return useBackend(g, r)
+			},
+		})
+	}
+	return entries
+}
+
+// paletteEditor narrows the palette list on every keystroke.
+type paletteEditor struct {
+	g *gocui.Gui
+}
+
+func (e *paletteEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	gocui.DefaultEditor.Edit(v, key, ch, mod)
+	renderPalette(e.g, strings.TrimSpace(v.Buffer()))
+}
+
+// renderPalette lists the entries matching query.
+func renderPalette(g *gocui.Gui, query string) error {
+	list, err := g.View("paletteList")
+	if err != nil {
+		return err
+	}
+	labels := make([]string, len(paletteItems))
+	byLabel := make(map[string]paletteEntry)
+	for i, e := range paletteItems {
+		labels[i] = e.label
+		byLabel[e.label] = e
+	}
+	paletteShown = paletteShown[:0]
+	for _, label := range fuzzyFilter(query, labels) {
+		paletteShown = append(paletteShown, byLabel[label])
+	}
+
+	list.Clear()
+	for _, e := range paletteShown {
+		if e.keys != "" {
+			fmt.Fprintf(list, "%s \x1b[38;5;8m(%s)\x1b[0m\n", e.label, e.keys)
+		} else {
+			fmt.Fprintln(list, e.label)
+		}
+	}
+	if err := list.SetOrigin(0, 0); err != nil {
+		return err
+	}
+	return list.SetCursor(0, 0)
+}
+
+// openPalette shows the command palette in the middle of the screen.
+func openPalette(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("palette"); err == nil {
+		return closePalette(g, v)
+	}
+	maxX, maxY := g.Size()
+	width, height := maxX*3/5, maxY/2
+	x0, y0 := (maxX-width)/2, (maxY-height)/2
+
+	input, err := g.SetView("palette", x0, y0, x0+width, y0+2)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	input.Title = "Command palette (Enter to run, Esc to close)"
+	input.Editable = true
+	input.Editor = &paletteEditor{g: g}
+	list, err := g.SetView("paletteList", x0, y0+2, x0+width, y0+height)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	list.Highlight = true
+
+	paletteItems = paletteEntries()
+	if err := renderPalette(g, ""); err != nil {
+		return err
+	}
+	if _, err := g.SetViewOnTop("paletteList"); err != nil {
+		return err
+	}
+	if _, err := g.SetViewOnTop("palette"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("palette")
+	return err
+}
+
+func closePalette(g *gocui.Gui, v *gocui.View) error {
+	for _, name := range []string{"palette", "paletteList"} {
+		if err := g.DeleteView(name); err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+			return err
+		}
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+func paletteCursorDown(g *gocui.Gui, v *gocui.View) error {
+	list, err := g.View("paletteList")
+	if err != nil {
+		return err
+	}
+	_, cy := list.Cursor()
+	_, oy := list.Origin()
+	if cy+oy+1 >= len(paletteShown) {
+		return nil
+	}
+	return cursorDown(g, list)
+}
+
+func paletteCursorUp(g *gocui.Gui, v *gocui.View) error {
+	list, err := g.View("paletteList")
+	if err != nil {
+		return err
+	}
+	return cursorUp(g, list)
+}
+
+// runPaletteEntry closes the palette and runs the highlighted entry.
+func runPaletteEntry(g *gocui.Gui, v *gocui.View) error {
+	list, err := g.View("paletteList")
+	if err != nil {
+		return err
+	}
+	_, cy := list.Cursor()
+	_, oy := list.Origin()
+	if err := closePalette(g, v); err != nil {
+		return err
+	}
+	if i := cy + oy; i < len(paletteShown) {
+		return paletteShown[i].run(g)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/jroimartin/gocui"
+	"gopkg.in/yaml.v3"
+)
+
+// LayoutConfig holds the pane sizes, saved to the config whenever they are
+// changed from the keyboard.
+type LayoutConfig struct {
+	// SidebarWidth is the width of the Sidebar in twelfths of the screen.
+	SidebarWidth int `yaml:"sidebar_width,omitempty"`
+	// HideHelp collapses the help and hints panes at the bottom.
+	HideHelp bool `yaml:"hide_help,omitempty"`
+}
+
+const defaultSidebarWidth = 3
+
+// zoomed shows the Command Output over the whole screen. Unlike the pane
+// sizes it only lasts for the session.
+var zoomed bool
+
+// currentLayout returns the arrangement of the main views for the pane
+// sizes in the config.
+func currentLayout() Layout {
+	width := config.Layout.SidebarWidth
+	if width < 1 || width > 11 {
+		width = defaultSidebarWidth
+	}
+	if zoomed {
+		return Layout{
+			{"Sidebar", width, 12, 0, 0},
+			{"command", 12, 12, 0, 0},
+		}
+	}
+	if config.Layout.HideHelp {
+		return Layout{
+			{"Sidebar", width, 12, 0, 0},
+			{"command", 12 - width, 12, width, 0},
+		}
+	}
+	return Layout{
+		{"Sidebar", width, 10, 0, 0},
+		{"command", 12 - width, 10, width, 0},
+		{"help", width, 2, 0, 10},
+		{"hints", 12 - width, 2, width, 10},
+	}
+}
+
+// resizeSidebar returns a handler changing the Sidebar width by delta grid
+// units.
+func resizeSidebar(delta int) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		width := config.Layout.SidebarWidth
+		if width == 0 {
+			width = defaultSidebarWidth
+		}
+		width += delta
+		if width < 1 || width > 11 {
+			return nil
+		}
+		config.Layout.SidebarWidth = width
+		return saveLayout(g)
+	}
+}
+
+// toggleHelpPane collapses or restores the help and hints panes.
+func toggleHelpPane(g *gocui.Gui, v *gocui.View) error {
+	config.Layout.HideHelp = !config.Layout.HideHelp
+	if config.Layout.HideHelp {
+		for _, name := range []string{"help", "hints"} {
+			if err := g.DeleteView(name); err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+				return err
+			}
+		}
+	}
+	return saveLayout(g)
+}
+
+// toggleZoom shows the Command Output over the whole screen, or restores
+// the panes.
+func toggleZoom(g *gocui.Gui, v *gocui.View) error {
+	zoomed = !zoomed
+	if !zoomed {
+		return nil
+	}
+	if _, err := g.SetViewOnTop("command"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("command")
+	return err
+}
+
+// saveLayout writes the pane sizes into the config file, keeping the rest
+// of the file and its comments as they are.
+func saveLayout(g *gocui.Gui) error {
+	path, err := configPath()
+	if err != nil {
+		return showWarning(g, "layout: "+err.Error())
+	}
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return showWarning(g, "layout: "+err.Error())
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return showWarning(g, "layout: "+err.Error())
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return showWarning(g, "layout: "+path+" is not a mapping")
+	}
+	var value yaml.Node
+	if err := value.Encode(config.Layout); err != nil {
+		return err
+	}
+	replaced := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "layout" {
+			root.Content[i+1] = &value
+			replaced = true
+		}
+	}
+	if !replaced {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "layout"}, &value)
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return showWarning(g, "layout: "+err.Error())
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+		return showWarning(g, "layout: "+err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// runPane is one split of the output area showing a target of a parallel
+// run.
+type runPane struct {
+	view   string
+	target string
+	status string
+}
+
+// runPanes are the splits of the current parallel run, stacked on top of
+// the Command Output view. It is empty when no parallel run is shown.
+var runPanes []*runPane
+
+func (p *runPane) title() string {
+	return fmt.Sprintf("%s %s", p.target, p.status)
+}
+
+// layoutRunPanes splits the Command Output area between the run panes.
+// It is called by the manager so the panes follow terminal resizes.
+func layoutRunPanes(g *gocui.Gui) error {
+	if len(runPanes) == 0 {
+		return nil
+	}
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	height := float64(y1-y0+1) / float64(len(runPanes))
+	for i, p := range runPanes {
+		top := y0 + int(float64(i)*height)
+		bottom := y0 + int(float64(i+1)*height) - 1
+		if bottom <= top {
+			bottom = top + 1
+		}
+		v, err := g.SetView(p.view, x0, top, x1, bottom)
+		if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+			return err
+		}
+		v.Title = p.title()
+		v.Autoscroll = true
+		if _, err := g.SetViewOnTop(p.view); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runMarked runs every marked target concurrently, each in its own split
+// of the output area.
+func runMarked(g *gocui.Gui, v *gocui.View) error {
+	targets := append([]string(nil), marked...)
+	if len(targets) == 0 {
+		return nil
+	}
+	for _, target := range targets {
+		if runs.Busy(target) {
+			return showWarning(g, target+" is still running")
+		}
+	}
+
+	if err := closeRunPanes(g); err != nil {
+		return err
+	}
+	for i, target := range targets {
+		runPanes = append(runPanes, &runPane{
+			view:   fmt.Sprintf("run-%d", i),
+			target: target,
+			status: "▶ running",
+		})
+	}
+	if err := layoutRunPanes(g); err != nil {
+		return err
+	}
+	for _, p := range runPanes {
+		pane := p
+		view, err := g.View(pane.view)
+		if err != nil {
+			return err
+		}
+		if err := g.SetKeybinding(pane.view, gocui.MouseLeft, gocui.ModNone, click(nil)); err != nil {
+			return err
+		}
+		if err := setResult(g, pane.target, runResult{running: true}); err != nil {
+			return err
+		}
+		out, closeLog := teeLog(view, pane.target)
+		_, err = startCommand(g, pane.target, out, backend.Exec(pane.target, nil), func(g *gocui.Gui, code int, started time.Time) {
+			closeLog()
+			setResult(g, pane.target, runResult{code: code})
+			recordRun(view, pane.target, nil, code, started)
+			notifyFinished(pane.target, code, started)
+			if code == 0 {
+				pane.status = "✓ success"
+			} else {
+				pane.status = fmt.Sprintf("✗ failed (%d)", code)
+			}
+			if err := reloadAfterRun(g, started); err != nil {
+				showWarning(g, fmt.Sprintf("reload: %v", err))
+			}
+		})
+		if err != nil {
+			closeLog()
+			return err
+		}
+	}
+	return nil
+}
+
+// closeRunPanes removes the splits of the last parallel run, revealing the
+// Command Output view again. Commands still running keep running.
+func closeRunPanes(g *gocui.Gui) error {
+	for _, p := range runPanes {
+		if err := g.DeleteView(p.view); err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+			return err
+		}
+		g.DeleteKeybindings(p.view)
+	}
+	runPanes = nil
+	return nil
+}
+
+// closeRunPanesHandler closes the splits of the last parallel run and the
+// strip of a finished queue.
+func closeRunPanesHandler(g *gocui.Gui, v *gocui.View) error {
+	if err := closeRunPanes(g); err != nil {
+		return err
+	}
+	return closeQueue(g)
+}
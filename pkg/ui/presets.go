@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// presetChoices are the lines of the open preset picker: running without
+// arguments, each preset of the target, and typing custom arguments.
+var presetChoices []string
+
+const (
+	presetNone   = "(no arguments)"
+	presetCustom = "Custom arguments..."
+)
+
+// presetTarget is the target the open preset picker runs.
+var presetTarget string
+
+// openPresets shows the argument presets of target from .imake.yaml.
+func openPresets(g *gocui.Gui, target string) error {
+	presetTarget = target
+	presetChoices = append([]string{presetNone}, project.Presets[target]...)
+	presetChoices = append(presetChoices, presetCustom)
+
+	maxX, maxY := g.Size()
+	height := len(presetChoices) + 1
+	pv, err := g.SetView("presets", maxX/6, maxY/2-height/2-1, maxX*5/6, maxY/2+height-height/2)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	pv.Title = "$ " + commandLine(target, nil) + " (Enter to run, Esc to close)"
+	pv.Highlight = true
+	pv.Clear()
+	for _, choice := range presetChoices {
+		fmt.Fprintln(pv, choice)
+	}
+	if err := pv.SetCursor(0, 0); err != nil {
+		return err
+	}
+	if _, err := g.SetViewOnTop("presets"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("presets")
+	return err
+}
+
+func closePresets(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("presets"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+// runPreset runs the target of the picker with the selected preset.
+func runPreset(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(presetChoices) {
+		return nil
+	}
+	choice := presetChoices[cy+oy]
+	if err := closePresets(g, v); err != nil {
+		return err
+	}
+	switch choice {
+	case presetNone:
+		return runTarget(g, presetTarget, nil)
+	case presetCustom:
+		sidebar, err := g.View("Sidebar")
+		if err != nil {
+			return err
+		}
+		return openArgsPrompt(g, sidebar)
+	}
+	return runTarget(g, presetTarget, strings.Fields(choice))
+}
+
+func presetCursorDown(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy+1 >= len(presetChoices) {
+		return nil
+	}
+	return cursorDown(g, v)
+}
@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// problem is an error or warning found in the output of a failed run.
+type problem struct {
+	file      string // absolute path
+	line, col int    // 1-based, 0 if unknown
+	message   string
+}
+
+var (
+	// locationRegexp matches "file:line[:col]: message", as printed by the
+	// Go toolchain, gcc, clang, rustc's short format, mypy and pytest's
+	// tracebacks.
+	locationRegexp = regexp.MustCompile(`^\s*([^\s:]+\.\w+):(\d+)(?::(\d+))?:\s*(.*)$`)
+	// pytestRegexp matches the summary of a failed pytest test.
+	pytestRegexp = regexp.MustCompile(`^(?:FAILED|ERROR) ([^\s:]+\.py)::(\S+)(?: - (.*))?$`)
+	// eslintFileRegexp and eslintRegexp match eslint's default output: the
+	// file on a line of its own, followed by "line:col  severity  message".
+	eslintFileRegexp = regexp.MustCompile(`^(\S+\.(?:js|jsx|mjs|cjs|ts|tsx|vue))$`)
+	eslintRegexp     = regexp.MustCompile(`^\s+(\d+):(\d+)\s+(error|warning)\s+(.*)$`)
+	// timestampRegexp matches the prefix added to lines by timestamps.
+	timestampRegexp = regexp.MustCompile(`^\s*\d+\.\d{3}s `)
+)
+
+// findProblems extracts the problems from output, resolving files relative
+// to dir. Only files that exist are kept, which weeds out lines that merely
+// look like locations.
+func findProblems(output, dir string) []problem {
+	var problems []problem
+	seen := make(map[problem]bool)
+	add := func(file string, line, col int, message string) {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		p := problem{file, line, col, strings.TrimSpace(message)}
+		if seen[p] || !fileExists(p.file) {
+			return
+		}
+		seen[p] = true
+		problems = append(problems, p)
+	}
+
+	eslintFile := ""
+	for _, line := range strings.Split(filterANSI(output, ANSIStrip), "\n") {
+		line = timestampRegexp.ReplaceAllString(strings.TrimRight(line, "\r"), "")
+		if m := eslintFileRegexp.FindStringSubmatch(line); m != nil {
+			eslintFile = m[1]
+			continue
+		}
+		if m := eslintRegexp.FindStringSubmatch(line); m != nil && eslintFile != "" {
+			n, _ := strconv.Atoi(m[1])
+			col, _ := strconv.Atoi(m[2])
+			add(eslintFile, n, col, m[3]+": "+m[4])
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			eslintFile = ""
+		}
+		if m := pytestRegexp.FindStringSubmatch(line); m != nil {
+			message := m[2]
+			if m[3] != "" {
+				message += ": " + m[3]
+			}
+			add(m[1], 0, 0, message)
+			continue
+		}
+		if m := locationRegexp.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			col, _ := strconv.Atoi(m[3])
+			add(m[1], n, col, m[4])
+		}
+	}
+	return problems
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// formatProblem renders p as a line of the Problems panel, with its file
+// relative to the working directory.
+func formatProblem(p problem) string {
+	file := p.file
+	if dir, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(dir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	location := file
+	if p.line > 0 {
+		location += ":" + strconv.Itoa(p.line)
+	}
+	if p.col > 0 {
+		location += ":" + strconv.Itoa(p.col)
+	}
+	return fmt.Sprintf("\x1b[36m%s\x1b[0m  %s", location, p.message)
+}
+
+// reportProblems looks for problems in the output of tab after it failed,
+// opening the Problems panel if there are any.
+func reportProblems(g *gocui.Gui, tab *outputTab) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	tab.problems = findProblems(tab.buf.String(), dir)
+	if len(tab.problems) == 0 {
+		return nil
+	}
+	fmt.Fprintf(tab, "\x1b[33m%d problem(s) found\x1b[0m\n", len(tab.problems))
+	// Leave prompts and other overlays alone.
+	v := g.CurrentView()
+	if v == nil || v.Name() != "Sidebar" || tabs[activeTab] != tab {
+		return nil
+	}
+	return toggleProblems(g, v)
+}
+
+// toggleProblems opens or closes the Problems panel listing the problems
+// of the active tab on top of the Command Output view.
+func toggleProblems(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("problems"); err == nil {
+		return closeProblems(g, v)
+	}
+	if activeTab < 0 {
+		return nil
+	}
+	tab := tabs[activeTab]
+
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	pv, err := g.SetView("problems", x0, y0, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	pv.Title = fmt.Sprintf("Problems of %s (Enter to edit, Esc to close)", tab.name)
+	pv.Highlight = true
+	pv.Clear()
+	if len(tab.problems) == 0 {
+		fmt.Fprintln(pv, "\x1b[38;5;8m(no problems)\x1b[0m")
+	}
+	for _, p := range tab.problems {
+		fmt.Fprintln(pv, formatProblem(p))
+	}
+	if _, err := g.SetViewOnTop("problems"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("problems")
+	return err
+}
+
+func closeProblems(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("problems"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+// editProblem opens the selected problem in the editor. The panel stays
+// open to go through the others.
+func editProblem(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if activeTab < 0 || cy+oy >= len(tabs[activeTab].problems) {
+		return nil
+	}
+	p := tabs[activeTab].problems[cy+oy]
+	return runEditor(g, p.file, p.line)
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// progressPatterns match the progress indicators of common build tools in
+// a line of output. Each has either a percentage or a step and a total.
+var progressPatterns = []*regexp.Regexp{
+	// CMake's generated Makefiles: "[ 45%] Building CXX object ...".
+	regexp.MustCompile(`^\[\s*(?P<percent>\d{1,3})%\]`),
+	// Ninja and BuildKit: "[12/345] ..." and "#8 [ 3/12] RUN ...".
+	regexp.MustCompile(`^(?:#\d+ )?\[\s*(?P<step>\d+)/(?P<total>\d+)\]`),
+	// The classic Docker builder: "Step 3/12 : RUN ...".
+	regexp.MustCompile(`^Step (?P<step>\d+)/(?P<total>\d+) :`),
+	// Cargo: "    Building [=====>     ] 45/120: serde, ...".
+	regexp.MustCompile(`^\s*Building \[[=> ]*\] (?P<step>\d+)/(?P<total>\d+)`),
+}
+
+// parseProgress returns how far along the output of a build is from line,
+// between 0 and 1, if it shows progress.
+func parseProgress(line string) (float64, bool) {
+	for _, re := range progressPatterns {
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if i := re.SubexpIndex("percent"); i >= 0 {
+			percent, _ := strconv.Atoi(m[i])
+			return min(float64(percent), 100) / 100, true
+		}
+		step, _ := strconv.Atoi(m[re.SubexpIndex("step")])
+		total, _ := strconv.Atoi(m[re.SubexpIndex("total")])
+		if total == 0 || step > total {
+			return 0, false
+		}
+		return float64(step) / float64(total), true
+	}
+	return 0, false
+}
+
+// progressBar draws fraction as a bar width characters wide followed by
+// the percentage, e.g. "██████░░░░ 60%".
+func progressBar(fraction float64, width int) string {
+	filled := int(fraction * float64(width))
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + " " + strconv.Itoa(int(fraction*100)) + "%"
+}
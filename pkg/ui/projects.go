@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/jroimartin/gocui"
+)
+
+// projectRoot is the directory imake was started in. The project picker
+// lists the build files found below it.
+var projectRoot string
+
+// projects lists the directories shown in the open project picker.
+var projects []string
+
+// toggleProjects opens or closes the project picker on top of the
+// Command Output view.
+func toggleProjects(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("projects"); err == nil {
+		return closeProjects(g, v)
+	}
+
+	found, err := runner.FindProjects(projectRoot)
+	if err != nil {
+		return showWarning(g, fmt.Sprintf("projects: %v", err))
+	}
+	projects = found
+
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	pv, err := g.SetView("projects", x0, y0, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	pv.Title = "Projects (Enter to switch, Esc to close)"
+	pv.Highlight = true
+	pv.Clear()
+	current, _ := os.Getwd()
+	for i, dir := range projects {
+		if filepath.Join(projectRoot, dir) == current {
+			fmt.Fprintf(pv, "%s \x1b[33m*\x1b[0m\n", dir)
+			if err := selectLine(pv, i); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintln(pv, dir)
+	}
+	if _, err := g.SetViewOnTop("projects"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("projects")
+	return err
+}
+
+func closeProjects(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("projects"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+// switchProject loads the build file of the selected project. imake
+// changes into its directory so commands run there.
+func switchProject(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(projects) {
+		return nil
+	}
+	dir := filepath.Join(projectRoot, projects[cy+oy])
+	if err := closeProjects(g, v); err != nil {
+		return err
+	}
+	return openProject(g, dir)
+}
+
+// openProject changes into dir and loads the build file found there.
+func openProject(g *gocui.Gui, dir string) error {
+	if err := os.Chdir(dir); err != nil {
+		return showWarning(g, fmt.Sprintf("projects: %v", err))
+	}
+	r, err := runner.Detect("", "")
+	if err != nil {
+		return showWarning(g, fmt.Sprintf("projects: %v", err))
+	}
+	addRecent(dir)
+	if err := loadProjectConfig(); err != nil {
+		if err := showWarning(g, err.Error()); err != nil {
+			return err
+		}
+	}
+	return useBackend(g, r)
+}
+
+// useBackend makes r the runner of the Sidebar and loads its targets. r
+// must read its build file from the current directory.
+func useBackend(g *gocui.Gui, r runner.Runner) error {
+	backend = r
+	applyMakeFlags()
+	sidebar, err := g.View("Sidebar")
+	if err != nil {
+		return err
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	setSidebarTitle(sidebar, displayPath(dir, backend.File()))
+	// The targets of another build file are not compared with these.
+	targets = nil
+	return reloadTargets(g)
+}
+
+// displayPath returns file, which is relative to dir, relative to the
+// project root instead.
+func displayPath(dir, file string) string {
+	if rel, err := filepath.Rel(projectRoot, filepath.Join(dir, file)); err == nil {
+		return rel
+	}
+	return file
+}
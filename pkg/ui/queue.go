@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// queueSkipped is the status of the steps left out after a failure.
+const queueSkipped = "–"
+
+// queueStep is a target of the queue and the status of its run.
+type queueStep struct {
+	target string
+	status string // "" while pending, else a tab status icon or queueSkipped
+}
+
+// queue lists the steps of the last queue, shown in a strip above the
+// Command Output view; queueNext is the index of the step running or due
+// next. Both are only touched from the gocui main loop.
+var (
+	queue     []*queueStep
+	queueNext int
+)
+
+// queueRunning reports whether the queue has steps left to run.
+func queueRunning() bool {
+	return queueNext < len(queue)
+}
+
+// layoutQueue places the queue strip at the top of the Command Output
+// area and moves the Command Output view below it. It is called by the
+// manager so the strip follows terminal resizes.
+func layoutQueue(g *gocui.Gui) error {
+	if len(queue) == 0 {
+		return nil
+	}
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	if y1-y0 < 6 {
+		// Too small to share; the tab icons still show the progress.
+		return nil
+	}
+	v, err := g.SetView("queue", x0, y0, x1, y0+2)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	if _, err := g.SetView("command", x0, y0+3, x1, y1); err != nil {
+		return err
+	}
+	v.Title = "Queue"
+	v.Clear()
+	fmt.Fprint(v, queueStrip())
+	return nil
+}
+
+// queueStrip renders the steps of the queue with their status.
+func queueStrip() string {
+	parts := make([]string, len(queue))
+	for i, step := range queue {
+		switch step.status {
+		case "":
+			parts[i] = step.target
+		case tabRunning:
+			parts[i] = runningText(step.status + " " + step.target)
+		case tabSuccess:
+			parts[i] = successText(step.status + " " + step.target)
+		case tabFailed:
+			parts[i] = failureText(step.status + " " + step.target)
+		default:
+			parts[i] = fmt.Sprintf("\x1b[38;5;8m%s %s\x1b[0m", step.status, step.target)
+		}
+	}
+	return " " + strings.Join(parts, " → ")
+}
+
+// runQueue runs the marked targets one after another, in the order they
+// were marked, each in its own tab.
+func runQueue(g *gocui.Gui, v *gocui.View) error {
+	if queueRunning() {
+		return showWarning(g, "queue: already running")
+	}
+	if len(marked) == 0 {
+		return nil
+	}
+	if err := closeRunPanes(g); err != nil {
+		return err
+	}
+	queue = nil
+	for _, name := range marked {
+		queue = append(queue, &queueStep{target: name})
+	}
+	queueNext = 0
+	return runQueueStep(g)
+}
+
+// runQueueStep runs the next step of the queue. A failed step stops the
+// queue unless keep_going is set in the config; a cancelled one always
+// does, as does a step whose target is already running.
+func runQueueStep(g *gocui.Gui) error {
+	if !queueRunning() {
+		return nil
+	}
+	step := queue[queueNext]
+	if runs.Busy(step.target) {
+		for _, s := range queue[queueNext:] {
+			s.status = queueSkipped
+		}
+		queueNext = len(queue)
+		return showWarning(g, "queue: "+step.target+" is still running")
+	}
+	step.status = tabRunning
+	return startTarget(g, step.target, nil, func(g *gocui.Gui, tab *outputTab, code int) {
+		step.status = tab.status
+		queueNext++
+		if tab.status == tabCancelled || code != 0 && !config.KeepGoing {
+			for _, s := range queue[queueNext:] {
+				s.status = queueSkipped
+			}
+			queueNext = len(queue)
+			return
+		}
+		if err := runQueueStep(g); err != nil {
+			showWarning(g, fmt.Sprintf("queue: %v", err))
+		}
+	})
+}
+
+// closeQueue removes the strip of a finished queue.
+func closeQueue(g *gocui.Gui) error {
+	if queueRunning() {
+		return nil
+	}
+	queue = nil
+	if err := g.DeleteView("queue"); err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	return nil
+}
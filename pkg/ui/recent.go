@@ -0,0 +1,188 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// maxRecent is how many recent projects are remembered.
+const maxRecent = 20
+
+// recentProjects lists the directories imake was used in, most recent
+// first, across all projects.
+var recentProjects []string
+
+// recentPath returns the location of the recent projects file, next to
+// the run history.
+func recentPath() (string, error) {
+	path, err := historyPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "recent.json"), nil
+}
+
+func loadRecent() error {
+	path, err := recentPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &recentProjects)
+}
+
+func saveRecent() error {
+	path, err := recentPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(recentProjects, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// addRecent moves dir to the front of the recent projects and persists
+// them.
+func addRecent(dir string) {
+	list := []string{dir}
+	for _, d := range recentProjects {
+		if d != dir && len(list) < maxRecent {
+			list = append(list, d)
+		}
+	}
+	recentProjects = list
+	if err := saveRecent(); err != nil {
+		log.Printf("imake: could not save recent projects: %v", err)
+	}
+}
+
+// recentLabel shortens dir for the switcher, replacing the home directory
+// with ~.
+func recentLabel(dir string) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+	}
+	return dir
+}
+
+// toggleRecent opens or closes the switcher of recent projects on top of
+// the Command Output view.
+func toggleRecent(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("recent"); err == nil {
+		return closeRecent(g, v)
+	}
+	if len(recentProjects) == 0 {
+		return showWarning(g, "recent: no recent projects")
+	}
+
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	rv, err := g.SetView("recent", x0, y0, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	rv.Title = "Recent projects (Enter to switch, Esc to close)"
+	rv.Highlight = true
+	rv.Clear()
+	current, _ := os.Getwd()
+	for _, dir := range recentProjects {
+		if dir == current {
+			fmt.Fprintf(rv, "%s \x1b[33m*\x1b[0m\n", recentLabel(dir))
+			continue
+		}
+		fmt.Fprintln(rv, recentLabel(dir))
+	}
+	if _, err := g.SetViewOnTop("recent"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("recent")
+	return err
+}
+
+func closeRecent(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("recent"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+// switchRecent makes the selected recent project the project imake works
+// on, as if it had been started there: the targets are discovered again
+// and the panes are reset. The session of the project left is saved first.
+func switchRecent(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(recentProjects) {
+		return nil
+	}
+	dir := recentProjects[cy+oy]
+	if err := closeRecent(g, v); err != nil {
+		return err
+	}
+	if runs.Count() > 0 {
+		return showWarning(g, "recent: wait for the running commands to finish, or cancel them")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return showWarning(g, fmt.Sprintf("recent: %v", err))
+	}
+
+	if err := saveSession(g); err != nil {
+		log.Printf("imake: could not save session: %v", err)
+	}
+	if err := resetPanes(g); err != nil {
+		return err
+	}
+	projectRoot = dir
+	return openProject(g, dir)
+}
+
+// resetPanes closes the tabs, splits and prompts of the current project
+// and forgets its marks and results.
+func resetPanes(g *gocui.Gui) error {
+	if _, err := g.View("filter"); err == nil {
+		if err := clearFilter(g, nil); err != nil {
+			return err
+		}
+	}
+	if err := closeRunPanes(g); err != nil {
+		return err
+	}
+	queue, queueNext = nil, 0
+	if err := g.DeleteView("queue"); err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	if err := closeCompare(g, nil); err != nil {
+		return err
+	}
+	tabs, activeTab = nil, -1
+	outputView.Clear()
+	outputView.Title = tabStrip()
+	marked = nil
+	collapsed = make(map[string]bool)
+	results = make(map[string]runResult)
+	lastRun.target, lastRun.args = "", nil
+	return nil
+}
@@ -0,0 +1,179 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+	"github.com/jroimartin/gocui"
+)
+
+// shellKeywords are highlighted in recipes.
+var shellKeywords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true,
+	"for": true, "while": true, "until": true, "do": true, "done": true,
+	"case": true, "esac": true, "in": true, "function": true,
+	"return": true, "exit": true, "export": true, "local": true,
+	"set": true, "unset": true, "cd": true, "echo": true, "test": true,
+}
+
+// highlightShell colors a recipe line: make's command prefixes, shell
+// keywords, variables, strings, operators and comments.
+func highlightShell(line string) string {
+	var b strings.Builder
+	rest := line
+	// Leading @ (silent), - (ignore errors) and + (always run).
+	prefix := strings.TrimLeft(rest, " ")
+	if n := len(prefix) - len(strings.TrimLeft(prefix, "@-+")); n > 0 {
+		b.WriteString(rest[:len(rest)-len(prefix)])
+		fmt.Fprintf(&b, "\x1b[38;5;8m%s\x1b[0m", prefix[:n])
+		rest = prefix[n:]
+	}
+
+	for rest != "" {
+		r := rest[0]
+		switch {
+		case r == '#' && (b.Len() == 0 || strings.HasSuffix(b.String(), " ")):
+			fmt.Fprintf(&b, "\x1b[38;5;8m%s\x1b[0m", rest)
+			rest = ""
+		case r == '"' || r == '\'':
+			end := strings.IndexByte(rest[1:], r)
+			if end < 0 {
+				end = len(rest) - 1
+			} else {
+				end += 2
+			}
+			fmt.Fprintf(&b, "\x1b[32m%s\x1b[0m", rest[:end])
+			rest = rest[end:]
+		case r == '$':
+			end := variableEnd(rest)
+			fmt.Fprintf(&b, "\x1b[36m%s\x1b[0m", rest[:end])
+			rest = rest[end:]
+		case strings.ContainsRune("|&;<>", rune(r)):
+			end := 1
+			for end < len(rest) && strings.ContainsRune("|&;<>", rune(rest[end])) {
+				end++
+			}
+			fmt.Fprintf(&b, "\x1b[33m%s\x1b[0m", rest[:end])
+			rest = rest[end:]
+		case isWordByte(r):
+			end := 1
+			for end < len(rest) && isWordByte(rest[end]) {
+				end++
+			}
+			if word := rest[:end]; shellKeywords[word] {
+				fmt.Fprintf(&b, "\x1b[35;1m%s\x1b[0m", word)
+			} else {
+				b.WriteString(word)
+			}
+			rest = rest[end:]
+		default:
+			b.WriteByte(r)
+			rest = rest[1:]
+		}
+	}
+	return b.String()
+}
+
+// variableEnd returns the length of the variable reference at the start of
+// s: $(NAME), ${NAME}, $$NAME or $@-style automatic variables.
+func variableEnd(s string) int {
+	i := 1
+	if strings.HasPrefix(s, "$$") {
+		i = 2
+	}
+	if i >= len(s) {
+		return i
+	}
+	if open := s[i]; open == '(' || open == '{' {
+		closing := byte(')')
+		if open == '{' {
+			closing = '}'
+		}
+		depth := 0
+		for j := i; j < len(s); j++ {
+			switch s[j] {
+			case open:
+				depth++
+			case closing:
+				depth--
+				if depth == 0 {
+					return j + 1
+				}
+			}
+		}
+		return len(s)
+	}
+	if !isWordByte(s[i]) {
+		// Automatic variables such as $@, $< and $^.
+		return i + 1
+	}
+	for i < len(s) && isWordByte(s[i]) {
+		i++
+	}
+	return i
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// toggleRecipe opens or closes an overlay showing the commands the
+// selected target runs.
+func toggleRecipe(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("recipe"); err == nil {
+		return closeRecipe(g, v)
+	}
+	t, ok := makefile.Find(targets, selectedTarget(v))
+	if !ok {
+		return nil
+	}
+
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	rv, err := g.SetView("recipe", x0, y0, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	rv.Title = fmt.Sprintf("Recipe of %s, %s:%d (Esc to close)", t.Name, t.File, t.Line)
+	rv.Clear()
+	if len(t.Recipe) == 0 {
+		fmt.Fprintln(rv, "\x1b[38;5;8m(no recipe)\x1b[0m")
+	}
+	for _, line := range t.Recipe {
+		fmt.Fprintln(rv, highlightShell(line))
+	}
+	if legend := ruleLegend(t); len(legend) > 0 {
+		fmt.Fprintln(rv)
+		for _, line := range legend {
+			fmt.Fprintf(rv, "\x1b[38;5;8m%s\x1b[0m\n", line)
+		}
+	}
+
+	if _, err := g.SetViewOnTop("recipe"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("recipe")
+	return err
+}
+
+// ruleLegend explains the target-specific and automatic variables of the
+// rule of t, for readers who do not know them by heart.
+func ruleLegend(t makefile.Target) []string {
+	var legend []string
+	if len(t.Vars) > 0 {
+		legend = append(legend, "target variables: "+strings.Join(t.Vars, "; "))
+	}
+	return append(legend, makefile.ExplainAutomatic(t)...)
+}
+
+func closeRecipe(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("recipe"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// reportFoldLines is how many lines of output a report shows before
+// folding them in a <details> block.
+const reportFoldLines = 30
+
+// backtickRunRegexp matches runs of backticks, which a code fence must be
+// longer than.
+var backtickRunRegexp = regexp.MustCompile("`+")
+
+// markdownReport formats the last run of t as GitHub-flavored Markdown:
+// its outcome, command, environment, duration, the errors found and the
+// output, or only the lines of output given if not nil.
+func markdownReport(t *outputTab, lines []string) string {
+	var b strings.Builder
+	outcome := "succeeded"
+	switch t.status {
+	case tabRunning:
+		outcome = "is running"
+	case tabCancelled:
+		outcome = "was cancelled"
+	case tabFailed:
+		outcome = fmt.Sprintf("failed (exit code %d)", t.code)
+	}
+	fmt.Fprintf(&b, "### `%s` %s\n\n", t.name, outcome)
+	fmt.Fprintln(&b, "| | |\n|---|---|")
+	if t.command != "" {
+		fmt.Fprintf(&b, "| Command | `%s` |\n", markdownCell(t.command))
+	}
+	if len(t.env) > 0 {
+		fmt.Fprintf(&b, "| Environment | `%s` |\n", markdownCell(strings.Join(t.env, " ")))
+	}
+	if !t.started.IsZero() {
+		fmt.Fprintf(&b, "| Started | %s |\n", t.started.Format("2006-01-02 15:04:05 MST"))
+	}
+	if t.status != tabRunning {
+		fmt.Fprintf(&b, "| Duration | %s |\n", t.duration.Round(10*time.Millisecond))
+		fmt.Fprintf(&b, "| Exit code | %d |\n", t.code)
+	}
+
+	if errors := reportErrors(t); len(errors) > 0 {
+		fmt.Fprint(&b, "\n#### Errors\n\n")
+		for _, e := range errors {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+
+	output := lines
+	if output == nil {
+		output = strings.Split(strings.TrimSuffix(filterANSI(t.buf.String(), ANSIStrip), "\n"), "\n")
+	}
+	fence := "```"
+	for _, run := range backtickRunRegexp.FindAllString(strings.Join(output, "\n"), -1) {
+		if len(run) >= len(fence) {
+			fence = strings.Repeat("`", len(run)+1)
+		}
+	}
+	fmt.Fprintln(&b)
+	if len(output) > reportFoldLines {
+		fmt.Fprintf(&b, "<details><summary>Output (%d lines)</summary>\n\n", len(output))
+	} else {
+		fmt.Fprint(&b, "#### Output\n\n")
+	}
+	if lines == nil && t.buf.dropped > 0 {
+		fmt.Fprintf(&b, "The first %d lines were dropped.\n\n", t.buf.dropped)
+	}
+	fmt.Fprintf(&b, "%s\n%s\n%s\n", fence, strings.Join(output, "\n"), fence)
+	if len(output) > reportFoldLines {
+		fmt.Fprintln(&b, "\n</details>")
+	}
+	return b.String()
+}
+
+// markdownCell escapes s for a code span in a table cell.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "`", "'").Replace(s)
+}
+
+// reportErrors returns the errors of the last run of t as Markdown list
+// items: the problems found in its output, or else the lines of make
+// reporting a failure.
+func reportErrors(t *outputTab) []string {
+	dir, _ := os.Getwd()
+	var errors []string
+	for _, p := range t.problems {
+		location := p.file
+		if rel, err := filepath.Rel(dir, p.file); err == nil && !strings.HasPrefix(rel, "..") {
+			location = rel
+		}
+		if p.line > 0 {
+			location += fmt.Sprintf(":%d", p.line)
+		}
+		if p.col > 0 {
+			location += fmt.Sprintf(":%d", p.col)
+		}
+		errors = append(errors, fmt.Sprintf("`%s`: %s", location, p.message))
+	}
+	if len(errors) > 0 || t.status != tabFailed {
+		return errors
+	}
+	for _, line := range strings.Split(filterANSI(t.buf.String(), ANSIStrip), "\n") {
+		if makeErrorRegexp.MatchString(line) {
+			errors = append(errors, "`"+strings.TrimSpace(line)+"`")
+		}
+	}
+	return errors
+}
+
+// reportLines returns the selected lines of output, ending the selection,
+// or nil when nothing is selected.
+func reportLines(g *gocui.Gui, v *gocui.View) ([]string, error) {
+	if !selection.active {
+		return nil, nil
+	}
+	all := outputView.BufferLines()
+	first, last := selectedRange()
+	lines := append([]string(nil), all[first:min(last+1, len(all))]...)
+	for i, line := range lines {
+		lines[i] = filterANSI(line, ANSIStrip)
+	}
+	return lines, endSelection(g, v)
+}
+
+// copyReport copies the Markdown report of the active tab to the
+// clipboard, with the selected lines as output if any.
+func copyReport(g *gocui.Gui, v *gocui.View) error {
+	if activeTab < 0 {
+		return showWarning(g, "report: no run to report")
+	}
+	lines, err := reportLines(g, v)
+	if err != nil {
+		return err
+	}
+	via, err := copyToClipboard(markdownReport(tabs[activeTab], lines))
+	if err != nil {
+		return showWarning(g, fmt.Sprintf("report: %v", err))
+	}
+	return showNotice(fmt.Sprintf("copied the report of %s to the clipboard (%s)", tabs[activeTab].name, via))
+}
+
+// saveReport writes the Markdown report of the active tab next to the run
+// logs.
+func saveReport(g *gocui.Gui, v *gocui.View) error {
+	if activeTab < 0 {
+		return showWarning(g, "report: no run to report")
+	}
+	t := tabs[activeTab]
+	lines, err := reportLines(g, v)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(logDir, logFileName(t.name, "report")+".md")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		return showWarning(g, fmt.Sprintf("report: %v", err))
+	}
+	if err := os.WriteFile(path, []byte(markdownReport(t, lines)), 0o644); err != nil {
+		return showWarning(g, fmt.Sprintf("report: %v", err))
+	}
+	return showNotice("wrote the report of " + t.name + " to " + path)
+}
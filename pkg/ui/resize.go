@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/jroimartin/gocui"
+)
+
+// Below minWidth by minHeight cells the panes are too small to be of use,
+// and a message asks for a bigger terminal instead.
+const (
+	minWidth  = 40
+	minHeight = 10
+)
+
+// minPaneSize is the smallest width and height of a pane, leaving room for
+// its frame and one cell of content.
+const minPaneSize = 2
+
+// outputOverlays are the views opened on top of the Command Output area.
+// layoutOverlays keeps them, and the prompts, in place when the terminal
+// is resized.
+var outputOverlays = []string{"setup", "history", "stats", "graph", "recipe", "projects", "recent", "problems", "diagnostics", "hosts", "jobs"}
+
+// ptySize is the size the pseudo-terminals of running commands were last
+// given.
+var ptySize struct{ cols, rows int }
+
+// layoutTooSmall covers the panes with a message while the terminal is
+// smaller than minWidth by minHeight.
+func layoutTooSmall(g *gocui.Gui) error {
+	maxX, maxY := g.Size()
+	if maxX >= minWidth && maxY >= minHeight {
+		if err := g.DeleteView("tooSmall"); err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+			return err
+		}
+		return nil
+	}
+	v, err := g.SetView("tooSmall", -1, -1, max(maxX, 1), max(maxY, 1))
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	v.Frame = false
+	v.Wrap = true
+	v.Clear()
+	fmt.Fprintf(v, "Terminal too small: %dx%d, imake needs %dx%d", maxX, maxY, minWidth, minHeight)
+	_, err = g.SetViewOnTop("tooSmall")
+	return err
+}
+
+// layoutOverlays moves the open overlays and prompts to where they would
+// be opened now, and resizes the pseudo-terminals of running commands to
+// the Command Output view.
+func layoutOverlays(g *gocui.Gui) error {
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	for _, name := range outputOverlays {
+		if err := moveView(g, name, x0, y0, x1, y1); err != nil {
+			return err
+		}
+	}
+	if err := moveView(g, "vars", x0, y0, x1, y0+2); err != nil {
+		return err
+	}
+	if err := moveView(g, "varsList", x0, y0+2, x1, y1); err != nil {
+		return err
+	}
+	if err := moveView(g, "interact", x0, y1-2, x1, y1); err != nil {
+		return err
+	}
+	if sx0, _, sx1, sy1, err := g.ViewPosition("Sidebar"); err == nil {
+		if err := moveView(g, "filter", sx0, sy1-2, sx1, sy1); err != nil {
+			return err
+		}
+	}
+	maxX, maxY := g.Size()
+	for _, name := range []string{"args", "flags"} {
+		if err := moveView(g, name, maxX/6, maxY/2-1, maxX*5/6, maxY/2+1); err != nil {
+			return err
+		}
+	}
+	if err := moveView(g, "sudo", maxX/4, maxY/2-1, maxX*3/4, maxY/2+1); err != nil {
+		return err
+	}
+	if err := moveView(g, "shell", 0, maxY-3, maxX-1, maxY-1); err != nil {
+		return err
+	}
+	kx0, ky0, kx1, ky1 := keysPosition(g)
+	if err := moveView(g, "keys", kx0, ky0, kx1, ky1); err != nil {
+		return err
+	}
+
+	if outputView == nil {
+		return nil
+	}
+	cols, rows := outputView.Size()
+	if cols != ptySize.cols || rows != ptySize.rows {
+		ptySize.cols, ptySize.rows = cols, rows
+		for _, t := range tabs {
+			if t.input != nil && t.input.tty {
+				runner.ResizePTY(t.input.w, cols, rows)
+			}
+		}
+	}
+	return nil
+}
+
+// moveView places the view called name at x0, y0, x1, y1 if it is open.
+func moveView(g *gocui.Gui, name string, x0, y0, x1, y1 int) error {
+	if _, err := g.View(name); err != nil {
+		return nil
+	}
+	_, err := g.SetView(name, x0, y0, max(x1, x0+minPaneSize), max(y1, y0+minPaneSize))
+	return err
+}
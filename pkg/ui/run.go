@@ -0,0 +1,489 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/jroimartin/gocui"
+)
+
+// commandLine returns the command line used to run target with args.
+func commandLine(target string, args []string) string {
+	return strings.Join(backend.Exec(target, args).Args, " ")
+}
+
+func runTarget(g *gocui.Gui, target string, args []string) error {
+	lastRun.target, lastRun.args = target, args
+	g.Update(func(g *gocui.Gui) error {
+		if err := closeRunPanes(g); err != nil {
+			return err
+		}
+		return startTarget(g, target, args, nil)
+	})
+
+	return nil
+}
+
+// lastRun is the last target run with runTarget and its arguments.
+var lastRun struct {
+	target string
+	args   []string
+}
+
+// rerunLast runs the last target again with the same arguments, falling
+// back to the newest history entry of the project after a restart.
+func rerunLast(g *gocui.Gui, v *gocui.View) error {
+	target, args := lastRun.target, lastRun.args
+	if target == "" {
+		entries := projectHistory()
+		if len(entries) == 0 {
+			return showWarning(g, "nothing has been run yet")
+		}
+		target, args = entries[0].Target, entries[0].Args
+	}
+	if runs.Busy(target) {
+		return showWarning(g, target+" is still running")
+	}
+	return runTarget(g, target, args)
+}
+
+// startTarget runs target with args in its tab. Once it has exited, done
+// (if not nil) is called with the tab and the exit code. Nothing is run
+// while target is still running.
+func startTarget(g *gocui.Gui, target string, args []string, done func(g *gocui.Gui, tab *outputTab, code int)) error {
+	if runs.Busy(target) {
+		return showWarning(g, target+" is still running")
+	}
+	cmd := backend.Exec(target, args)
+	if needsSudo(target) {
+		if err := checkSudo(); err != nil {
+			return showWarning(g, fmt.Sprintf("sudo: %v, %s was not run", err, target))
+		}
+		if !sudoCached() {
+			return openSudoPrompt(g, target, args, done, fmt.Sprintf("sudo password to run %s (Enter runs, Esc cancels)", target))
+		}
+		cmd = sudoCommand(cmd)
+	}
+	tab := openTab(target)
+	tab.reset()
+	if err := setResult(g, target, runResult{running: true}); err != nil {
+		return err
+	}
+	out, closeLog := teeLog(tab, target)
+	// Hold target until its hooks are done too, so nothing starts in the
+	// tab between two of its commands.
+	release := runs.Hold(target)
+	finish := func(g *gocui.Gui, code int) {
+		release()
+		closeLog()
+		setResult(g, target, runResult{code: code})
+		if done != nil {
+			done(g, tab, code)
+		}
+	}
+	before, after := targetHooks(target)
+	tab.status, tab.started = tabRunning, time.Now()
+	outputView.Title = tabStrip()
+	runHooks(g, tab, out, before, target, -1, func(g *gocui.Gui, code int) {
+		if code != 0 {
+			fmt.Fprintf(out, "\x1b[31mbefore hook failed: %s was not run\x1b[0m\n", target)
+			tab.status = tabFailed
+			outputView.Title = tabStrip()
+			finish(g, code)
+			return
+		}
+		err := startTabCommand(g, tab, out, cmd, func(g *gocui.Gui, code int, started time.Time) {
+			recordRun(tab, target, args, code, started)
+			notifyFinished(target, code, started)
+			if code > 0 {
+				reportProblems(g, tab)
+			}
+			if err := reloadAfterRun(g, started); err != nil {
+				showWarning(g, fmt.Sprintf("reload: %v", err))
+			}
+			runHooks(g, tab, out, after, target, code, func(g *gocui.Gui, _ int) {
+				finish(g, code)
+			})
+		})
+		if err != nil {
+			fmt.Fprintln(out, "Error starting command:", err)
+			finish(g, -1)
+		}
+	})
+	return nil
+}
+
+// dryRunTarget shows the commands target would run without running them.
+func dryRunTarget(g *gocui.Gui, v *gocui.View) error {
+	target := selectedTarget(v)
+	if target == "" {
+		return nil
+	}
+	if runs.Busy(target + " (dry run)") {
+		return showWarning(g, target+" (dry run) is still running")
+	}
+	if err := closeRunPanes(g); err != nil {
+		return err
+	}
+	tab := openTab(target + " (dry run)")
+	tab.reset()
+
+	dry, ok := backend.(runner.DryRunner)
+	if !ok {
+		fmt.Fprintf(tab, "%s does not support dry runs\n", backend.Name())
+		return nil
+	}
+	fmt.Fprintln(tab, "\x1b[33mdry run: nothing is executed\x1b[0m")
+	return startTabCommand(g, tab, tab, dry.DryRun(target, nil), nil)
+}
+
+// startTabCommand runs cmd with startCommand, writing to out, and keeps
+// the status icon and input of tab up to date.
+func startTabCommand(g *gocui.Gui, tab *outputTab, out io.Writer, cmd *exec.Cmd, done func(g *gocui.Gui, code int, started time.Time)) error {
+	tab.status, tab.started = tabRunning, time.Now()
+	tab.command, tab.env = strings.Join(cmd.Args, " "), envOverrides(cmd)
+	outputView.Title = tabStrip()
+	var run *commandRun
+	run, err := startCommand(g, tab.name, out, cmd, func(g *gocui.Gui, code int, started time.Time) {
+		tab.input = nil
+		tab.code, tab.duration = code, time.Since(started)
+		if interactTab == tab {
+			closeInteract(g, nil)
+		}
+		switch {
+		case run != nil && run.Cancelled():
+			tab.status = tabCancelled
+		case code == 0:
+			tab.status = tabSuccess
+		default:
+			tab.status = tabFailed
+		}
+		outputView.Title = tabStrip()
+		if done != nil {
+			done(g, code, started)
+		}
+	})
+	if run != nil {
+		tab.input = run.input
+	}
+	return err
+}
+
+// recordRun adds a finished run to the history, reporting failures to
+// save it in out.
+func recordRun(out io.Writer, target string, args []string, code int, started time.Time) {
+	if code < 0 {
+		return
+	}
+	err := addHistory(HistoryEntry{
+		Makefile: absBuildFile(),
+		Target:   target,
+		Args:     args,
+		ExitCode: code,
+		Started:  started,
+		Duration: time.Since(started),
+	})
+	if err != nil {
+		fmt.Fprintln(out, "Error saving history:", err)
+	}
+}
+
+// envOverrides returns the variables cmd sets in its environment on top of
+// imake's own, as NAME=value.
+func envOverrides(cmd *exec.Cmd) []string {
+	var env []string
+	for _, kv := range cmd.Env {
+		name, value, _ := strings.Cut(kv, "=")
+		if current, ok := os.LookupEnv(name); !ok || current != value {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// startCommand runs cmd in the background for key, streaming its output
+// into out, and returns its run, or nil if it could not be started. Once
+// the command has exited, done (if not nil) is called from the main loop
+// with its exit code, or -1 if it could not be run, and start time.
+// Callers make sure no other command runs for key.
+func startCommand(g *gocui.Gui, key string, out io.Writer, cmd *exec.Cmd, done func(g *gocui.Gui, code int, started time.Time)) (*commandRun, error) {
+	// Show where the command runs, which changes with the selected project.
+	dir := workingDir()
+	if filepath.IsAbs(cmd.Dir) {
+		dir = cmd.Dir
+	} else if cmd.Dir != "" {
+		dir = filepath.Join(dir, cmd.Dir)
+	}
+	if remoteHost != "" {
+		dir = remoteHost + ": " + config.Hosts[remoteHost]
+	}
+	line := strings.Join(append(envOverrides(cmd), cmd.Args...), " ")
+	fmt.Fprintf(out, "\x1b[38;5;8m# %s\x1b[0m\n$ %s\n", dir, line)
+	if remoteHost == "" {
+		// Values of the env files are left out of the command line above.
+		if err := applyDotenv(cmd); err != nil {
+			fmt.Fprintln(out, "Error loading env files:", err)
+		}
+	}
+
+	// Start the command under a pseudo-terminal if possible, so tools
+	// writing to it keep their colors and progress bars, and with pipes
+	// otherwise.
+	started := time.Now()
+	cmd, err := remoteCommand(cmd)
+	var outputs []io.Reader
+	var input *commandInput
+	if err == nil {
+		outputs, input, err = startOutputs(g, cmd)
+	}
+	if err != nil {
+		fmt.Fprintln(out, "Error starting command:", err)
+		if done != nil {
+			done(g, -1, started)
+		}
+		return nil, nil
+	}
+	run := &commandRun{runs.Add(key, cmd), input}
+	runOutputs[run.ID] = out
+	commandStarted(strings.Join(cmd.Args, " "), started)
+
+	// gocui sends each Update from a goroutine of its own, so the output is
+	// queued to be written in the order it was read.
+	updates := &orderedUpdates{g: g}
+
+	// Stream the outputs into out, stderr in red unless a highlight rule
+	// colors the line. A carriage return
+	// without a newline is kept until the next output arrives, so progress
+	// bars redraw their line instead of adding new ones.
+	var wg sync.WaitGroup
+	stream := func(r io.Reader, color string) {
+		defer wg.Done()
+		defer recoverCrash()
+		scanner := bufio.NewScanner(r)
+		scanner.Split(scanTerminalLines)
+		returned, lineStart := false, true
+		pending := "" // start of the line being logged for the API
+		for scanner.Scan() {
+			elapsed := time.Since(started)
+			text, end := splitTerminator(scanner.Text())
+			switch end {
+			case "\n":
+				run.AddLine(filterANSI(pending+text, ANSIStrip))
+				pending = ""
+			case "\r":
+				// Progress bars log the last state of their line only.
+				pending = ""
+			default:
+				pending += text
+			}
+			outputLine := filterANSI(text, ansiMode)
+			lineColor := color
+			if c := highlightColor(outputLine); c != "" {
+				lineColor = c
+			}
+			if lineColor != "" {
+				outputLine = lineColor + outputLine + "\x1b[0m"
+			}
+			carriageReturn, newLine := returned, lineStart
+			returned, lineStart = end == "\r", end != ""
+			updates.queue(func(g *gocui.Gui) error {
+				if carriageReturn {
+					fmt.Fprint(out, "\r")
+				}
+				if timestamps && newLine {
+					fmt.Fprint(out, timestampPrefix(elapsed))
+				}
+				fmt.Fprint(out, outputLine)
+				if end == "\n" {
+					fmt.Fprintln(out)
+				}
+				return nil
+			})
+		}
+		if pending != "" {
+			run.AddLine(filterANSI(pending, ANSIStrip))
+		}
+		if err := scanner.Err(); err != nil {
+			updates.queue(func(g *gocui.Gui) error {
+				fmt.Fprintln(out, "Error reading command output:", err)
+				return nil
+			})
+		}
+	}
+	wg.Add(len(outputs))
+	go stream(outputs[0], "")
+	if len(outputs) > 1 {
+		go stream(outputs[1], "\x1b[31m")
+	}
+
+	// Report the exit code once the output is drained
+	go func() {
+		defer recoverCrash()
+		wg.Wait()
+		err := cmd.Wait()
+		for _, r := range outputs {
+			if c, ok := r.(io.Closer); ok {
+				c.Close()
+			}
+		}
+		cancelled := run.Cancelled()
+		if err != nil && !errors.As(err, new(*exec.ExitError)) {
+			runs.Finish(run.Run, -1)
+		} else {
+			runs.Finish(run.Run, cmd.ProcessState.ExitCode())
+		}
+		updates.queue(func(g *gocui.Gui) error {
+			delete(runOutputs, run.ID)
+			commandFinished(cmd.ProcessState.ExitCode())
+			var exitErr *exec.ExitError
+			if err != nil && !errors.As(err, &exitErr) {
+				fmt.Fprintln(out, "Error running command:", err)
+				if done != nil {
+					done(g, -1, started)
+				}
+				return nil
+			}
+			code := cmd.ProcessState.ExitCode()
+			if cancelled {
+				fmt.Fprintf(out, "\ncancelled (exit code %d)", code)
+			} else {
+				fmt.Fprintf(out, "\nexit code %d", code)
+			}
+			if timestamps {
+				fmt.Fprint(out, ", "+timingSummary(cmd.ProcessState, time.Since(started)))
+			}
+			fmt.Fprintln(out)
+			if done != nil {
+				done(g, code, started)
+			}
+			return nil
+		})
+	}()
+
+	return run, nil
+}
+
+// orderedUpdates applies funcs in the gocui main loop in the order they
+// were queued, which g.Update alone does not guarantee.
+type orderedUpdates struct {
+	g       *gocui.Gui
+	mu      sync.Mutex
+	pending []func(*gocui.Gui) error
+}
+
+func (u *orderedUpdates) queue(f func(*gocui.Gui) error) {
+	u.mu.Lock()
+	u.pending = append(u.pending, f)
+	u.mu.Unlock()
+	u.g.Update(u.apply)
+}
+
+// apply runs the queued funcs. Updates finding nothing left to run are
+// those whose funcs an earlier apply already ran.
+func (u *orderedUpdates) apply(g *gocui.Gui) error {
+	u.mu.Lock()
+	pending := u.pending
+	u.pending = nil
+	u.mu.Unlock()
+	for _, f := range pending {
+		if err := f(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startOutputs starts cmd and returns its output and input: the
+// pseudo-terminal it runs in, or its stdout, stderr and stdin pipes when
+// pseudo-terminals are turned off in the config or not supported.
+func startOutputs(g *gocui.Gui, cmd *exec.Cmd) ([]io.Reader, *commandInput, error) {
+	if (config.PTY == nil || *config.PTY) && !isSudoCommand(cmd) {
+		cols, rows := 80, 24
+		if v, err := g.View("command"); err == nil {
+			cols, rows = v.Size()
+		}
+		tty, err := runner.StartPTY(cmd, cols, rows)
+		if err == nil {
+			return []io.Reader{tty}, &commandInput{w: tty, tty: true}, nil
+		}
+		if !errors.Is(err, runner.ErrNoPTY) {
+			return nil, nil, err
+		}
+	}
+
+	runner.SetProcessGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+	return []io.Reader{stdout, stderr}, &commandInput{w: stdin}, nil
+}
+
+// scanTerminalLines is a bufio.SplitFunc for terminal output: lines end
+// with "\n", "\r\n" or a bare "\r", which is kept in the token. Output
+// without a line ending, such as a prompt, is returned as it arrives.
+func scanTerminalLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	i := bytes.IndexAny(data, "\r\n")
+	switch {
+	case i < 0:
+		return len(data), data, nil
+	case data[i] == '\n':
+		return i + 1, data[:i+1], nil
+	case i+1 < len(data) && data[i+1] == '\n':
+		return i + 2, data[:i+2], nil
+	case i+1 == len(data) && !atEOF:
+		// Wait to tell "\r\n" from a bare "\r".
+		return 0, nil, nil
+	}
+	return i + 1, data[:i+1], nil
+}
+
+// splitTerminator returns a token of scanTerminalLines without its line
+// ending, and the ending: "\r" for a bare carriage return, "\n" for a
+// newline and "" if the line is not finished yet.
+func splitTerminator(token string) (string, string) {
+	switch {
+	case strings.HasSuffix(token, "\r\n"):
+		return token[:len(token)-2], "\n"
+	case strings.HasSuffix(token, "\n"):
+		return token[:len(token)-1], "\n"
+	case strings.HasSuffix(token, "\r"):
+		return token[:len(token)-1], "\r"
+	}
+	return token, ""
+}
+
+// cancelCommand interrupts the running commands. Repeated presses escalate
+// to stronger signals for commands that ignore the interrupt.
+func cancelCommand(g *gocui.Gui, v *gocui.View) error {
+	for _, r := range runs.CancelAll() {
+		if out, ok := runOutputs[r.ID]; ok {
+			fmt.Fprintln(out, "\x1b[33mcancelling...\x1b[0m")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"io"
+
+	"github.com/gshireesh/imake/pkg/runner"
+)
+
+// commandRun is a command started with startCommand.
+type commandRun struct {
+	*runner.Run
+	input *commandInput
+}
+
+// runs own the running commands. They are shared with the HTTP API when
+// imake serves it alongside the UI, so a target never runs twice at once
+// and the API lists the runs of the UI.
+var runs = runner.NewRunManager()
+
+// runOutputs are the tabs or panes the runs write to, by ID, to report
+// that they are being cancelled. They are only touched from the gocui main
+// loop.
+var runOutputs = make(map[int]io.Writer)
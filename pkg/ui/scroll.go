@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"math"
+
+	"github.com/jroimartin/gocui"
+)
+
+// scrolledView returns the output pane scrolling applies to: the compare
+// pane while it is focused, else the Command Output view.
+func scrolledView(g *gocui.Gui) (*gocui.View, error) {
+	if v := g.CurrentView(); v != nil && v.Name() == "compare" {
+		return v, nil
+	}
+	return g.View("command")
+}
+
+// scrollOutput moves the focused output pane by delta lines. Scrolling up
+// pauses autoscroll; reaching the bottom again resumes it.
+func scrollOutput(g *gocui.Gui, delta int) error {
+	v, err := scrolledView(g)
+	if err != nil {
+		return err
+	}
+	_, height := v.Size()
+	ox, oy := v.Origin()
+	bottom := len(v.BufferLines()) - height
+	if bottom < 0 {
+		bottom = 0
+	}
+
+	oy += delta
+	if oy < 0 {
+		oy = 0
+	}
+	if oy >= bottom {
+		oy = bottom
+		v.Autoscroll = true
+	} else {
+		v.Autoscroll = false
+	}
+	return v.SetOrigin(ox, oy)
+}
+
+// outputPage returns the height of the focused output pane.
+func outputPage(g *gocui.Gui) int {
+	v, err := scrolledView(g)
+	if err != nil {
+		return 1
+	}
+	_, height := v.Size()
+	return height
+}
+
+func scrollPageUp(g *gocui.Gui, v *gocui.View) error {
+	return scrollOutput(g, -outputPage(g))
+}
+
+func scrollPageDown(g *gocui.Gui, v *gocui.View) error {
+	return scrollOutput(g, outputPage(g))
+}
+
+// scrollHalfPageUp scrolls the output by half a page. In the vim profile
+// it moves the Sidebar cursor instead while the Sidebar is focused.
+func scrollHalfPageUp(g *gocui.Gui, v *gocui.View) error {
+	if config.Vim && v != nil && v.Name() == "Sidebar" {
+		_, height := v.Size()
+		return moveSidebar(v, -height/2)
+	}
+	return scrollOutput(g, -outputPage(g)/2)
+}
+
+// scrollHalfPageDown is the downwards counterpart of scrollHalfPageUp.
+func scrollHalfPageDown(g *gocui.Gui, v *gocui.View) error {
+	if config.Vim && v != nil && v.Name() == "Sidebar" {
+		_, height := v.Size()
+		return moveSidebar(v, height/2)
+	}
+	return scrollOutput(g, outputPage(g)/2)
+}
+
+func scrollTop(g *gocui.Gui, v *gocui.View) error {
+	v, err := scrolledView(g)
+	if err != nil {
+		return err
+	}
+	v.Autoscroll = false
+	return v.SetOrigin(0, 0)
+}
+
+func scrollBottom(g *gocui.Gui, v *gocui.View) error {
+	return scrollOutput(g, math.MaxInt32)
+}
+
+func scrollWheelUp(g *gocui.Gui, v *gocui.View) error {
+	return scrollOutput(g, -3)
+}
+
+func scrollWheelDown(g *gocui.Gui, v *gocui.View) error {
+	return scrollOutput(g, 3)
+}
@@ -0,0 +1,247 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jroimartin/gocui"
+)
+
+// makeDirRegexp matches the messages recursive makes print on entering and
+// leaving a directory; makeErrorRegexp matches those reporting a failure.
+var (
+	makeDirRegexp   = regexp.MustCompile("make(?:\\[\\d+\\])?: (Entering|Leaving) directory [`'‘](.*)['’]$")
+	makeErrorRegexp = regexp.MustCompile(`make(?:\[\d+\])?: \*\*\*`)
+)
+
+// outputSection is the output of a recursive make in a directory, from
+// its "Entering directory" line to its "Leaving directory" line.
+type outputSection struct {
+	start, end int // lines of the two messages, end is -1 while it runs
+	depth      int // 1 for a make run by the top-level one, and so on
+	dir        string
+	failed     bool
+	lines      int // lines of output in the section, nested ones included
+}
+
+// parseSections finds the sections of recursive makes in lines, in the
+// order they start.
+func parseSections(lines []string) []*outputSection {
+	var sections, open []*outputSection
+	for i, line := range lines {
+		if !strings.Contains(line, "make") {
+			for _, s := range open {
+				s.lines++
+			}
+			continue
+		}
+		plain := filterANSI(line, ANSIStrip)
+		m := makeDirRegexp.FindStringSubmatch(plain)
+		switch {
+		case m != nil && m[1] == "Entering":
+			for _, s := range open {
+				s.lines++
+			}
+			s := &outputSection{start: i, end: -1, depth: len(open) + 1, dir: m[2]}
+			sections = append(sections, s)
+			open = append(open, s)
+		case m != nil:
+			// Close the innermost section in the directory, and any left
+			// open inside it by a make that was killed.
+			for j := len(open) - 1; j >= 0; j-- {
+				if open[j].dir != m[2] {
+					continue
+				}
+				for _, s := range open[j:] {
+					if s.end < 0 {
+						s.end = i
+					}
+				}
+				if j > 0 && open[j].failed {
+					open[j-1].failed = true
+				}
+				open = open[:j]
+				break
+			}
+		default:
+			for _, s := range open {
+				s.lines++
+			}
+			if len(open) > 0 && makeErrorRegexp.MatchString(plain) {
+				open[len(open)-1].failed = true
+			}
+		}
+	}
+	return sections
+}
+
+// sectionRow is a line of the Command Output view drawn by renderSections:
+// the start of the section it is the header of, or -1, and its text
+// without escapes, to tell how many rows it wraps to.
+type sectionRow struct {
+	start int
+	text  string
+}
+
+// shownRows are the lines of the Command Output view when the active tab
+// is drawn in sections.
+var shownRows []sectionRow
+
+// renderSections renders the output of t with the output of each
+// recursive make indented below a header, or replaced by it if the
+// section is folded. The messages leaving directories are left out.
+func renderSections(t *outputTab) []byte {
+	lines := strings.Split(t.buf.String(), "\n")
+	sections := parseSections(lines)
+	starts := make(map[int]*outputSection)
+	for _, s := range sections {
+		starts[s.start] = s
+	}
+	shownRows = shownRows[:0]
+	var out []string
+	add := func(start int, line string) {
+		out = append(out, line)
+		shownRows = append(shownRows, sectionRow{start, filterANSI(line, ANSIStrip)})
+	}
+
+	root, _ := os.Getwd()
+	var open []*outputSection
+	for i := 0; i < len(lines); i++ {
+		for len(open) > 0 && open[len(open)-1].end >= 0 && open[len(open)-1].end < i {
+			open = open[:len(open)-1]
+		}
+		s, ok := starts[i]
+		if !ok {
+			if len(open) > 0 && open[len(open)-1].end == i {
+				continue
+			}
+			if lines[i] == "" {
+				if i < len(lines)-1 {
+					add(-1, "")
+				}
+				continue
+			}
+			add(-1, strings.Repeat("  ", len(open))+lines[i])
+			continue
+		}
+
+		// Name the directory relative to the one the make above ran in.
+		dir, parent := s.dir, root
+		if len(open) > 0 {
+			parent = open[len(open)-1].dir
+		}
+		if rel, err := filepath.Rel(parent, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			dir = rel
+		}
+		status := runningText("…")
+		switch {
+		case s.failed:
+			status = failureText("✗")
+		case s.end >= 0:
+			status = successText("✓")
+		}
+		indent := strings.Repeat("  ", s.depth-1)
+		if t.folded[t.buf.dropped+s.start] {
+			add(s.start, fmt.Sprintf("%s▸ %s %s \x1b[38;5;8m(%d lines)\x1b[0m", indent, dir, status, s.lines))
+			if s.end < 0 {
+				break
+			}
+			i = s.end
+			continue
+		}
+		add(s.start, fmt.Sprintf("%s▾ %s %s", indent, dir, status))
+		open = append(open, s)
+	}
+	return []byte(strings.Join(out, "\n"))
+}
+
+// drawTab redraws the Command Output view with the output of t, in
+// sections once it ran a recursive make.
+func drawTab(t *outputTab) {
+	outputView.Clear()
+	t.redraw = false
+	if !t.sectioned {
+		outputView.Write(t.buf.Bytes())
+		return
+	}
+	outputView.Write(renderSections(t))
+}
+
+// layoutSections redraws the active tab if it is drawn in sections and
+// output arrived since. It is called by the manager, so bursts of output
+// are drawn once.
+func layoutSections() {
+	if activeTab < 0 || !tabs[activeTab].redraw || selection.active || outputView == nil {
+		return
+	}
+	drawTab(tabs[activeTab])
+}
+
+// toggleSection folds or unfolds the section whose header is at the cursor
+// of the Command Output view.
+func toggleSection(g *gocui.Gui, v *gocui.View) error {
+	if activeTab < 0 || !tabs[activeTab].sectioned || selection.active {
+		return nil
+	}
+	t := tabs[activeTab]
+	width, _ := v.Size()
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	y := cy + oy
+	for _, row := range shownRows {
+		y -= max(1, (utf8.RuneCountInString(row.text)+width-1)/max(width, 1))
+		if y >= 0 {
+			continue
+		}
+		if row.start < 0 {
+			return nil
+		}
+		if t.folded == nil {
+			t.folded = make(map[int]bool)
+		}
+		key := t.buf.dropped + row.start
+		t.folded[key] = !t.folded[key]
+		drawTab(t)
+		outputView.Autoscroll = false
+		return outputView.SetOrigin(0, oy)
+	}
+	return nil
+}
+
+// foldSections folds the sections of the active tab whose make succeeded,
+// or unfolds every section if they are folded already.
+func foldSections(g *gocui.Gui, v *gocui.View) error {
+	if activeTab < 0 || !tabs[activeTab].sectioned {
+		return showWarning(g, "fold: no recursive make in this output")
+	}
+	t := tabs[activeTab]
+	if t.folded == nil {
+		t.folded = make(map[int]bool)
+	}
+	var passed []int
+	for _, s := range parseSections(strings.Split(t.buf.String(), "\n")) {
+		if s.end >= 0 && !s.failed {
+			passed = append(passed, t.buf.dropped+s.start)
+		}
+	}
+	fold := false
+	for _, start := range passed {
+		if !t.folded[start] {
+			fold = true
+		}
+	}
+	if fold {
+		for _, start := range passed {
+			t.folded[start] = true
+		}
+	} else {
+		clear(t.folded)
+	}
+	selection.active = false
+	drawTab(t)
+	return nil
+}
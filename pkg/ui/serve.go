@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/jroimartin/gocui"
+)
+
+// showOtherRun shows a run started outside the UI, such as through the
+// HTTP API, in the tab of its target, as if it had been started from the
+// Sidebar.
+func showOtherRun(g *gocui.Gui, r *runner.Run) {
+	lines, next, _ := r.Subscribe()
+	updates := &orderedUpdates{g: g}
+	var tab *outputTab
+	updates.queue(func(g *gocui.Gui) error {
+		tab = openTab(r.Key)
+		tab.reset()
+		tab.status, tab.started = tabRunning, r.Started
+		tab.command, tab.env = strings.Join(r.Cmd.Args, " "), nil
+		outputView.Title = tabStrip()
+		runOutputs[r.ID] = tab
+		commandStarted(tab.command, r.Started)
+		fmt.Fprintf(tab, "\x1b[38;5;8m# started through the HTTP API\x1b[0m\n$ %s\n", tab.command)
+		for _, line := range lines {
+			fmt.Fprintln(tab, line)
+		}
+		return setResult(g, r.Key, runResult{running: true})
+	})
+	go func() {
+		defer recoverCrash()
+		for line := range next {
+			updates.queue(func(g *gocui.Gui) error {
+				fmt.Fprintln(tab, line)
+				return nil
+			})
+		}
+		<-r.Done()
+		updates.queue(func(g *gocui.Gui) error {
+			info := r.Info()
+			code := *info.Code
+			delete(runOutputs, r.ID)
+			commandFinished(code)
+			tab.code, tab.duration = code, info.Ended.Sub(r.Started)
+			switch info.State {
+			case runner.RunCancelled:
+				tab.status = tabCancelled
+				fmt.Fprintf(tab, "\ncancelled (exit code %d)\n", code)
+			case runner.RunSucceeded:
+				tab.status = tabSuccess
+				fmt.Fprintf(tab, "\nexit code %d\n", code)
+			default:
+				tab.status = tabFailed
+				fmt.Fprintf(tab, "\nexit code %d\n", code)
+			}
+			outputView.Title = tabStrip()
+			recordRun(tab, r.Key, nil, code, r.Started)
+			if code > 0 {
+				reportProblems(g, tab)
+			}
+			return setResult(g, r.Key, runResult{code: code})
+		})
+	}()
+}
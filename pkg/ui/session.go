@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Limits of the output kept in the session file.
+const (
+	maxSessionTabs   = 10
+	maxSessionOutput = 256 << 10 // bytes per tab, the end is kept
+)
+
+// Session is the state of the UI restored when imake is started again in
+// the same directory.
+type Session struct {
+	Root      string       `json:"root"`              // directory imake was started in
+	Project   string       `json:"project,omitempty"` // project switched to, relative to Root
+	Selected  string       `json:"selected,omitempty"`
+	Filter    string       `json:"filter,omitempty"`
+	Collapsed []string     `json:"collapsed,omitempty"`
+	Sort      string       `json:"sort,omitempty"` // order of the Sidebar, if not the file's
+	Tabs      []SessionTab `json:"tabs,omitempty"`
+	ActiveTab int          `json:"active_tab"`
+	// Scroll is the first line shown in the active tab, or nil to follow
+	// the end of its output.
+	Scroll *int `json:"scroll,omitempty"`
+}
+
+// SessionTab is an output tab of a Session.
+type SessionTab struct {
+	Name   string `json:"name"`
+	Status string `json:"status,omitempty"`
+	Output string `json:"output"`
+}
+
+// sessionPath returns the location of the session file of projectRoot,
+// following the XDG base directory spec.
+func sessionPath() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	sum := sha256.Sum256([]byte(projectRoot))
+	name := filepath.Base(projectRoot) + "-" + hex.EncodeToString(sum[:8]) + ".json"
+	return filepath.Join(dir, "imake", "sessions", name), nil
+}
+
+// saveSession writes the state of the UI to the session file.
+func saveSession(g *gocui.Gui) error {
+	s := Session{Root: projectRoot, ActiveTab: activeTab}
+	if dir, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(projectRoot, dir); err == nil && rel != "." {
+			s.Project = rel
+		}
+	}
+	if sidebar, err := g.View("Sidebar"); err == nil {
+		s.Selected = selectedTarget(sidebar)
+	}
+	if filter, err := g.View("filter"); err == nil {
+		s.Filter = strings.TrimSpace(filter.Buffer())
+	}
+	for group, ok := range collapsed {
+		if ok {
+			s.Collapsed = append(s.Collapsed, group)
+		}
+	}
+	sort.Strings(s.Collapsed)
+	if sortMode != 0 {
+		s.Sort = sortModes[sortMode].name
+	}
+
+	first := 0
+	if len(tabs) > maxSessionTabs {
+		first = len(tabs) - maxSessionTabs
+		s.ActiveTab -= first
+	}
+	for _, t := range tabs[first:] {
+		status := t.status
+		if status == tabRunning {
+			// The command is stopped along with imake.
+			status = tabCancelled
+		}
+		output := t.buf.Bytes()
+		if len(output) > maxSessionOutput {
+			output = output[len(output)-maxSessionOutput:]
+		}
+		s.Tabs = append(s.Tabs, SessionTab{Name: t.name, Status: status, Output: string(output)})
+	}
+	if outputView != nil && !outputView.Autoscroll {
+		_, oy := outputView.Origin()
+		s.Scroll = &oy
+	}
+
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// restoreSession brings back the state saved by saveSession, if any.
+func restoreSession(g *gocui.Gui) error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if s.Project != "" && discoverErr == nil {
+		if err := openProject(g, filepath.Join(projectRoot, s.Project)); err != nil {
+			return err
+		}
+	}
+	for _, group := range s.Collapsed {
+		collapsed[group] = true
+	}
+	sidebar, err := g.View("Sidebar")
+	if err != nil {
+		return err
+	}
+	if setSortMode(s.Sort) {
+		setTargets(targets)
+		setSidebarTitle(sidebar, sidebarFile)
+	}
+	if s.Filter != "" {
+		if err := openFilter(g, sidebar); err != nil {
+			return err
+		}
+		filter, err := g.View("filter")
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(filter, s.Filter)
+		if width, _ := filter.Size(); len(s.Filter) < width {
+			if err := filter.SetCursor(len(s.Filter), 0); err != nil {
+				return err
+			}
+		}
+	}
+	if err := refreshSidebar(g); err != nil {
+		return err
+	}
+	if err := selectTarget(sidebar, s.Selected); err != nil {
+		return err
+	}
+
+	for _, st := range s.Tabs {
+		t := &outputTab{name: st.Name, status: st.Status, progress: -1}
+		t.buf.WriteString(st.Output)
+		tabs = append(tabs, t)
+	}
+	if len(tabs) == 0 {
+		return nil
+	}
+	if s.ActiveTab < 0 || s.ActiveTab >= len(tabs) {
+		s.ActiveTab = len(tabs) - 1
+	}
+	showTab(s.ActiveTab)
+	if s.Scroll != nil {
+		outputView.Autoscroll = false
+		return outputView.SetOrigin(0, *s.Scroll)
+	}
+	return nil
+}
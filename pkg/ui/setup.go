@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/jroimartin/gocui"
+)
+
+// discoverErr is the error reading the build file imake was started
+// with, if any.
+var discoverErr error
+
+// setupChoice is an entry of the setup screen.
+type setupChoice struct {
+	label string
+	run   func(g *gocui.Gui) error
+}
+
+var setupChoices []setupChoice
+
+// openSetup replaces a fatal error about the build file with a screen
+// offering the other runners available here and the nearby projects.
+func openSetup(g *gocui.Gui, cause error) error {
+	setupChoices = nil
+	for _, name := range runner.Available() {
+		r, err := runner.Detect("", name)
+		if err != nil || name == backend.Name() {
+			continue
+		}
+		setupChoices = append(setupChoices, setupChoice{
+			label: fmt.Sprintf("Use %s (%s)", name, r.File()),
+			run:   func(g *gocui.Gui) error { return useBackend(g, r) },
+		})
+	}
+	dirs, _ := runner.FindProjects(projectRoot)
+	for _, dir := range dirs {
+		if dir == "." {
+			continue
+		}
+		path := filepath.Join(projectRoot, dir)
+		setupChoices = append(setupChoices, setupChoice{
+			label: "Open " + dir,
+			run:   func(g *gocui.Gui) error { return openProject(g, path) },
+		})
+	}
+	setupChoices = append(setupChoices, setupChoice{
+		label: "Quit",
+		run:   func(g *gocui.Gui) error { return gocui.ErrQuit },
+	})
+
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	sv, err := g.SetView("setup", x0, y0, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	sv.Title = fmt.Sprintf("%v (Enter to pick another, Esc to quit)", cause)
+	sv.Highlight = true
+	sv.Clear()
+	for _, c := range setupChoices {
+		fmt.Fprintln(sv, c.label)
+	}
+	if err := sv.SetCursor(0, 0); err != nil {
+		return err
+	}
+	if _, err := g.SetViewOnTop("setup"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("setup")
+	return err
+}
+
+// pickSetup runs the selected choice of the setup screen.
+func pickSetup(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(setupChoices) {
+		return nil
+	}
+	choice := setupChoices[cy+oy]
+	if err := g.DeleteView("setup"); err != nil {
+		return err
+	}
+	if _, err := g.SetCurrentView("Sidebar"); err != nil {
+		return err
+	}
+	discoverErr = nil
+	return choice.run(g)
+}
+
+func setupCursorDown(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy+1 >= len(setupChoices) {
+		return nil
+	}
+	return cursorDown(g, v)
+}
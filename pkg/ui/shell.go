@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jroimartin/gocui"
+)
+
+// maxShellTabName is how many characters of a shell command name its tab.
+const maxShellTabName = 24
+
+// shellHistory lists the shell commands run from the prompt this session,
+// oldest first. It is only touched from the gocui main loop.
+var shellHistory []string
+
+// shellCommand returns the command running line with the shell.
+func shellCommand(line string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", line)
+	}
+	return exec.Command("sh", "-c", line)
+}
+
+// shellEditor is the editor of the shell prompt. Up and Down go through
+// the commands run before.
+type shellEditor struct {
+	index int // in shellHistory, len(shellHistory) for a new command
+}
+
+func (e *shellEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	switch key {
+	case gocui.KeyArrowUp:
+		e.recall(v, e.index-1)
+	case gocui.KeyArrowDown:
+		e.recall(v, e.index+1)
+	default:
+		gocui.DefaultEditor.Edit(v, key, ch, mod)
+	}
+}
+
+// recall replaces the prompt with the command at index in shellHistory,
+// or empties it past the last one.
+func (e *shellEditor) recall(v *gocui.View, index int) {
+	if index < 0 || index > len(shellHistory) {
+		return
+	}
+	e.index = index
+	line := ""
+	if index < len(shellHistory) {
+		line = shellHistory[index]
+	}
+	v.Clear()
+	fmt.Fprint(v, line)
+	v.SetOrigin(0, 0)
+	if width, _ := v.Size(); len(line) >= width {
+		v.SetOrigin(len(line)-width+1, 0)
+		v.SetCursor(width-1, 0)
+		return
+	}
+	v.SetCursor(len(line), 0)
+}
+
+// openShellPrompt opens a prompt at the bottom of the screen to run a
+// shell command, such as git status, in a tab of its own.
+func openShellPrompt(g *gocui.Gui, v *gocui.View) error {
+	maxX, maxY := g.Size()
+	prompt, err := g.SetView("shell", 0, maxY-3, maxX-1, maxY-1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	prompt.Title = ": shell command (Enter runs, Up/Down for history, Esc cancels)"
+	prompt.Editable = true
+	prompt.Editor = &shellEditor{index: len(shellHistory)}
+	if _, err := g.SetViewOnTop("shell"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("shell")
+	return err
+}
+
+func closeShellPrompt(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("shell"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+// executeShell runs the command typed in the shell prompt, streaming its
+// output into a tab named after it.
+func executeShell(g *gocui.Gui, v *gocui.View) error {
+	line := strings.TrimSpace(v.Buffer())
+	if err := closeShellPrompt(g, v); err != nil {
+		return err
+	}
+	if line == "" {
+		return nil
+	}
+	if n := len(shellHistory); n == 0 || shellHistory[n-1] != line {
+		shellHistory = append(shellHistory, line)
+	}
+
+	name := "$ " + line
+	if utf8.RuneCountInString(line) > maxShellTabName {
+		name = "$ " + string([]rune(line)[:maxShellTabName-1]) + "…"
+	}
+	if runs.Busy(name) {
+		return showWarning(g, name+" is still running")
+	}
+	if err := closeRunPanes(g); err != nil {
+		return err
+	}
+	tab := openTab(name)
+	tab.reset()
+	return startTabCommand(g, tab, tab, shellCommand(line), nil)
+}
@@ -0,0 +1,336 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+	"github.com/jroimartin/gocui"
+)
+
+// sidebarRow is a line of the Sidebar: a target or the header of a group.
+type sidebarRow struct {
+	target string // "" for a group header
+	group  string
+}
+
+// sidebarRows lists the lines currently rendered in the Sidebar. The
+// selected target is read from it, never from the text on screen, which is
+// shortened and decorated with markers.
+var sidebarRows []sidebarRow
+
+// collapsed holds the groups whose targets are hidden in the Sidebar.
+var collapsed = make(map[string]bool)
+
+// marked lists the targets selected with Space for a parallel run or a
+// queue, in the order they were marked.
+var marked []string
+
+// markIndex returns the position of name in marked, or -1.
+func markIndex(name string) int {
+	for i, m := range marked {
+		if m == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// results holds the outcome of the last run of each target this session.
+var results = make(map[string]runResult)
+
+// runResult is the outcome of a run shown next to its target.
+type runResult struct {
+	running bool
+	code    int
+}
+
+var spinnerFrames = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+// spinnerFrame returns the current frame of the running spinners.
+func spinnerFrame() rune {
+	return spinnerFrames[time.Now().UnixMilli()/100%int64(len(spinnerFrames))]
+}
+
+// resultMarker returns the colored marker of the last run of target, or
+// "" if it has not been run.
+func resultMarker(target string) string {
+	r, ok := results[target]
+	switch {
+	case !ok:
+		return ""
+	case r.running:
+		return " " + runningText(string(spinnerFrame()))
+	case r.code == 0:
+		return " " + successText("✓")
+	default:
+		return " " + failureText("✗")
+	}
+}
+
+// setResult records the outcome of a run of target and redraws the
+// Sidebar.
+func setResult(g *gocui.Gui, target string, r runResult) error {
+	results[target] = r
+	sidebar, err := g.View("Sidebar")
+	if err != nil {
+		return err
+	}
+	return redrawSidebar(sidebar)
+}
+
+// anyRunning reports whether a target shown with a spinner is running.
+func anyRunning() bool {
+	for _, r := range results {
+		if r.running {
+			return true
+		}
+	}
+	return false
+}
+
+// showHidden lists file targets, pattern rules, internal targets and the
+// ones the project ignores in the Sidebar too.
+var showHidden bool
+
+// renderSidebar replaces the Sidebar contents with names and moves the
+// cursor back to the first entry. When grouped, targets annotated with
+// "@group" are listed in collapsible sections after the others.
+func renderSidebar(v *gocui.View, names []string, grouped bool) error {
+	sidebarRows = sidebarRows[:0]
+	var groups []string
+	members := make(map[string][]string)
+	for _, name := range names {
+		t, _ := makefile.Find(targets, name)
+		if !grouped || t.Group == "" || isFavorite(name) {
+			sidebarRows = append(sidebarRows, sidebarRow{target: name})
+			continue
+		}
+		if _, ok := members[t.Group]; !ok {
+			groups = append(groups, t.Group)
+		}
+		members[t.Group] = append(members[t.Group], name)
+	}
+	for _, group := range groups {
+		sidebarRows = append(sidebarRows, sidebarRow{group: group})
+		if collapsed[group] {
+			continue
+		}
+		for _, name := range members[group] {
+			sidebarRows = append(sidebarRows, sidebarRow{target: name, group: group})
+		}
+	}
+
+	if err := redrawSidebar(v); err != nil {
+		return err
+	}
+	if err := v.SetOrigin(0, 0); err != nil {
+		return err
+	}
+	return v.SetCursor(0, 0)
+}
+
+// sidebarWidth is the width the Sidebar was last drawn for.
+var sidebarWidth int
+
+// minBadgedName is how short the badges of a target may make its name.
+const minBadgedName = 12
+
+// truncateMiddle shortens s to width characters by replacing its middle
+// with an ellipsis, keeping both ends, which tell targets like
+// test-integration-api and test-integration-web apart.
+func truncateMiddle(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width < 2 {
+		return string(runes[:max(width, 0)])
+	}
+	head := width / 2
+	tail := width - 1 - head
+	return string(runes[:head]) + "…" + string(runes[len(runes)-tail:])
+}
+
+// visibleLen returns how many characters s takes on screen.
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(filterANSI(s, ANSIStrip))
+}
+
+// redrawSidebar writes sidebarRows to v, keeping the cursor in place.
+// Names too long for the Sidebar are shortened in the middle; the status
+// bar shows the selected one in full.
+func redrawSidebar(v *gocui.View) error {
+	v.Clear()
+	sidebarWidth, _ = v.Size()
+	for _, row := range sidebarRows {
+		if row.target == "" {
+			arrow := "▾"
+			if collapsed[row.group] {
+				arrow = "▸"
+			}
+			group := truncateMiddle(row.group, sidebarWidth-2)
+			if _, err := fmt.Fprintf(v, "\x1b[36;1m%s %s\x1b[0m\n", arrow, group); err != nil {
+				return err
+			}
+			continue
+		}
+		name := row.target
+		prefix, suffix := "", resultMarker(name)
+		if row.group != "" {
+			prefix = "  "
+		}
+		if isFavorite(name) {
+			prefix = "\x1b[33m★\x1b[0m " + prefix
+		}
+		if i := markIndex(name); i >= 0 {
+			suffix += fmt.Sprintf(" \x1b[33m*%d\x1b[0m", i+1)
+		}
+		if badges := targetBadges(name); badges != "" && sidebarWidth-visibleLen(prefix+suffix+badges) >= min(utf8.RuneCountInString(strings.TrimPrefix(name, row.group+":")), minBadgedName) {
+			// Badges only take the room the name does not need.
+			suffix = badges + suffix
+		}
+		// Within its group, a target named after the group, such as
+		// services/api:build, is shown by the rest of its name.
+		label := strings.TrimPrefix(name, row.group+":")
+		if row.group == "" {
+			label = name
+		}
+		shown := truncateMiddle(label, sidebarWidth-visibleLen(prefix)-visibleLen(suffix))
+		if t, ok := makefile.Find(targets, name); ok && t.Kind != makefile.KindTarget {
+			shown = "\x1b[38;5;8m" + shown + "\x1b[0m"
+		}
+		if _, err := fmt.Fprintln(v, prefix+shown+suffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// layoutSidebar redraws the Sidebar when its width changed, to shorten
+// the names to the new width. It is called by the manager.
+func layoutSidebar(g *gocui.Gui) error {
+	v, err := g.View("Sidebar")
+	if err != nil {
+		return nil
+	}
+	if width, _ := v.Size(); width != sidebarWidth {
+		return redrawSidebar(v)
+	}
+	return nil
+}
+
+// selectedRow returns the Sidebar line under the cursor of v.
+func selectedRow(v *gocui.View) (sidebarRow, bool) {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if i := cy + oy; i >= 0 && i < len(sidebarRows) {
+		return sidebarRows[i], true
+	}
+	return sidebarRow{}, false
+}
+
+// selectedTarget returns the name of the target under the cursor of the
+// Sidebar view v, or "" if the cursor is on a group header or past the
+// last target.
+func selectedTarget(v *gocui.View) string {
+	row, _ := selectedRow(v)
+	return row.target
+}
+
+// selectTarget moves the cursor of v to target if it is shown.
+func selectTarget(v *gocui.View, target string) error {
+	for i, row := range sidebarRows {
+		if row.target == target {
+			return selectLine(v, i)
+		}
+	}
+	return nil
+}
+
+// toggleGroup collapses or expands the group under the cursor of v,
+// reporting whether the cursor was on a group header.
+func toggleGroup(g *gocui.Gui, v *gocui.View) (bool, error) {
+	row, ok := selectedRow(v)
+	if !ok || row.target != "" {
+		return false, nil
+	}
+	collapsed[row.group] = !collapsed[row.group]
+	if err := refreshSidebar(g); err != nil {
+		return true, err
+	}
+	for i, r := range sidebarRows {
+		if r.target == "" && r.group == row.group {
+			return true, selectLine(v, i)
+		}
+	}
+	return true, nil
+}
+
+// selectLine moves the cursor of v to line, scrolling it into view.
+func selectLine(v *gocui.View, line int) error {
+	_, height := v.Size()
+	oy := 0
+	if line >= height {
+		oy = line - height + 1
+	}
+	if err := v.SetOrigin(0, oy); err != nil {
+		return err
+	}
+	return v.SetCursor(0, line-oy)
+}
+
+// toggleMark marks or unmarks the selected target for a parallel run or a
+// queue and moves on to the next one.
+func toggleMark(g *gocui.Gui, v *gocui.View) error {
+	name := selectedTarget(v)
+	if name == "" {
+		return nil
+	}
+	if i := markIndex(name); i >= 0 {
+		marked = append(marked[:i], marked[i+1:]...)
+	} else {
+		marked = append(marked, name)
+	}
+	if err := redrawSidebar(v); err != nil {
+		return err
+	}
+	// The redrawn view has no lines to move the cursor over until the next
+	// frame, so select the next line directly.
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if next := cy + oy + 1; next < len(sidebarRows) {
+		return selectLine(v, next)
+	}
+	return nil
+}
+
+// refreshSidebar renders targetNames again, honouring an open filter and
+// keeping the selected target selected when it is still shown.
+func refreshSidebar(g *gocui.Gui) error {
+	sidebar, err := g.View("Sidebar")
+	if err != nil {
+		return err
+	}
+	selected := selectedTarget(sidebar)
+
+	if filter, err := g.View("filter"); err == nil {
+		err = renderSidebar(sidebar, fuzzyFilter(strings.TrimSpace(filter.Buffer()), targetNames), false)
+	} else {
+		err = renderSidebar(sidebar, targetNames, true)
+	}
+	if err != nil {
+		return err
+	}
+	return selectTarget(sidebar, selected)
+}
+
+// toggleHidden shows or hides file targets, pattern rules, internal
+// targets and the ones the project ignores.
+func toggleHidden(g *gocui.Gui, v *gocui.View) error {
+	showHidden = !showHidden
+	setTargets(targets)
+	return refreshSidebar(g)
+}
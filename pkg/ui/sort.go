@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// sortModes are the orders the Sidebar lists the targets in, in the order
+// cycleSort goes through them. The first is the order of the build file.
+var sortModes = []struct {
+	name  string // as saved in the session
+	title string // shown in the title of the Sidebar
+}{
+	{"file", ""},
+	{"name", "a-z"},
+	{"recent", "recent"},
+	{"runs", "most run"},
+}
+
+// sortMode is the index in sortModes of the order of the Sidebar.
+var sortMode int
+
+// sidebarFile is the build file named in the title of the Sidebar.
+var sidebarFile string
+
+// setSidebarTitle names file, the build file of the targets, in the title
+// of the Sidebar v, followed by the sort order unless it is the file's.
+func setSidebarTitle(v *gocui.View, file string) {
+	sidebarFile = file
+	v.Title = fmt.Sprintf("Targets (%s)", file)
+	if title := sortModes[sortMode].title; title != "" {
+		v.Title = fmt.Sprintf("Targets (%s, %s)", file, title)
+	}
+}
+
+// setSortMode selects the sort order called name, reporting whether there
+// is one.
+func setSortMode(name string) bool {
+	for i, mode := range sortModes {
+		if mode.name == name {
+			sortMode = i
+			return true
+		}
+	}
+	return false
+}
+
+// sortTargets orders names, which are in the order of the build file, by
+// the current sort mode. Targets that never ran come last in the recent
+// and most run orders, still in the order of the file.
+func sortTargets(names []string) {
+	switch sortModes[sortMode].name {
+	case "name":
+		slices.SortStableFunc(names, strings.Compare)
+	case "recent":
+		slices.SortStableFunc(names, func(a, b string) int {
+			return lastStarted(b).Compare(lastStarted(a))
+		})
+	case "runs":
+		slices.SortStableFunc(names, func(a, b string) int {
+			return runCount(b) - runCount(a)
+		})
+	}
+}
+
+// lastStarted returns when target last started, according to its statistics,
+// or the zero time.
+func lastStarted(target string) time.Time {
+	if s := targetStats(target); s != nil {
+		return s.LastRun
+	}
+	return time.Time{}
+}
+
+// runCount returns how many times target ran, according to its statistics.
+func runCount(target string) int {
+	if s := targetStats(target); s != nil {
+		return s.Runs
+	}
+	return 0
+}
+
+// cycleSort switches the Sidebar to the next sort order, which is kept in
+// the session.
+func cycleSort(g *gocui.Gui, v *gocui.View) error {
+	sortMode = (sortMode + 1) % len(sortModes)
+	setTargets(targets)
+	sidebar, err := g.View("Sidebar")
+	if err != nil {
+		return err
+	}
+	setSidebarTitle(sidebar, sidebarFile)
+	if err := refreshSidebar(g); err != nil {
+		return err
+	}
+	if sortMode == 0 {
+		return showNotice("targets in the order of " + sidebarFile)
+	}
+	return showNotice(fmt.Sprintf("sort: %s (# for the next order)", sortModes[sortMode].title))
+}
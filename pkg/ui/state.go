@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"io"
+	"sync"
+
+	"github.com/gshireesh/imake/pkg/runner"
+)
+
+// resetState returns the state the UI keeps in package variables to what
+// it is before the first Run, so that a program embedding imake can run
+// it again without the tabs, marks, sudo grants and config of the last
+// Run. Tables that never change, such as actions, are left alone.
+func resetState() {
+	// Config and what it sets.
+	config, project, configErrors = Config{}, ProjectConfig{}, nil
+	backend, ansiMode, restoreLast = nil, ANSIRender, false
+	outputLines, logging, dotenv, timestamps = defaultOutputLines, false, false, false
+	makeFlags, highlights, remoteHost = nil, nil, ""
+	zero(&colors)
+	zero(&runOnStart)
+
+	// Targets and the Sidebar.
+	targets, targetNames, discoverErr = nil, nil, nil
+	sidebarRows, shownRows, marked = nil, nil, nil
+	collapsed = make(map[string]bool)
+	results = make(map[string]runResult)
+	badgeFilter, showHidden, sidebarWidth = "", false, 0
+	sortMode, sidebarFile = 0, ""
+	projectRoot, projects, recentProjects = "", nil, nil
+	history, stats, shellHistory = nil, nil, nil
+	diagnostics = nil
+	zero(&variables)
+
+	// Commands and their output.
+	runs = runner.NewRunManager()
+	runOutputs = make(map[int]io.Writer)
+	sudoTargets, sudoPending = make(map[string]bool), nil
+	tabs, activeTab, outputView, interactTab = nil, -1, nil, nil
+	runPanes, zoomed = nil, false
+	compare, compareView = compareSource{}, nil
+	queue, queueNext, jobs = nil, 0, nil
+	zero(&runState)
+	zero(&lastRun)
+	zero(&ptySize)
+
+	// Pickers, prompts and input.
+	presetChoices, presetTarget, setupChoices, hostNames = nil, "", nil, nil
+	paletteItems, paletteShown = nil, nil
+	zero(&recording)
+	replaying, reservedMacroKeys = false, nil
+	zero(&selection)
+	zero(&message)
+	zero(&lastClick)
+	zero(&lastTap)
+
+	watcher.Lock()
+	watcher.w, watcher.files = nil, nil
+	watcher.Unlock()
+	crashGui, crashErr, crashOnce = nil, nil, sync.Once{}
+}
+
+// zero sets *p to the zero value of its type.
+func zero[T any](p *T) {
+	var z T
+	*p = z
+}
@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// maxDurations is how many durations of the last runs of a target are
+// kept for its median duration.
+const maxDurations = 50
+
+// TargetStats sums up every run of a target, unlike the history, which
+// only keeps the last runs.
+type TargetStats struct {
+	Runs        int             `json:"runs"`
+	Failures    int             `json:"failures"`
+	Total       time.Duration   `json:"total"`     // duration of all runs
+	Durations   []time.Duration `json:"durations"` // of the last runs, oldest first
+	LastFailure time.Time       `json:"last_failure"`
+	LastRun     time.Time       `json:"last_run"`
+}
+
+// Average returns the mean duration of the runs.
+func (s *TargetStats) Average() time.Duration {
+	if s.Runs == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Runs)
+}
+
+// Median returns the median duration of the last runs.
+func (s *TargetStats) Median() time.Duration {
+	if len(s.Durations) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(s.Durations)
+	slices.Sort(sorted)
+	n := len(sorted)
+	if n%2 == 0 {
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return sorted[n/2]
+}
+
+// SuccessRate returns the share of the runs that succeeded, from 0 to 1.
+func (s *TargetStats) SuccessRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Runs-s.Failures) / float64(s.Runs)
+}
+
+// stats holds the statistics of the targets by build file, then target.
+// It is only touched from the gocui main loop.
+var stats map[string]map[string]*TargetStats
+
+// statsPath returns the location of the statistics file, next to the run
+// history.
+func statsPath() (string, error) {
+	path, err := historyPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "stats.json"), nil
+}
+
+func loadStats() error {
+	path, err := statsPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &stats)
+}
+
+func saveStats() error {
+	path, err := statsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// addStats counts a finished run in the statistics of its target and
+// persists them.
+func addStats(entry HistoryEntry) error {
+	if stats == nil {
+		stats = make(map[string]map[string]*TargetStats)
+	}
+	byTarget := stats[entry.Makefile]
+	if byTarget == nil {
+		byTarget = make(map[string]*TargetStats)
+		stats[entry.Makefile] = byTarget
+	}
+	s := byTarget[entry.Target]
+	if s == nil {
+		s = &TargetStats{}
+		byTarget[entry.Target] = s
+	}
+	s.Runs++
+	s.LastRun = entry.Started
+	s.Total += entry.Duration
+	s.Durations = append(s.Durations, entry.Duration)
+	if len(s.Durations) > maxDurations {
+		s.Durations = s.Durations[len(s.Durations)-maxDurations:]
+	}
+	if entry.ExitCode != 0 {
+		s.Failures++
+		s.LastFailure = entry.Started
+	}
+	return saveStats()
+}
+
+// targetStats returns the statistics of target in the current build file,
+// or nil if it never ran.
+func targetStats(target string) *TargetStats {
+	return stats[absBuildFile()][target]
+}
+
+// formatStats sums up s on one line.
+func formatStats(s *TargetStats) string {
+	rate := fmt.Sprintf("%.0f%%", 100*s.SuccessRate())
+	switch {
+	case s.Failures == 0:
+		rate = successText(rate)
+	case s.Failures*2 > s.Runs:
+		if config.Accessibility {
+			rate = "✗ " + rate
+		}
+		rate = failureText(rate)
+	default:
+		rate = "\x1b[33m" + rate + "\x1b[0m"
+	}
+	line := fmt.Sprintf("%d runs, %s ok, avg %s, median %s", s.Runs, rate,
+		s.Average().Round(10*time.Millisecond), s.Median().Round(10*time.Millisecond))
+	if !s.LastFailure.IsZero() {
+		line += ", last failed " + s.LastFailure.Format("01-02 15:04")
+	}
+	return line
+}
+
+// toggleStats opens or closes the statistics of the targets of the
+// project on top of the Command Output view, slowest first.
+func toggleStats(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("stats"); err == nil {
+		return closeStats(g, v)
+	}
+	byTarget := stats[absBuildFile()]
+	if len(byTarget) == 0 {
+		return showWarning(g, "stats: no runs recorded yet")
+	}
+
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	sv, err := g.SetView("stats", x0, y0, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	sv.Title = "Target statistics (Esc to close)"
+	sv.Highlight = true
+	sv.Clear()
+	names := make([]string, 0, len(byTarget))
+	width := 0
+	for name := range byTarget {
+		names = append(names, name)
+		width = max(width, len(name))
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := byTarget[names[i]].Average(), byTarget[names[j]].Average()
+		if a != b {
+			return a > b
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		fmt.Fprintf(sv, "%-*s  %s\n", width, name, formatStats(byTarget[name]))
+	}
+	if _, err := g.SetViewOnTop("stats"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("stats")
+	return err
+}
+
+func closeStats(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("stats"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
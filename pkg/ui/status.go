@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// runState summarises command activity for the status bar. It is only
+// touched from the gocui main loop.
+var runState struct {
+	active   int       // number of commands running
+	command  string    // command line of the most recently started command
+	started  time.Time // start of the most recently started command
+	finished bool      // whether a command has finished yet
+	lastCode int       // exit code of the most recently finished command
+}
+
+// commandStarted and commandFinished keep runState up to date.
+func commandStarted(commandLine string, started time.Time) {
+	runState.active++
+	runState.command = commandLine
+	runState.started = started
+}
+
+func commandFinished(code int) {
+	runState.active--
+	runState.finished = true
+	runState.lastCode = code
+}
+
+// layoutStatus places the one-line status bar below the grid.
+func layoutStatus(g *gocui.Gui) error {
+	maxX, maxY := g.Size()
+	v, err := g.SetView("status", -1, maxY-2, maxX, maxY)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	v.Frame = false
+	return nil
+}
+
+// updateStatus redraws the status bar.
+func updateStatus(g *gocui.Gui) error {
+	v, err := g.View("status")
+	if err != nil {
+		return err
+	}
+	sidebar, err := g.View("Sidebar")
+	if err != nil {
+		return err
+	}
+
+	parts := []string{"target: " + selectedTarget(sidebar)}
+	if s := macroStatus(); s != "" {
+		parts = append(parts, s)
+	}
+	if runState.active > 0 {
+		elapsed := time.Since(runState.started).Truncate(time.Second)
+		running := fmt.Sprintf("%s %s (%s)", runningText("▶ running"), runState.command, elapsed)
+		if activeTab >= 0 && tabs[activeTab].status == tabRunning && tabs[activeTab].progress >= 0 {
+			running += " " + runningText(progressBar(tabs[activeTab].progress, 10))
+		}
+		parts = append(parts, running)
+	} else {
+		parts = append(parts, "idle")
+	}
+	if selection.active {
+		first, last := selectedRange()
+		parts = append(parts, fmt.Sprintf("\x1b[35mselecting\x1b[0m %d lines (y to copy, Esc to cancel)", last-first+1))
+	}
+	if runState.finished {
+		if runState.lastCode == 0 {
+			parts = append(parts, "last: "+successText("✓ 0"))
+		} else {
+			parts = append(parts, "last: "+failureText(fmt.Sprintf("✗ %d", runState.lastCode)))
+		}
+	}
+	if config.Layout.HideHelp || zoomed {
+		if text := currentMessage(); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	if badgeFilter != "" {
+		parts = append(parts, "only "+formatBadge(badgeFilter))
+	}
+	if n := len(diagnostics); n > 0 {
+		parts = append(parts, failureText(fmt.Sprintf("⚠ %d build file problem(s)", n))+" (W)")
+	}
+	if logging {
+		parts = append(parts, "\x1b[31m●\x1b[0m log")
+	}
+	if files := loadedDotenvFiles(); len(files) > 0 {
+		parts = append(parts, "env: "+strings.Join(files, ", "))
+	}
+	if remoteHost != "" {
+		parts = append(parts, "\x1b[36mssh\x1b[0m "+remoteHost)
+	}
+	parts = append(parts, workingDir())
+
+	v.Clear()
+	fmt.Fprint(v, " "+strings.Join(parts, " │ "))
+	return nil
+}
+
+// workingDir returns the current directory with the home directory
+// abbreviated to ~.
+func workingDir() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "?"
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+	}
+	return dir
+}
+
+// tickStatus redraws the UI regularly so the elapsed time in the status
+// bar and the spinners of running targets stay live.
+func tickStatus(g *gocui.Gui, stop <-chan struct{}) {
+	defer recoverCrash()
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			g.Update(func(g *gocui.Gui) error {
+				if !anyRunning() && !tabsRunning() {
+					return nil
+				}
+				if outputView != nil {
+					outputView.Title = tabStrip()
+				}
+				sidebar, err := g.View("Sidebar")
+				if err != nil {
+					return err
+				}
+				return redrawSidebar(sidebar)
+			})
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// sudoTargets are the targets run with run_sudo this session, which keep
+// running under sudo when they are run again.
+var sudoTargets = make(map[string]bool)
+
+// sudoRun is a run of startTarget waiting for the password of sudo.
+type sudoRun struct {
+	target string
+	args   []string
+	done   func(g *gocui.Gui, tab *outputTab, code int)
+}
+
+// sudoPending is the run waiting for the password prompt, or nil.
+var sudoPending *sudoRun
+
+// needsSudo reports whether target runs under sudo: it is listed under
+// sudo: in the project config or was run with run_sudo.
+func needsSudo(target string) bool {
+	if sudoTargets[target] {
+		return true
+	}
+	for _, name := range project.Sudo {
+		if name == target {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSudo returns why targets cannot be run under sudo here, or nil.
+func checkSudo() error {
+	if runtime.GOOS == "windows" {
+		return errors.New("sudo is not supported on Windows, run imake as an administrator instead")
+	}
+	if remoteHost != "" {
+		return errors.New("only local targets can be run under sudo")
+	}
+	if _, err := exec.LookPath("sudo"); err != nil {
+		return errors.New("sudo is not installed")
+	}
+	return nil
+}
+
+// sudoCached reports whether sudo runs commands without asking for the
+// password, because it was given recently or is not needed.
+func sudoCached() bool {
+	return os.Geteuid() == 0 || exec.Command("sudo", "-n", "true").Run() == nil
+}
+
+// sudoCommand returns cmd run under sudo, which must not ask for the
+// password. The environment cmd sets and the variables of the env files
+// are passed on, as sudo resets the environment otherwise.
+func sudoCommand(cmd *exec.Cmd) *exec.Cmd {
+	if os.Geteuid() == 0 {
+		return cmd
+	}
+	names := make(map[string]bool)
+	for _, kv := range envOverrides(cmd) {
+		name, _, _ := strings.Cut(kv, "=")
+		names[name] = true
+	}
+	for _, path := range loadedDotenvFiles() {
+		// Errors are reported when the command starts.
+		env, _ := parseDotenv(path)
+		for _, kv := range env {
+			name, _, _ := strings.Cut(kv, "=")
+			names[name] = true
+		}
+	}
+	args := []string{"-n"}
+	if len(names) > 0 {
+		var list []string
+		for name := range names {
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		args = append(args, "--preserve-env="+strings.Join(list, ","))
+	}
+	args = append(append(args, "--"), cmd.Args...)
+
+	sudo := exec.Command("sudo", args...)
+	sudo.Dir, sudo.Env = cmd.Dir, cmd.Env
+	return sudo
+}
+
+// isSudoCommand reports whether cmd was made by sudoCommand. It runs
+// without a pseudo-terminal: sudo remembers the password per terminal, and
+// a new one would not know it was given in imake's.
+func isSudoCommand(cmd *exec.Cmd) bool {
+	return len(cmd.Args) > 1 && cmd.Args[0] == "sudo" && cmd.Args[1] == "-n"
+}
+
+// runSudo runs the selected target under sudo, and keeps doing so when it
+// is run again this session.
+func runSudo(g *gocui.Gui, v *gocui.View) error {
+	target := selectedTarget(v)
+	if target == "" {
+		return nil
+	}
+	if err := checkSudo(); err != nil {
+		return showWarning(g, "sudo: "+err.Error())
+	}
+	sudoTargets[target] = true
+	return runTarget(g, target, nil)
+}
+
+// openSudoPrompt asks for the password sudo needs to run target, which is
+// then started as startTarget would have.
+func openSudoPrompt(g *gocui.Gui, target string, args []string, done func(g *gocui.Gui, tab *outputTab, code int), title string) error {
+	sudoPending = &sudoRun{target, args, done}
+
+	maxX, maxY := g.Size()
+	prompt, err := g.SetView("sudo", maxX/4, maxY/2-1, maxX*3/4, maxY/2+1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	prompt.Title = title
+	prompt.Editable = true
+	prompt.Mask = '*'
+	prompt.Clear()
+	prompt.SetCursor(0, 0)
+	prompt.SetOrigin(0, 0)
+	if _, err := g.SetViewOnTop("sudo"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("sudo")
+	return err
+}
+
+// submitSudoPassword has sudo check the typed password, which it then
+// remembers for a while, and starts the waiting run. The password is not
+// kept.
+func submitSudoPassword(g *gocui.Gui, v *gocui.View) error {
+	password := strings.TrimSuffix(v.Buffer(), "\n")
+	v.Clear()
+	pending := sudoPending
+	if err := deleteSudoPrompt(g); err != nil {
+		return err
+	}
+	if pending == nil {
+		return nil
+	}
+	showNotice("sudo: checking the password…")
+	go func() {
+		defer recoverCrash()
+		check := exec.Command("sudo", "-S", "-p", "", "-v")
+		check.Stdin = strings.NewReader(password + "\n")
+		out, err := check.CombinedOutput()
+		g.Update(func(g *gocui.Gui) error {
+			showNotice("")
+			if err != nil {
+				title := "sudo: wrong password, try again (Esc cancels)"
+				if msg := strings.TrimSpace(string(out)); msg != "" && !strings.Contains(msg, "try again") {
+					title = "sudo: " + msg
+				}
+				return openSudoPrompt(g, pending.target, pending.args, pending.done, title)
+			}
+			return startTarget(g, pending.target, pending.args, pending.done)
+		})
+	}()
+	return nil
+}
+
+// cancelSudoPrompt closes the password prompt without running the target,
+// which counts as a failed run for a queue waiting on it.
+func cancelSudoPrompt(g *gocui.Gui, v *gocui.View) error {
+	pending := sudoPending
+	if err := deleteSudoPrompt(g); err != nil {
+		return err
+	}
+	if pending == nil {
+		return nil
+	}
+	tab := openTab(pending.target)
+	tab.reset()
+	fmt.Fprintf(tab, "\x1b[31msudo: no password given, %s was not run\x1b[0m\n", pending.target)
+	tab.status = tabFailed
+	outputView.Title = tabStrip()
+	if pending.done != nil {
+		pending.done(g, tab, -1)
+	}
+	return nil
+}
+
+func deleteSudoPrompt(g *gocui.Gui) error {
+	sudoPending = nil
+	if err := g.DeleteView("sudo"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
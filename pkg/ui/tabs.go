@@ -0,0 +1,247 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Tab status icons.
+const (
+	tabRunning   = "▶"
+	tabSuccess   = "✓"
+	tabFailed    = "✗"
+	tabCancelled = "■"
+)
+
+// outputTab keeps the output of one target so that running another target
+// does not lose it. Only the active tab is shown in the Command Output
+// view; writes to other tabs are buffered until they are switched to.
+type outputTab struct {
+	name   string
+	buf    outputBuffer
+	status string
+	input  *commandInput // input of the running command, nil once it exits
+	// problems are the errors found in the output of the last run if it
+	// failed.
+	problems []problem
+	// previous is the run before the one in buf, kept to compare them.
+	previous *pastRun
+	started  time.Time // start of the last run
+	// command is the command line of the last run and env the variables it
+	// set; code and duration are known once it exited.
+	command  string
+	env      []string
+	code     int
+	duration time.Duration
+	// shownDropped is how many lines buf had dropped when it was last
+	// drawn in full.
+	shownDropped int
+	// sectioned is set once the output shows a recursive make, to draw it
+	// in sections, folded by the line they start at in folded. redraw is
+	// set when output arrived since the tab was last drawn.
+	sectioned bool
+	folded    map[int]bool
+	redraw    bool
+	// bookmarks are the lines of the run bookmarked, in order.
+	bookmarks []int
+	// progress is how far along the running build is, between 0 and 1, as
+	// its output last showed, or -1 if it did not.
+	progress float64
+}
+
+// pastRun is the output of a finished run and its status icon.
+type pastRun struct {
+	output []byte
+	status string
+}
+
+// tabs lists the open tabs in the order they were opened; activeTab is the
+// index of the one shown. Both are only touched from the gocui main loop.
+var (
+	tabs      []*outputTab
+	activeTab = -1
+	// outputView is the Command Output view tabs are displayed in.
+	outputView *gocui.View
+)
+
+func (t *outputTab) Write(p []byte) (int, error) {
+	t.buf.Write(p)
+	if t.status == tabRunning {
+		if fraction, ok := parseProgress(filterANSI(string(p), ANSIStrip)); ok {
+			t.progress = fraction
+		}
+	}
+	if !t.sectioned && bytes.Contains(p, []byte(": Entering directory")) {
+		t.sectioned = true
+	}
+	// The views keep every line written to them, so they are redrawn from
+	// the buffer once it dropped a quarter of its lines.
+	if outputLines > 0 && t.buf.dropped-t.shownDropped > outputLines/4 {
+		t.shownDropped = t.buf.dropped
+		if compare.tab == t && !compare.previous {
+			redrawCompare()
+		}
+		if activeTab >= 0 && tabs[activeTab] == t && outputView != nil {
+			drawTab(t)
+		}
+		return len(p), nil
+	}
+	if compare.tab == t && !compare.previous && compareView != nil {
+		compareView.Write(p)
+	}
+	if t.sectioned {
+		// Sections are redrawn from the buffer by layoutSections.
+		t.redraw = true
+		return len(p), nil
+	}
+	if activeTab >= 0 && tabs[activeTab] == t && outputView != nil {
+		return outputView.Write(p)
+	}
+	return len(p), nil
+}
+
+// reset empties the tab before it is reused for a new run.
+func (t *outputTab) reset() {
+	if t.buf.Len() > 0 {
+		t.previous = &pastRun{output: append([]byte(nil), t.buf.Bytes()...), status: t.status}
+	}
+	t.buf.Reset()
+	t.shownDropped = 0
+	t.sectioned, t.folded, t.redraw = false, nil, false
+	t.bookmarks = nil
+	t.problems = nil
+	t.progress = -1
+	if compare.tab == t {
+		redrawCompare()
+	}
+	if activeTab >= 0 && tabs[activeTab] == t && outputView != nil {
+		outputView.Clear()
+	}
+}
+
+// openTab returns the tab called name, creating it if needed, and makes it
+// the active tab.
+func openTab(name string) *outputTab {
+	for i, t := range tabs {
+		if t.name == name {
+			showTab(i)
+			return t
+		}
+	}
+	tabs = append(tabs, &outputTab{name: name, progress: -1})
+	showTab(len(tabs) - 1)
+	return tabs[len(tabs)-1]
+}
+
+// showTab makes tab i the active tab and redraws the output view with its
+// contents.
+func showTab(i int) {
+	if i < 0 || i >= len(tabs) {
+		return
+	}
+	activeTab = i
+	selection.active = false
+	if outputView == nil {
+		return
+	}
+	outputView.Autoscroll = true
+	drawTab(tabs[i])
+	tabs[i].shownDropped = tabs[i].buf.dropped
+	outputView.Title = tabStrip()
+}
+
+// tabStrip renders the tab names and status icons for the output title,
+// with the active tab in brackets.
+func tabStrip() string {
+	if len(tabs) == 0 {
+		return "Command Output"
+	}
+	parts := make([]string, len(tabs))
+	for i, t := range tabs {
+		label := fmt.Sprintf("%d:%s", i+1, t.name)
+		switch t.status {
+		case "":
+		case tabRunning:
+			label += " " + string(spinnerFrame())
+			if t.progress >= 0 {
+				label += " " + strconv.Itoa(int(t.progress*100)) + "%"
+			}
+		default:
+			label += " " + t.status
+		}
+		if i == activeTab {
+			label = "[" + label + "]"
+		}
+		parts[i] = label
+	}
+	return " " + strings.Join(parts, " │ ") + " "
+}
+
+// currentOutput returns where messages about the current run go: the
+// active tab if there is one, else the output view itself.
+func currentOutput() io.Writer {
+	if activeTab >= 0 {
+		return tabs[activeTab]
+	}
+	return outputView
+}
+
+// nextTab and prevTab switch the active tab, or the buffer shown in the
+// compare pane while it is focused.
+func nextTab(g *gocui.Gui, v *gocui.View) error {
+	if v != nil && v.Name() == "compare" {
+		return cycleCompare(g, 1)
+	}
+	if len(tabs) > 0 {
+		showTab((activeTab + 1) % len(tabs))
+	}
+	return nil
+}
+
+func prevTab(g *gocui.Gui, v *gocui.View) error {
+	if v != nil && v.Name() == "compare" {
+		return cycleCompare(g, -1)
+	}
+	if len(tabs) > 0 {
+		showTab((activeTab + len(tabs) - 1) % len(tabs))
+	}
+	return nil
+}
+
+// closeTab closes the active tab unless its command is still running.
+func closeTab(g *gocui.Gui, v *gocui.View) error {
+	if activeTab < 0 || tabs[activeTab].status == tabRunning {
+		return nil
+	}
+	if compare.tab == tabs[activeTab] {
+		if err := closeCompare(g, v); err != nil {
+			return err
+		}
+	}
+	tabs = append(tabs[:activeTab], tabs[activeTab+1:]...)
+	if len(tabs) == 0 {
+		activeTab = -1
+		outputView.Clear()
+		outputView.Title = tabStrip()
+		return nil
+	}
+	if activeTab >= len(tabs) {
+		activeTab = len(tabs) - 1
+	}
+	showTab(activeTab)
+	return nil
+}
+
+// selectTab returns a handler switching to tab n (1-based).
+func selectTab(n int) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		showTab(n - 1)
+		return nil
+	}
+}
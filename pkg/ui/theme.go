@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+	"gopkg.in/yaml.v3"
+)
+
+// Theme holds the colors of the UI. Colors are names (default, black, red,
+// green, yellow, blue, magenta, cyan, white) or numbers of the 256-color
+// palette; empty fields keep the color of the base theme.
+type Theme struct {
+	// Base is the built-in theme the other fields override.
+	Base        string `yaml:"base"`
+	Fg          string `yaml:"fg"`
+	Bg          string `yaml:"bg"`
+	SelectionFg string `yaml:"selection_fg"`
+	SelectionBg string `yaml:"selection_bg"`
+	// Frame colors the borders and titles of the panes, Title the border
+	// and title of the focused one.
+	Frame    string `yaml:"frame"`
+	Title    string `yaml:"title"`
+	StatusFg string `yaml:"status_fg"`
+	StatusBg string `yaml:"status_bg"`
+}
+
+// UnmarshalYAML accepts the name of a built-in theme as well as a full
+// theme.
+func (t *Theme) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = Theme{Base: node.Value}
+		return nil
+	}
+	type plain Theme
+	return node.Decode((*plain)(t))
+}
+
+// themes are the built-in themes. dark is the default, high-contrast the
+// default with accessibility on.
+var themes = map[string]Theme{
+	"dark": {
+		Fg: "default", Bg: "default",
+		SelectionFg: "black", SelectionBg: "blue",
+		Frame: "default", Title: "green",
+		StatusFg: "default", StatusBg: "default",
+	},
+	"light": {
+		Fg: "default", Bg: "default",
+		SelectionFg: "black", SelectionBg: "153",
+		Frame: "245", Title: "24",
+		StatusFg: "238", StatusBg: "254",
+	},
+	"high-contrast": {
+		Fg: "white", Bg: "black",
+		SelectionFg: "black", SelectionBg: "226",
+		Frame: "white", Title: "226",
+		StatusFg: "black", StatusBg: "white",
+	},
+	"solarized": {
+		Fg: "246", Bg: "234",
+		SelectionFg: "230", SelectionBg: "33",
+		Frame: "240", Title: "136",
+		StatusFg: "246", StatusBg: "235",
+	},
+}
+
+// colors are the resolved colors of the configured theme.
+var colors struct {
+	fg, bg, selFg, selBg, frame, title, statusFg, statusBg gocui.Attribute
+}
+
+var colorNames = map[string]gocui.Attribute{
+	"default": gocui.ColorDefault,
+	"black":   gocui.ColorBlack,
+	"red":     gocui.ColorRed,
+	"green":   gocui.ColorGreen,
+	"yellow":  gocui.ColorYellow,
+	"blue":    gocui.ColorBlue,
+	"magenta": gocui.ColorMagenta,
+	"cyan":    gocui.ColorCyan,
+	"white":   gocui.ColorWhite,
+}
+
+// parseColor converts a color name or palette number into an attribute.
+func parseColor(name string) (gocui.Attribute, error) {
+	if c, ok := colorNames[strings.ToLower(name)]; ok {
+		return c, nil
+	}
+	n, err := strconv.Atoi(name)
+	if err != nil || n < 0 || n > 255 {
+		return 0, fmt.Errorf("unknown color %q", name)
+	}
+	// In 256-color mode termbox numbers the palette from 1.
+	return gocui.Attribute(n + 1), nil
+}
+
+// loadTheme resolves config.Theme into colors, reporting invalid colors
+// and falling back to the base theme for them, or to the dark theme for an
+// unknown base.
+func loadTheme() []string {
+	var problems []string
+	base := config.Theme.Base
+	if base == "" {
+		base = "dark"
+		if config.Accessibility {
+			base = "high-contrast"
+		}
+	}
+	theme, ok := themes[base]
+	if !ok {
+		var names []string
+		for name := range themes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		problems = append(problems, fmt.Sprintf("theme: unknown theme %q: must be one of %s", base, strings.Join(names, ", ")))
+		theme = themes["dark"]
+	}
+
+	fields := []struct {
+		name        string
+		value, base string
+		color       *gocui.Attribute
+	}{
+		{"fg", config.Theme.Fg, theme.Fg, &colors.fg},
+		{"bg", config.Theme.Bg, theme.Bg, &colors.bg},
+		{"selection_fg", config.Theme.SelectionFg, theme.SelectionFg, &colors.selFg},
+		{"selection_bg", config.Theme.SelectionBg, theme.SelectionBg, &colors.selBg},
+		{"frame", config.Theme.Frame, theme.Frame, &colors.frame},
+		{"title", config.Theme.Title, theme.Title, &colors.title},
+		{"status_fg", config.Theme.StatusFg, theme.StatusFg, &colors.statusFg},
+		{"status_bg", config.Theme.StatusBg, theme.StatusBg, &colors.statusBg},
+	}
+	for _, f := range fields {
+		value := f.base
+		if f.value != "" {
+			value = f.value
+		}
+		c, err := parseColor(value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("theme.%s: %v", f.name, err))
+			c, _ = parseColor(f.base)
+		}
+		*f.color = c
+	}
+	return problems
+}
+
+// applyTheme colors every view. It is called by the manager so views
+// created at any time follow the theme. With accessibility on, the
+// selection and the frame of the focused pane are bold too.
+func applyTheme(g *gocui.Gui) {
+	var emphasis gocui.Attribute
+	if config.Accessibility {
+		emphasis = gocui.AttrBold
+	}
+	g.FgColor, g.BgColor = colors.frame, colors.bg
+	g.SelFgColor, g.SelBgColor = colors.title|emphasis, colors.bg
+	for _, v := range g.Views() {
+		if v.Name() == "status" {
+			v.FgColor, v.BgColor = colors.statusFg, colors.statusBg
+			continue
+		}
+		v.FgColor, v.BgColor = colors.fg, colors.bg
+		v.SelFgColor, v.SelBgColor = colors.selFg|emphasis, colors.selBg
+	}
+}
@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// timestamps prefixes each output line with the time since its command
+// started and adds wall and CPU times to the exit line. It starts out as
+// set in the config and is toggled with the timestamps action.
+var timestamps bool
+
+// timestampPrefix returns the prefix of a line printed elapsed after its
+// command started.
+func timestampPrefix(elapsed time.Duration) string {
+	return fmt.Sprintf("\x1b[38;5;8m%8.3fs\x1b[0m ", elapsed.Seconds())
+}
+
+// timingSummary describes how long a finished command took, in wall and
+// CPU time.
+func timingSummary(state *os.ProcessState, wall time.Duration) string {
+	user, sys := state.UserTime(), state.SystemTime()
+	return fmt.Sprintf("\x1b[38;5;8mwall %s, cpu %s (user %s, sys %s)\x1b[0m",
+		wall.Round(time.Millisecond), (user + sys).Round(time.Millisecond),
+		user.Round(time.Millisecond), sys.Round(time.Millisecond))
+}
+
+// toggleTimestamps turns timestamps on or off for the output that follows.
+func toggleTimestamps(g *gocui.Gui, v *gocui.View) error {
+	timestamps = !timestamps
+	state := "off"
+	if timestamps {
+		state = "on"
+	}
+	return showWarning(g, "timestamps "+state)
+}
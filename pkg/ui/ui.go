@@ -0,0 +1,521 @@
+// Package ui is the terminal interface of imake, built on gocui.
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/jroimartin/gocui"
+)
+
+// Options configure a Run of the UI.
+type Options struct {
+	// Runner discovers and runs the targets shown in the Sidebar.
+	Runner runner.Runner
+	// ANSI selects how escape sequences in command output are handled,
+	// ANSIRender or ANSIStrip.
+	ANSI string
+	// Vim forces the vim keybinding profile regardless of the config.
+	Vim bool
+	// Fresh starts without restoring the last session in the directory.
+	Fresh bool
+	// Theme replaces the theme of the config if not nil.
+	Theme *Theme
+	// Keybindings override the keys of the config, by action name.
+	Keybindings map[string]KeyList
+	// Context quits the UI once it is done. It may be nil.
+	Context context.Context
+	// Target, if set, is selected and run with Args once the UI starts.
+	Target string
+	Args   []string
+	// Runs, if not nil, own the running commands instead of the UI's own,
+	// to share them with an HTTP API server. The runs it starts are shown
+	// in tabs.
+	Runs *runner.RunManager
+}
+
+// backend discovers and runs the targets shown in the Sidebar.
+var backend runner.Runner
+
+// ansiMode is how ANSI escapes in command output are handled.
+var ansiMode = ANSIRender
+
+// restoreLast is set unless the last session should be ignored.
+var restoreLast bool
+
+// runOnStart is the target run as soon as the UI starts, if any, and its
+// arguments.
+var runOnStart struct {
+	target string
+	args   []string
+}
+
+// targets holds every discovered target; targetNames lists their names in
+// the order shown in the Sidebar.
+var (
+	targets     []runner.Target
+	targetNames []string
+)
+
+// Cell places a view on the 12x12 grid of a Layout.
+type Cell struct {
+	Name   string // Name of the view
+	Width  int    // Width in grid units (1-12)
+	Height int    // Height in grid units (1-12)
+	XPos   int    // X position in grid units
+	YPos   int    // Y position in grid units
+}
+
+// Layout divides the screen into views placed on a 12x12 grid.
+type Layout []Cell
+
+// Run discovers the targets of opts.Runner and shows the UI until the user
+// quits. Each Run starts afresh, without the tabs, marks and settings of
+// the last one. If the UI panics, Run gives the terminal back and returns
+// a *CrashError.
+func Run(opts Options) (err error) {
+	resetState()
+	backend = opts.Runner
+	if opts.ANSI != "" {
+		ansiMode = opts.ANSI
+	}
+	restoreLast = !opts.Fresh
+	runOnStart.target, runOnStart.args = opts.Target, opts.Args
+
+	if projectRoot, err = os.Getwd(); err != nil {
+		return err
+	}
+	if err := loadConfig(); err != nil {
+		configErrors = append(configErrors, err.Error())
+	}
+	if opts.Vim {
+		config.Vim = true
+	}
+	if opts.Theme != nil {
+		config.Theme = *opts.Theme
+	}
+	for name, keys := range opts.Keybindings {
+		if config.Keybindings == nil {
+			config.Keybindings = make(map[string]KeyList)
+		}
+		config.Keybindings[name] = keys
+	}
+	logging = config.Log
+	dotenv = config.DotEnv == nil || *config.DotEnv
+	timestamps = config.Timestamps
+	if config.OutputLines != nil {
+		outputLines = max(*config.OutputLines, 0)
+	}
+	configErrors = append(configErrors, loadTheme()...)
+	configErrors = append(configErrors, loadHighlights()...)
+	makeFlags = config.MakeFlags
+	applyMakeFlags()
+	// Discover once the config has set up the runner.
+	if targets, err = backend.Discover(); err != nil {
+		// Offer other build files instead once the UI is up.
+		discoverErr = err
+	}
+	configErrors = append(configErrors, validateKeybindings()...)
+	if err := loadProjectConfig(); err != nil {
+		configErrors = append(configErrors, err.Error())
+	}
+	if err := loadHistory(); err != nil {
+		log.Printf("imake: could not load history: %v", err)
+	}
+	if err := loadStats(); err != nil {
+		log.Printf("imake: could not load target statistics: %v", err)
+	}
+	if err := loadRecent(); err != nil {
+		log.Printf("imake: could not load recent projects: %v", err)
+	}
+	if discoverErr == nil {
+		addRecent(projectRoot)
+	}
+
+	// Log lines would be drawn over by the UI, so they are shown once it
+	// is closed.
+	defer recordLogs()()
+	g, err := gocui.NewGui(gocui.Output256)
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+	// A panic in a callback is returned once the GUI is closed, or the
+	// terminal would be left in raw mode with the stack trace drawn over.
+	crashGui = g
+	defer func() {
+		if r := recover(); r != nil {
+			err = crash(r, debug.Stack())
+		}
+	}()
+
+	if opts.Runs != nil {
+		runs = opts.Runs
+		runs.OnStart(func(r *runner.Run) { showOtherRun(g, r) })
+		defer runs.OnStart(nil)
+	}
+
+	g.InputEsc = true
+	g.Mouse = true
+	g.Highlight = true
+
+	started := false
+
+	g.SetManagerFunc(func(gui *gocui.Gui) error {
+		maxX, maxY := g.Size()
+		// Leave the bottom line to the status bar.
+		err := currentLayout().Apply(g, maxX, maxY-1)
+		if err != nil {
+			return err
+		}
+		if err := layoutStatus(g); err != nil {
+			return err
+		}
+		if err := layoutBusy(g); err != nil {
+			return err
+		}
+		if err := layoutQueue(g); err != nil {
+			return err
+		}
+		if err := layoutCompare(g); err != nil {
+			return err
+		}
+		if err := layoutRunPanes(g); err != nil {
+			return err
+		}
+		if err := layoutSidebar(g); err != nil {
+			return err
+		}
+		if started == false {
+			started = true
+			err = initViews(g)
+			if err != nil {
+				return err
+			}
+		} else {
+
+			err := updateViews(g)
+			if err != nil {
+				return err
+			}
+		}
+		layoutSections()
+		if err := layoutOverlays(g); err != nil {
+			return err
+		}
+		if err := updateStatus(g); err != nil {
+			return err
+		}
+		if err := updateHints(g); err != nil {
+			return err
+		}
+		if err := layoutTooSmall(g); err != nil {
+			return err
+		}
+		applyTheme(g)
+
+		return nil
+	})
+
+	if err := keybindings(g); err != nil {
+		return err
+	}
+
+	defer stopWatching()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go tickStatus(g, stop)
+
+	if opts.Context != nil {
+		go func() {
+			select {
+			case <-opts.Context.Done():
+				g.Update(func(g *gocui.Gui) error { return gocui.ErrQuit })
+			case <-stop:
+			}
+		}()
+	}
+
+	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
+		return err
+	}
+	if err := saveSession(g); err != nil {
+		log.Printf("imake: could not save session: %v", err)
+	}
+	return nil
+}
+
+func updateViews(g *gocui.Gui) error {
+
+	v, err := g.View("Sidebar")
+	if err != nil {
+		return err
+	}
+	line := selectedTarget(v)
+
+	v2, err := g.View("help")
+	if errors.Is(err, gocui.ErrUnknownView) {
+		// Collapsed.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	v2.Clear()
+	doc := ""
+	if target, ok := makefile.Find(targets, line); ok {
+		doc = target.Doc
+		if len(target.Locations) > 1 {
+			var defs []string
+			for _, l := range target.Locations {
+				defs = append(defs, fmt.Sprintf("%s:%d", l.File, l.Line))
+			}
+			doc = fmt.Sprintf("%s (defined in %s)", doc, strings.Join(defs, ", "))
+		} else if target.File != backend.File() {
+			doc = fmt.Sprintf("%s (%s)", doc, target.File)
+		}
+		for _, line := range ruleLegend(target) {
+			doc += "\n\x1b[38;5;8m" + line + "\x1b[0m"
+		}
+		if s := targetStats(target.Name); s != nil {
+			doc += "\n\x1b[38;5;8m" + formatStats(s) + "\x1b[0m"
+		}
+	}
+	fmt.Fprintf(v2, "%s", doc)
+	if doc != "" {
+		v2.Wrap = true
+	}
+	return nil
+}
+
+func initViews(g *gocui.Gui) error {
+	v, err := g.View("Sidebar")
+	if err != nil {
+		return err
+	}
+	setSidebarTitle(v, backend.File())
+	v.Highlight = true
+	setTargets(targets)
+	if err := renderSidebar(v, targetNames, true); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("Sidebar")
+	if err != nil {
+		return err
+	}
+	v2, err := g.View("command")
+	if err != nil {
+		return err
+	}
+	outputView = v2
+	v2.Title = tabStrip()
+	v2.Autoscroll = true
+	var sessionErr error
+	if restoreLast {
+		sessionErr = restoreSession(g)
+	}
+	for _, problem := range configErrors {
+		fmt.Fprintf(v2, "\x1b[33mconfig: %s\x1b[0m\n", problem)
+	}
+	if sessionErr != nil {
+		fmt.Fprintf(v2, "\x1b[33msession: %v\x1b[0m\n", sessionErr)
+	}
+	if discoverErr != nil {
+		if err := openSetup(g, discoverErr); err != nil {
+			return err
+		}
+	} else {
+		checkBuildFiles(g)
+		if runOnStart.target != "" {
+			if err := runStartTarget(g, v); err != nil {
+				return err
+			}
+		}
+	}
+	if config.Watch == nil || *config.Watch {
+		if err := startWatching(g); err != nil {
+			fmt.Fprintf(v2, "\x1b[33mwatch: %v\x1b[0m\n", err)
+		}
+	}
+	return nil
+}
+
+// runStartTarget selects the target given on the command line in the
+// Sidebar v and runs it.
+func runStartTarget(g *gocui.Gui, v *gocui.View) error {
+	target := runOnStart.target
+	if _, ok := makefile.Find(targets, target); !ok {
+		return showWarning(g, fmt.Sprintf("unknown target %q", target))
+	}
+	if err := selectTarget(v, target); err != nil {
+		return err
+	}
+	return runTarget(g, target, runOnStart.args)
+}
+
+// setTargets replaces the discovered targets, dropping marks of targets
+// that no longer exist. Favorites are listed first.
+func setTargets(discovered []runner.Target) {
+	targets = discovered
+	targetNames = make([]string, 0, len(targets))
+	exists := make(map[string]bool)
+	if badgeFilter != "" && !slices.Contains(allBadges(), badgeFilter) {
+		// No target has the badge any more.
+		badgeFilter = ""
+	}
+	for _, name := range project.Favorites {
+		if t, ok := makefile.Find(targets, name); ok && !exists[name] && hasBadge(t) {
+			targetNames = append(targetNames, name)
+			exists[name] = true
+		}
+	}
+	// Favorites stay on top, each part sorted on its own.
+	sortTargets(targetNames)
+	favorites := len(targetNames)
+	for _, target := range targets {
+		hidden := target.Kind != makefile.KindTarget || isIgnored(target.Name)
+		if exists[target.Name] || hidden && !showHidden || !hasBadge(target) {
+			continue
+		}
+		targetNames = append(targetNames, target.Name)
+		exists[target.Name] = true
+	}
+	sortTargets(targetNames[favorites:])
+	kept := marked[:0]
+	for _, name := range marked {
+		if exists[name] {
+			kept = append(kept, name)
+		}
+	}
+	marked = kept
+}
+
+// Apply divides a maxX by maxY area at the top left of the screen of g
+// into the views of the layout, creating them as needed.
+func (l Layout) Apply(g *gocui.Gui, maxX, maxY int) error {
+
+	// Calculate the unit width and height as floats
+	unitX := float64(maxX) / 12.0
+	unitY := float64(maxY) / 12.0
+
+	for _, section := range l {
+		// Calculate the exact width, height, X position, and Y position using floats
+		width := float64(section.Width) * unitX
+		height := float64(section.Height) * unitY
+		xPos := float64(section.XPos) * unitX
+		yPos := float64(section.YPos) * unitY
+
+		// Convert to integers, subtract 1 from width and height to prevent boundary overflow
+		x0, y0 := int(xPos), int(yPos)
+		x1, y1 := int(xPos+width)-1, int(yPos+height)-1
+		// Keep every view drawable however small the terminal gets;
+		// layoutTooSmall hides them below a usable size.
+		x1 = max(x1, x0+minPaneSize)
+		y1 = max(y1, y0+minPaneSize)
+
+		// Create the view using the given name
+		if v, err := g.SetView(section.Name, x0, y0, x1, y1); err != nil {
+			if !errors.Is(err, gocui.ErrUnknownView) {
+				return err
+			}
+
+			v.Title = section.Name // Set the title to the view's name
+		}
+	}
+
+	return nil
+}
+
+func cursorDown(g *gocui.Gui, v *gocui.View) error {
+	v.MoveCursor(0, 1, false)
+	return nil
+}
+
+func cursorUp(g *gocui.Gui, v *gocui.View) error {
+	v.MoveCursor(0, -1, false)
+	return nil
+}
+
+// executeCommand runs the selected target, first offering its argument
+// presets if it has any. On a group header it collapses or expands the
+// group instead.
+func executeCommand(g *gocui.Gui, v *gocui.View) error {
+	if header, err := toggleGroup(g, v); header || err != nil {
+		return err
+	}
+	target := selectedTarget(v)
+	if target == "" {
+		return nil
+	}
+	if len(project.Presets[target]) > 0 {
+		return openPresets(g, target)
+	}
+	return runTarget(g, target, nil)
+}
+
+// argsEditor is the editor of the "args" prompt. It keeps the prompt title
+// in sync with the command that will be run.
+type argsEditor struct {
+	target string
+}
+
+func (e *argsEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	gocui.DefaultEditor.Edit(v, key, ch, mod)
+	v.Title = "$ " + commandLine(e.target, strings.Fields(v.Buffer()))
+}
+
+// openArgsPrompt opens a prompt to type extra arguments and variable
+// overrides (e.g. ENV=staging) for the selected target.
+func openArgsPrompt(g *gocui.Gui, v *gocui.View) error {
+	target := selectedTarget(v)
+	if target == "" {
+		return nil
+	}
+
+	maxX, maxY := g.Size()
+	prompt, err := g.SetView("args", maxX/6, maxY/2-1, maxX*5/6, maxY/2+1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	prompt.Title = "$ " + commandLine(target, nil)
+	prompt.Editable = true
+	prompt.Editor = &argsEditor{target: target}
+	if _, err := g.SetViewOnTop("args"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("args")
+	return err
+}
+
+func executeWithArgs(g *gocui.Gui, v *gocui.View) error {
+	editor, ok := v.Editor.(*argsEditor)
+	if !ok {
+		return nil
+	}
+	args := strings.Fields(v.Buffer())
+	if err := closeArgsPrompt(g, v); err != nil {
+		return err
+	}
+	return runTarget(g, editor.target, args)
+}
+
+func closeArgsPrompt(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView("args"); err != nil {
+		return err
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+func quit(g *gocui.Gui, v *gocui.View) error {
+	return gocui.ErrQuit
+}
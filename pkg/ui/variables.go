@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gshireesh/imake/pkg/makefile"
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/jroimartin/gocui"
+)
+
+// variables holds the variables of the variables panel: all of them, those
+// matching the search, and their values according to the build tool once
+// they were evaluated with Ctrl+E.
+var variables struct {
+	all       []makefile.Variable
+	shown     []makefile.Variable
+	effective map[string]string
+}
+
+// variablesEditor narrows the variables panel on every keystroke.
+type variablesEditor struct {
+	g *gocui.Gui
+}
+
+func (e *variablesEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	gocui.DefaultEditor.Edit(v, key, ch, mod)
+	renderVariables(e.g, strings.TrimSpace(v.Buffer()))
+}
+
+// toggleVariables opens or closes the panel listing the variables of the
+// build file on top of the Command Output view.
+func toggleVariables(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.View("vars"); err == nil {
+		return closeVariables(g, v)
+	}
+	lister, ok := backend.(runner.VariableLister)
+	if !ok {
+		return showWarning(g, fmt.Sprintf("variables: not supported by %s", backend.Name()))
+	}
+	all, err := lister.Variables()
+	if err != nil {
+		return showWarning(g, fmt.Sprintf("variables: %v", err))
+	}
+	if len(all) == 0 {
+		return showWarning(g, fmt.Sprintf("variables: none assigned in %s", backend.File()))
+	}
+	variables.all, variables.effective = all, nil
+
+	x0, y0, x1, y1, err := g.ViewPosition("command")
+	if err != nil {
+		return err
+	}
+	input, err := g.SetView("vars", x0, y0, x1, y0+2)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	input.Title = "Variables (Ctrl+E evaluates with make, Enter edits, Esc closes)"
+	input.Editable = true
+	input.Editor = &variablesEditor{g: g}
+	list, err := g.SetView("varsList", x0, y0+2, x1, y1)
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	list.Highlight = true
+	if err := renderVariables(g, ""); err != nil {
+		return err
+	}
+	if _, err := g.SetViewOnTop("varsList"); err != nil {
+		return err
+	}
+	if _, err := g.SetViewOnTop("vars"); err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView("vars")
+	return err
+}
+
+func closeVariables(g *gocui.Gui, v *gocui.View) error {
+	for _, name := range []string{"vars", "varsList"} {
+		if err := g.DeleteView(name); err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+			return err
+		}
+	}
+	_, err := g.SetCurrentView("Sidebar")
+	return err
+}
+
+// renderVariables lists the variables whose name or value contains query,
+// ignoring case, with their value as written and as expanded, or as
+// evaluated by the build tool.
+func renderVariables(g *gocui.Gui, query string) error {
+	list, err := g.View("varsList")
+	if err != nil {
+		return err
+	}
+	query = strings.ToLower(query)
+	variables.shown = variables.shown[:0]
+	width := 0
+	for _, v := range variables.all {
+		if strings.Contains(strings.ToLower(v.Name+" "+v.Value), query) {
+			variables.shown = append(variables.shown, v)
+			width = max(width, len(v.Name))
+		}
+	}
+
+	list.Clear()
+	for _, v := range variables.shown {
+		line := fmt.Sprintf("%-*s  %s", width, v.Name, v.Value)
+		resolved, evaluated := variables.effective[v.Name]
+		if !evaluated {
+			resolved = v.Expanded
+		}
+		if resolved != v.Value {
+			color := "\x1b[38;5;8m"
+			if evaluated {
+				color = "\x1b[36m"
+			}
+			line += fmt.Sprintf("  %s→ %s\x1b[0m", color, resolved)
+		}
+		fmt.Fprintln(list, line)
+	}
+	if err := list.SetOrigin(0, 0); err != nil {
+		return err
+	}
+	return list.SetCursor(0, 0)
+}
+
+// evaluateVariables asks the build tool for the values of the variables,
+// which evaluates functions such as $(shell ...) too.
+func evaluateVariables(g *gocui.Gui, v *gocui.View) error {
+	values, err := backend.(runner.VariableLister).EffectiveVariables()
+	if err != nil {
+		return showWarning(g, fmt.Sprintf("variables: %v", err))
+	}
+	variables.effective = values
+	return renderVariables(g, strings.TrimSpace(v.Buffer()))
+}
+
+func variablesCursorDown(g *gocui.Gui, v *gocui.View) error {
+	list, err := g.View("varsList")
+	if err != nil {
+		return err
+	}
+	_, cy := list.Cursor()
+	_, oy := list.Origin()
+	if cy+oy+1 >= len(variables.shown) {
+		return nil
+	}
+	return cursorDown(g, list)
+}
+
+func variablesCursorUp(g *gocui.Gui, v *gocui.View) error {
+	list, err := g.View("varsList")
+	if err != nil {
+		return err
+	}
+	return cursorUp(g, list)
+}
+
+// editVariable closes the panel and opens the build file at the first
+// assignment of the highlighted variable.
+func editVariable(g *gocui.Gui, v *gocui.View) error {
+	list, err := g.View("varsList")
+	if err != nil {
+		return err
+	}
+	_, cy := list.Cursor()
+	_, oy := list.Origin()
+	if cy+oy >= len(variables.shown) {
+		return nil
+	}
+	variable := variables.shown[cy+oy]
+	if err := closeVariables(g, v); err != nil {
+		return err
+	}
+	if err := runEditor(g, variable.File, variable.Line); err != nil {
+		return err
+	}
+	return reloadTargets(g)
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// vimKeys are the default keys of the vim profile, replacing the regular
+// defaults of the listed actions.
+var vimKeys = map[string][]string{
+	"cursor_down":   {"down", "j"},
+	"cursor_up":     {"up", "k"},
+	"cursor_top":    {"gg"},
+	"cursor_bottom": {"G"},
+	"scroll_down":   {"down", "j"},
+	"scroll_up":     {"up", "k"},
+	"graph":         {"ctrl+g"},
+	"quit":          {"ctrl+c", "q"},
+}
+
+// doubleTapDelay is how quickly the second key of a sequence like "gg"
+// must follow the first.
+const doubleTapDelay = 500 * time.Millisecond
+
+var lastTap struct {
+	key rune
+	at  time.Time
+}
+
+// doubleTap wraps handler so it only fires when r is pressed twice in a
+// row, as in vim's "gg".
+func doubleTap(r rune, handler func(*gocui.Gui, *gocui.View) error) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if lastTap.key == r && time.Since(lastTap.at) < doubleTapDelay {
+			lastTap.key = 0
+			return handler(g, v)
+		}
+		lastTap.key, lastTap.at = r, time.Now()
+		return nil
+	}
+}
+
+// focusNext moves the focus between the Sidebar and the Command Output.
+func focusNext(g *gocui.Gui, v *gocui.View) error {
+	if v == nil {
+		return nil
+	}
+	switch v.Name() {
+	case "Sidebar":
+		_, err := g.SetCurrentView("command")
+		return err
+	case "command":
+		if compareView != nil {
+			_, err := g.SetCurrentView("compare")
+			return err
+		}
+		_, err := g.SetCurrentView("Sidebar")
+		return err
+	case "compare":
+		_, err := g.SetCurrentView("Sidebar")
+		return err
+	}
+	return nil
+}
+
+// moveSidebar moves the Sidebar cursor by delta entries, staying within
+// the list.
+func moveSidebar(v *gocui.View, delta int) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	line := cy + oy + delta
+	if line >= len(sidebarRows) {
+		line = len(sidebarRows) - 1
+	}
+	if line < 0 {
+		line = 0
+	}
+	return selectLine(v, line)
+}
+
+// cursorTop jumps to the first target, or to the top of the output when
+// the Command Output is focused.
+func cursorTop(g *gocui.Gui, v *gocui.View) error {
+	if v != nil && v.Name() == "Sidebar" {
+		return moveSidebar(v, -len(sidebarRows))
+	}
+	return scrollTop(g, v)
+}
+
+// cursorBottom jumps to the last target, or to the end of the output when
+// the Command Output is focused.
+func cursorBottom(g *gocui.Gui, v *gocui.View) error {
+	if v != nil && v.Name() == "Sidebar" {
+		return moveSidebar(v, len(sidebarRows))
+	}
+	return scrollBottom(g, v)
+}
+
+// scrollLineDown and scrollLineUp scroll the output by a line, or move
+// the end of the selection while selecting.
+func scrollLineDown(g *gocui.Gui, v *gocui.View) error {
+	if selection.active {
+		return moveSelection(outputView, 1)
+	}
+	return scrollOutput(g, 1)
+}
+
+func scrollLineUp(g *gocui.Gui, v *gocui.View) error {
+	if selection.active {
+		return moveSelection(outputView, -1)
+	}
+	return scrollOutput(g, -1)
+}
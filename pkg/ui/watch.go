@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gshireesh/imake/pkg/makefile"
+	"github.com/gshireesh/imake/pkg/runner"
+	"github.com/jroimartin/gocui"
+)
+
+// reloadDelay debounces bursts of file events, e.g. editors writing a
+// file in several steps.
+const reloadDelay = 200 * time.Millisecond
+
+// maxDiffNames is how many added or removed targets the status bar names.
+const maxDiffNames = 4
+
+// watcher reloads the targets when one of the build files changes.
+var watcher struct {
+	sync.Mutex
+	w     *fsnotify.Watcher
+	files map[string]bool // absolute paths of the watched build files
+}
+
+// sourceFiles returns the files the current targets were read from.
+func sourceFiles() []string {
+	if l, ok := backend.(runner.SourceLister); ok && len(l.Sources()) > 0 {
+		return l.Sources()
+	}
+	return []string{backend.File()}
+}
+
+// startWatching watches the build files until stopWatching is called.
+// Files are watched through their directory so that editors replacing
+// the file on save are noticed too.
+func startWatching(g *gocui.Gui) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	watcher.Lock()
+	watcher.w = w
+	watcher.Unlock()
+
+	go func() {
+		defer recoverCrash()
+		var timer *time.Timer
+		for {
+			select {
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				watcher.Lock()
+				watched := watcher.files[ev.Name]
+				watcher.Unlock()
+				if !watched || ev.Op == fsnotify.Chmod {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDelay, func() {
+					g.Update(reloadTargets)
+				})
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				g.Update(func(g *gocui.Gui) error {
+					return showWarning(g, fmt.Sprintf("watch: %v", err))
+				})
+			}
+		}
+	}()
+	return updateWatches()
+}
+
+// updateWatches starts watching the directories of the current build
+// files, e.g. after a reload discovered a new include.
+func updateWatches() error {
+	watcher.Lock()
+	defer watcher.Unlock()
+	if watcher.w == nil {
+		return nil
+	}
+	watcher.files = make(map[string]bool)
+	for _, f := range sourceFiles() {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return err
+		}
+		watcher.files[abs] = true
+		if err := watcher.w.Add(filepath.Dir(abs)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func stopWatching() {
+	watcher.Lock()
+	defer watcher.Unlock()
+	if watcher.w != nil {
+		watcher.w.Close()
+		watcher.w = nil
+	}
+}
+
+// reloadTargets discovers the targets again and refreshes the Sidebar,
+// keeping the selected target selected when it still exists. Targets added
+// or removed since the last discovery are shown in the status bar.
+func reloadTargets(g *gocui.Gui) error {
+	discovered, err := backend.Discover()
+	if err != nil {
+		return showWarning(g, fmt.Sprintf("reload: %v", err))
+	}
+	if len(targets) > 0 {
+		if note := targetsDiff(targets, discovered); note != "" {
+			showNotice(note)
+		}
+	}
+	setTargets(discovered)
+	checkBuildFiles(g)
+	if err := refreshSidebar(g); err != nil {
+		return err
+	}
+	return updateWatches()
+}
+
+// reloadAfterRun reloads the targets if a build file was modified since
+// started, by a target generating part of it for instance.
+func reloadAfterRun(g *gocui.Gui, started time.Time) error {
+	for _, f := range sourceFiles() {
+		if info, err := os.Stat(f); err == nil && !info.ModTime().Before(started) {
+			return reloadTargets(g)
+		}
+	}
+	return nil
+}
+
+// targetsDiff describes the runnable targets added and removed between
+// old and current, or returns "" if there are none.
+func targetsDiff(old, current []runner.Target) string {
+	names := func(ts []runner.Target) map[string]bool {
+		m := make(map[string]bool)
+		for _, t := range ts {
+			if t.Kind == makefile.KindTarget {
+				m[t.Name] = true
+			}
+		}
+		return m
+	}
+	before, after := names(old), names(current)
+	var parts []string
+	for _, t := range current {
+		if after[t.Name] && !before[t.Name] {
+			parts = append(parts, "\x1b[32m+"+t.Name+"\x1b[0m")
+			before[t.Name] = true
+		}
+	}
+	for _, t := range old {
+		if before[t.Name] && !after[t.Name] {
+			parts = append(parts, "\x1b[31m-"+t.Name+"\x1b[0m")
+			after[t.Name] = true
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	if len(parts) > maxDiffNames {
+		parts = append(parts[:maxDiffNames], fmt.Sprintf("and %d more", len(parts)-maxDiffNames))
+	}
+	return "targets: " + strings.Join(parts, " ")
+}
+
+// toggleWatch turns reloading the targets on file changes on or off for
+// this session.
+func toggleWatch(g *gocui.Gui) error {
+	watcher.Lock()
+	watching := watcher.w != nil
+	watcher.Unlock()
+	if watching {
+		stopWatching()
+		return showWarning(g, "watching off")
+	}
+	if err := startWatching(g); err != nil {
+		return showWarning(g, fmt.Sprintf("watch: %v", err))
+	}
+	return showWarning(g, "watching on")
+}
+
+func reloadHandler(g *gocui.Gui, v *gocui.View) error {
+	return reloadTargets(g)
+}
+
+// showWarning prints message in yellow at the end of the Command Output,
+// in the active tab if there is one.
+func showWarning(g *gocui.Gui, message string) error {
+	fmt.Fprintf(currentOutput(), "\x1b[33m%s\x1b[0m\n", message)
+	setMessage(message, true)
+	return nil
+}